@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/client"
+	"github.com/danielboakye/github-repo-stats/pkg/export"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// repoArg returns the only positional argument of fs as a repository name
+func repoArg(fs interface {
+	NArg() int
+	Arg(int) string
+}, usage string) (string, error) {
+	if fs.NArg() != 1 {
+		return "", errors.New("usage: ghstats " + usage)
+	}
+
+	name, err := repository.ParseRepoName(strings.ToLower(strings.TrimSpace(fs.Arg(0))))
+	if err != nil {
+		return "", fmt.Errorf("%w, got %q", err, fs.Arg(0))
+	}
+
+	return name.String(), nil
+}
+
+// parseDate parses an ISO 8601 date or date time flag with parse, empty values are nil
+func parseDate(name, value string, parse func(string) (time.Time, error)) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("-%s %w", name, err)
+	}
+
+	return &t, nil
+}
+
+// commitFilterFlags defines the commit filter flags on fs and returns a func building the filter once fs is parsed
+func commitFilterFlags(fs *flag.FlagSet) func() (repository.CommitFilter, error) {
+	author := fs.String("author", "", "author name, email or github login")
+	since := fs.String("since", "", "ISO 8601 date or date time of the oldest commit")
+	until := fs.String("until", "", "ISO 8601 date or date time of the newest commit")
+	path := fs.String("path", "", "file or directory changed by the commits")
+	excludeMerges := fs.Bool("exclude-merges", false, "skip merge commits")
+	message := fs.String("message", "", "text the commit messages contain")
+	sortOrder := fs.String("sort", "", "desc (newest first, default) or asc")
+
+	return func() (repository.CommitFilter, error) {
+		filter := repository.CommitFilter{
+			Author:        *author,
+			Path:          *path,
+			ExcludeMerges: *excludeMerges,
+			Message:       *message,
+			Sort:          repository.SortOrder(*sortOrder),
+		}
+
+		var err error
+		if filter.Since, err = parseDate("since", *since, repository.ParseDate); err != nil {
+			return filter, err
+		}
+		if filter.Until, err = parseDate("until", *until, repository.ParseUntil); err != nil {
+			return filter, err
+		}
+
+		return filter, nil
+	}
+}
+
+// writeStatus writes status as a table with a single row
+func (c *cli) writeStatus(status *client.RepositoryStatus) error {
+	state, message := "", ""
+	if status.SyncStatus != nil {
+		state, message = string(status.SyncStatus.State), status.SyncStatus.Message
+	}
+
+	return c.write(table{
+		data:   status,
+		header: []string{"repository", "state", "last_synced_at", "message"},
+		rows: [][]string{{
+			status.Repository.RepositoryName,
+			state,
+			formatTime(status.Repository.CommitLastPulledTime),
+			message,
+		}},
+	})
+}
+
+func trackCommand(ctx context.Context, c *cli, args []string) error {
+	const usage = "track <owner/repo>"
+	fs := c.newFlagSet("track", usage)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	repoName, err := repoArg(fs, usage)
+	if err != nil {
+		return err
+	}
+
+	status, err := c.api.TrackRepository(ctx, repoName)
+	if err != nil {
+		return err
+	}
+
+	return c.writeStatus(status)
+}
+
+func statusCommand(ctx context.Context, c *cli, args []string) error {
+	const usage = "status <owner/repo>"
+	fs := c.newFlagSet("status", usage)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	repoName, err := repoArg(fs, usage)
+	if err != nil {
+		return err
+	}
+
+	status, err := c.api.GetRepository(ctx, repoName)
+	if err != nil {
+		return err
+	}
+
+	return c.writeStatus(status)
+}
+
+func reposCommand(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("repos", "repos")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	repos, err := c.api.GetRepositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{
+		data:   repos,
+		header: []string{"repository", "language", "stars", "forks", "last_synced_at"},
+	}
+	for _, repo := range repos {
+		t.rows = append(t.rows, []string{
+			repo.RepositoryName,
+			deref(repo.Language),
+			strconv.Itoa(repo.StarsCount),
+			strconv.Itoa(repo.ForksCount),
+			formatTime(repo.CommitLastPulledTime),
+		})
+	}
+
+	return c.write(t)
+}
+
+func commitsCommand(ctx context.Context, c *cli, args []string) error {
+	const usage = "commits [flags] <owner/repo>"
+	fs := c.newFlagSet("commits", usage)
+	limit := fs.Int("limit", 20, "number of commits to list, at most 100")
+	cursor := fs.String("cursor", "", "cursor of the page to list, printed after every page")
+	commitFilter := commitFilterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	repoName, err := repoArg(fs, usage)
+	if err != nil {
+		return err
+	}
+	filter, err := commitFilter()
+	if err != nil {
+		return err
+	}
+
+	page, err := c.api.GetCommits(ctx, repoName, filter, *limit, *cursor)
+	if err != nil {
+		return err
+	}
+
+	t := table{
+		data:   page.Commits,
+		header: []string{"commit_hash", "date", "author_name", "author_email", "message"},
+	}
+	for _, commit := range page.Commits {
+		t.rows = append(t.rows, []string{
+			commit.CommitHash,
+			formatTime(&commit.Date),
+			commit.AuthorName,
+			commit.AuthorEmail,
+			commit.Message,
+		})
+	}
+	if err := c.write(t); err != nil {
+		return err
+	}
+
+	if page.SyncStatus != nil && page.SyncStatus.Message != "" {
+		fmt.Fprintln(c.stderr, page.SyncStatus.Message)
+	}
+	if page.Next != "" {
+		fmt.Fprintf(c.stderr, "next page: -cursor %s\n", page.Next)
+	}
+
+	return nil
+}
+
+func searchCommand(ctx context.Context, c *cli, args []string) error {
+	const usage = "search [-repo owner/repo,...] [-limit n] <query>"
+	fs := c.newFlagSet("search", usage)
+	repos := fs.String("repo", "", "comma separated repositories to search, all tracked repositories by default")
+	limit := fs.Int("limit", 20, "number of results")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: ghstats " + usage)
+	}
+
+	var repoNames []string
+	if *repos != "" {
+		repoNames = strings.Split(*repos, ",")
+	}
+
+	results, err := c.api.SearchCommits(ctx, strings.Join(fs.Args(), " "), repoNames, *limit, 0)
+	if err != nil {
+		return err
+	}
+
+	t := table{
+		data:   results,
+		header: []string{"repository", "commit_hash", "date", "author_name", "snippet"},
+	}
+	for _, result := range results {
+		t.rows = append(t.rows, []string{
+			result.RepositoryName,
+			result.CommitHash,
+			formatTime(&result.Date),
+			result.AuthorName,
+			result.Snippet,
+		})
+	}
+
+	return c.write(t)
+}
+
+func leaderboardCommand(ctx context.Context, c *cli, args []string) error {
+	fs := c.newFlagSet("leaderboard", "leaderboard [-limit n]")
+	limit := fs.Int("limit", 10, "number of authors")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	stats, err := c.api.GetLeaderBoard(ctx, *limit)
+	if err != nil {
+		return err
+	}
+
+	t := table{
+		data:   stats,
+		header: []string{"author_name", "commit_count"},
+	}
+	for _, stat := range stats {
+		t.rows = append(t.rows, []string{stat.AuthorName, strconv.Itoa(stat.CommitCount)})
+	}
+
+	return c.write(t)
+}
+
+func exportCommand(ctx context.Context, c *cli, args []string) error {
+	const usage = "export [-format ndjson|csv|parquet] [-o file] [filter flags] <owner/repo>"
+	fs := c.newFlagSet("export", usage)
+	formatName := fs.String("format", string(export.FormatNDJSON), "export format: ndjson, csv or parquet")
+	output := fs.String("o", "", "file to write to, stdout by default")
+	commitFilter := commitFilterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	repoName, err := repoArg(fs, usage)
+	if err != nil {
+		return err
+	}
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	filter, err := commitFilter()
+	if err != nil {
+		return err
+	}
+
+	out := c.stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	written, err := c.api.ExportCommits(ctx, repoName, filter, format, out)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stderr, "exported %s as %s, %d bytes\n", repoName, format, written)
+	return nil
+}
@@ -0,0 +1,132 @@
+// Command ghstats is a command line client for the github-repo-stats http api
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/danielboakye/github-repo-stats/pkg/client"
+)
+
+// serverEnvVar represents env variable for the url of the api
+const serverEnvVar = "GHSTATS_URL"
+
+const usage = `usage: ghstats [flags] <command> [command flags] [args]
+
+commands:
+  track <owner/repo>                           start tracking a repository
+  repos                                        list tracked repositories
+  status <owner/repo>                          show the sync status of a tracked repository
+  commits [flags] <owner/repo>                 list commits of a repository, run commits -h for its filters
+  search [-repo owner/repo,...] [-limit n] <query>  search commit messages
+  leaderboard [-limit n]                       show the authors with the most commits
+  export [-format ndjson|csv|parquet] [-o file] <owner/repo>  write all commits of a tracked repository
+
+flags:
+`
+
+// command represents a ghstats subcommand
+type command func(ctx context.Context, c *cli, args []string) error
+
+var commands = map[string]command{
+	"track":       trackCommand,
+	"repos":       reposCommand,
+	"status":      statusCommand,
+	"commits":     commitsCommand,
+	"search":      searchCommand,
+	"leaderboard": leaderboardCommand,
+	"export":      exportCommand,
+}
+
+// cli represents the state shared by commands
+type cli struct {
+	api    *client.Client
+	output string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "ghstats:", err)
+		}
+		stop()
+		os.Exit(1)
+	}
+}
+
+// run parses args and runs the command they select
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("ghstats", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	server := os.Getenv(serverEnvVar)
+	if server == "" {
+		server = "http://localhost:9000"
+	}
+	fs.StringVar(&server, "server", server, "url of the github-repo-stats api, "+serverEnvVar+" by default")
+	output := fs.String("output", outputTable, "output format: table, json or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch *output {
+	case outputTable, outputJSON, outputCSV:
+	default:
+		return fmt.Errorf("unknown output format %q, must be one of: %s, %s, %s", *output, outputTable, outputJSON, outputCSV)
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q, must be one of: %s", fs.Arg(0), strings.Join(names, ", "))
+	}
+
+	c := &cli{
+		api:    client.New(server, client.DefaultConfig()),
+		output: *output,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	return cmd(ctx, c, fs.Args()[1:])
+}
+
+// newFlagSet returns the flag set of the command name
+func (c *cli) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(c.stderr, "usage: ghstats "+usage)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// write writes t to stdout in the output format
+func (c *cli) write(t table) error {
+	return t.write(c.stdout, c.output)
+}
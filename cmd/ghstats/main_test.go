@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielboakye/github-repo-stats/pkg/client"
+	"github.com/danielboakye/github-repo-stats/pkg/client/clienttest"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer serves clienttest.NewServer over http and returns its url
+func newTestServer(t *testing.T, repoName string, count int) string {
+	server := httptest.NewServer(clienttest.NewServer(t, repoName, count))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// runCommand runs ghstats with args against serverURL and returns its stdout and stderr
+func runCommand(serverURL string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), append([]string{"-server", serverURL}, args...), &stdout, &stderr)
+
+	return stdout.String(), stderr.String(), err
+}
+
+// go test -timeout 30s -run ^TestCommits$ ./cmd/ghstats -v
+func TestCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	serverURL := newTestServer(t, "owner/repo", 10)
+
+	stdout, stderr, err := runCommand(serverURL, "commits", "-limit", "4", "owner/repo")
+	require.NoError(err)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(lines, 5)
+	assert.Regexp(`^COMMIT_HASH\s+DATE\s+AUTHOR_NAME\s+AUTHOR_EMAIL\s+MESSAGE$`, lines[0])
+	assert.Contains(lines[1], "commit 9")
+	assert.NotContains(stdout, "body", "only the first line of messages is shown in tables")
+	require.Contains(stderr, "next page: -cursor ")
+
+	cursor := strings.TrimSpace(strings.TrimPrefix(stderr, "next page: -cursor "))
+	stdout, _, err = runCommand(serverURL, "-output", "json", "commits", "-limit", "4", "-cursor", cursor, "owner/repo")
+	require.NoError(err)
+	var commits []*repository.GithubCommit
+	require.NoError(json.Unmarshal([]byte(stdout), &commits))
+	require.Len(commits, 4)
+	assert.Equal("commit 5\n\nbody", commits[0].Message)
+
+	stdout, _, err = runCommand(serverURL, "-output", "csv", "commits", "-author", "bob", "-sort", "asc", "owner/repo")
+	require.NoError(err)
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	require.NoError(err)
+	require.Len(records, 5)
+	assert.Equal([]string{"commit_hash", "date", "author_name", "author_email", "message"}, records[0])
+	assert.Equal([]string{fmt.Sprintf("%040d", 0), "2024-05-01T00:00:00Z", "bob", "bob@example.com", "commit 0\n\nbody"}, records[1])
+}
+
+// go test -timeout 30s -run ^TestLeaderboard$ ./cmd/ghstats -v
+func TestLeaderboard(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	serverURL := newTestServer(t, "owner/repo", 10)
+
+	stdout, _, err := runCommand(serverURL, "-output", "json", "leaderboard", "-limit", "1")
+	require.NoError(err)
+	var stats []repository.CommitStats
+	require.NoError(json.Unmarshal([]byte(stdout), &stats))
+	assert.Equal([]repository.CommitStats{{AuthorName: "alice", CommitCount: 6}}, stats)
+}
+
+// go test -timeout 30s -run ^TestTrack$ ./cmd/ghstats -v
+func TestTrack(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	serverURL := newTestServer(t, "owner/repo", 1)
+
+	stdout, _, err := runCommand(serverURL, "-output", "json", "track", "Owner/New")
+	require.NoError(err)
+	var status client.RepositoryStatus
+	require.NoError(json.Unmarshal([]byte(stdout), &status))
+	assert.Equal("owner/new", status.Repository.RepositoryName)
+	require.NotNil(status.SyncStatus)
+	assert.Equal(response.SyncStatePending, status.SyncStatus.State)
+
+	stdout, _, err = runCommand(serverURL, "-output", "csv", "repos")
+	require.NoError(err)
+	assert.Contains(stdout, "owner/new,")
+	assert.Contains(stdout, "owner/repo,")
+
+	// problems of the api are returned as errors
+	_, _, err = runCommand(serverURL, "track", "owner/new")
+	var problem *response.Problem
+	require.ErrorAs(err, &problem)
+	assert.Equal(409, problem.Status)
+
+	_, _, err = runCommand(serverURL, "status", "owner/missing")
+	require.ErrorAs(err, &problem)
+	assert.Equal(404, problem.Status)
+
+	_, _, err = runCommand(serverURL, "status", "owner")
+	assert.EqualError(err, `repository name must be in the format 'owner/name', got "owner"`)
+}
+
+// go test -timeout 30s -run ^TestExport$ ./cmd/ghstats -v
+func TestExport(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	serverURL := newTestServer(t, "owner/repo", 250)
+
+	stdout, stderr, err := runCommand(serverURL, "export", "owner/repo")
+	require.NoError(err)
+	assert.Equal(fmt.Sprintf("exported owner/repo as ndjson, %d bytes\n", len(stdout)), stderr)
+
+	hashes := map[string]bool{}
+	decoder := json.NewDecoder(strings.NewReader(stdout))
+	for decoder.More() {
+		var commit repository.GithubCommit
+		require.NoError(decoder.Decode(&commit))
+		hashes[commit.CommitHash] = true
+	}
+	assert.Len(hashes, 250)
+
+	stdout, _, err = runCommand(serverURL, "export", "-format", "csv", "owner/repo")
+	require.NoError(err)
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	require.NoError(err)
+	assert.Len(records, 251)
+
+	stdout, _, err = runCommand(serverURL, "export", "-format", "csv", "-author", "bob", "owner/repo")
+	require.NoError(err)
+	records, err = csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	require.NoError(err)
+	assert.Len(records, 85)
+
+	stdout, _, err = runCommand(serverURL, "export", "-format", "parquet", "owner/repo")
+	require.NoError(err)
+	assert.True(strings.HasPrefix(stdout, "PAR1"))
+
+	_, _, err = runCommand(serverURL, "export", "-format", "xml", "owner/repo")
+	assert.EqualError(err, `unknown export format "xml", must be one of: ndjson, csv, parquet`)
+
+	// exporting does not track repositories
+	_, _, err = runCommand(serverURL, "export", "owner/missing")
+	var problem *response.Problem
+	require.ErrorAs(err, &problem)
+	assert.Equal(404, problem.Status)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+// maxCellLength is the number of characters a table cell is truncated to
+const maxCellLength = 72
+
+// table represents the output of a command, data is written as is in json output
+// and header and rows in table and csv output
+type table struct {
+	data   interface{}
+	header []string
+	rows   [][]string
+}
+
+// write writes t to w in format
+func (t table) write(w io.Writer, format string) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t.data)
+	case outputCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(t.header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(t.rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	default:
+		writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.ToUpper(strings.Join(t.header, "\t")))
+		for _, row := range t.rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = tableCell(cell)
+			}
+			fmt.Fprintln(writer, strings.Join(cells, "\t"))
+		}
+		return writer.Flush()
+	}
+}
+
+// tableCell returns the first line of s truncated to maxCellLength characters
+func tableCell(s string) string {
+	s, _, _ = strings.Cut(s, "\n")
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= maxCellLength {
+		return s
+	}
+
+	return string([]rune(s)[:maxCellLength-1]) + "…"
+}
+
+// formatTime formats t for output, nil times are empty
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// deref returns the value of s, nil strings are empty
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielboakye/github-repo-stats/pkg/config"
+)
+
+const configUsage = "usage: config print"
+
+// runConfig handles the config subcommand
+func runConfig(cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("unknown config action, %s", configUsage)
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		return err
+	}
+
+	// the effective config is printed even when it is invalid to help finding the setting at fault
+	return cfg.Validate()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/config"
+	"github.com/danielboakye/github-repo-stats/pkg/export"
+	"github.com/danielboakye/github-repo-stats/pkg/httpserver"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+const exportUsage = "usage: export [-format ndjson|csv|parquet] [-o file] [filter flags] <owner/repo>"
+
+// runExport handles the export subcommand
+func runExport(ctx context.Context, cfg config.Config, args []string, logger *slog.Logger) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatName := fs.String("format", string(export.FormatNDJSON), "output format: ndjson, csv or parquet")
+	output := fs.String("o", "", "file to write to, stdout by default")
+	author := fs.String("author", "", "author name, email or github login")
+	since := fs.String("since", "", "ISO 8601 date or date time of the oldest commit")
+	until := fs.String("until", "", "ISO 8601 date or date time of the newest commit")
+	path := fs.String("path", "", "file or directory changed by the commits")
+	excludeMerges := fs.Bool("exclude-merges", false, "skip merge commits")
+	message := fs.String("message", "", "text the commit messages contain")
+	sortOrder := fs.String("sort", "", "desc (newest first, default) or asc")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(exportUsage)
+	}
+	repoName, err := httpserver.NormalizeRepoName(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	filter := repository.CommitFilter{
+		Author:        *author,
+		Path:          *path,
+		ExcludeMerges: *excludeMerges,
+		Message:       *message,
+		Sort:          repository.SortOrder(*sortOrder),
+	}
+	if filter.Since, err = parseDateFlag("since", *since, repository.ParseDate); err != nil {
+		return err
+	}
+	if filter.Until, err = parseDateFlag("until", *until, repository.ParseUntil); err != nil {
+		return err
+	}
+	if err := filter.Normalize(); err != nil {
+		return fmt.Errorf("-%w", err)
+	}
+
+	store, err := openStore(ctx, cfg.Database, logger)
+	if err != nil {
+		return err
+	}
+	if store.conn != nil {
+		defer store.conn.Close()
+	}
+
+	githubRepo, err := store.repo.GetRepositoryByName(ctx, repoName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository %s is not tracked", repoName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get repository (%s): %w", repoName, err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer out.Close()
+	}
+	buffered := bufio.NewWriter(out)
+	writer, err := export.NewWriter(buffered, format)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = store.repo.StreamCommitsByRepository(ctx, githubRepo.ID, filter, func(commit *repository.GithubCommit) error {
+		count++
+		return writer.Write(commit)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export commits: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d commits of %s\n", count, repoName)
+	return nil
+}
+
+// parseDateFlag parses an ISO 8601 date or date time flag with parse, empty values are nil
+func parseDateFlag(name, value string, parse func(string) (time.Time, error)) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("-%s %w", name, err)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/config"
+	"github.com/danielboakye/github-repo-stats/pkg/httpserver"
+	"github.com/danielboakye/github-repo-stats/pkg/services/gitimport"
+)
+
+const importUsage = "usage: import [-rev revision] [-url prefix] <path> <owner/repo>"
+
+// runImport handles the import subcommand, progress is written to stderr
+func runImport(ctx context.Context, cfg config.Config, args []string, logger *slog.Logger) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	rev := fs.String("rev", "HEAD", "revision whose history is imported, e.g. a branch or tag")
+	urlPrefix := fs.String("url", "", "commit url prefix the hashes are appended to, the commit urls of the repository on github.com, gitlab.com, gitea.com or bitbucket.org by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New(importUsage)
+	}
+	path := fs.Arg(0)
+	repoName, err := httpserver.NormalizeRepoName(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(ctx, cfg.Database, logger)
+	if err != nil {
+		return err
+	}
+	if store.conn != nil {
+		defer store.conn.Close()
+	}
+
+	importer := gitimport.NewImporter(store.repo, logger, gitimport.DefaultConfig())
+	start := time.Now()
+	total, err := importer.Import(ctx, path, repoName, gitimport.Options{Rev: *rev, URLPrefix: *urlPrefix}, func(p gitimport.Progress) {
+		fmt.Fprintf(os.Stderr, "%s: %d commits\n", repoName, p.Commits)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import %s after %d commits: %w", repoName, total, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "imported %d commits of %s from %s in %s\n", total, repoName, path, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -4,65 +4,110 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 
-	"github.com/danielboakye/github-repo-stats/pkg/db/postgres"
-	"github.com/danielboakye/github-repo-stats/pkg/httpserver"
-	"github.com/danielboakye/github-repo-stats/pkg/services/githubrepo"
+	"github.com/danielboakye/github-repo-stats/pkg/config"
+	"github.com/danielboakye/github-repo-stats/pkg/logging"
 )
 
 var (
-	httpHost string
-	httpPort string
-
-	commitSinceDateString string
-	defaultSinceDate      string
+	configPath  string
+	configFlags *config.Flags
 )
 
 func init() {
-	// default since date
-	durationOneYear := time.Hour * 24 * 365
-	defaultSinceDate = time.Now().Add(-durationOneYear).Format(githubrepo.ISODateFormat)
-
-	flag.StringVar(&httpHost, "host", "localhost", "The host address where the application will run")
-	flag.StringVar(&httpPort, "port", "9000", "The http server port")
-	flag.StringVar(&commitSinceDateString, "since", defaultSinceDate, "date to start pulling commits from")
+	flag.StringVar(&configPath, "config", os.Getenv(config.ConfigEnvVar), "path to a yaml config file, settings in it are overridden by env variables and flags")
+	configFlags = config.RegisterFlags(flag.CommandLine)
 }
 
+const usage = `usage: github-repo-stats [flags] <command> [args]
+
+commands:
+  all                          run the api and the watcher, the default command
+  serve                        run the api only
+  worker                       run the watcher only, repositories tracked through the api are picked up by polling
+  sync <owner/repo>            sync a repository once in the foreground, tracking it first if needed
+  import <path> <owner/repo>   import the commits of a local git clone or bare repository, run import -h for its flags
+  migrate [action]             manage database migrations: up, down [n], status or version
+  export [flags] <owner/repo>  write the commits of a repository as ndjson, csv or parquet, run export -h for its flags
+  config print                 print the effective configuration
+
+flags:
+`
+
 func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	if commitSinceDateString == "" {
-		commitSinceDateString = defaultSinceDate
-	}
-	commitSinceDate, err := time.Parse(githubrepo.ISODateFormat, commitSinceDateString)
+	logger := newLogger(os.Stdout, slog.LevelInfo)
+
+	cfg, err := config.Load(configPath, os.Getenv, configFlags)
 	if err != nil {
-		log.Fatal("failed to parse 'since' flag into iso date format")
+		log.Fatal(err)
 	}
 
-	conn, err := postgres.NewConnection(os.Getenv(postgres.PostgresURLEnvVar))
-	if err != nil {
-		log.Fatal("failed to start postgres db: ", err)
+	command, args := flag.Arg(0), []string{}
+	if flag.NArg() > 1 {
+		args = flag.Args()[1:]
+	}
+
+	if command == "config" {
+		if err := runConfig(cfg, args); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
 	}
 
-	postgresRepo := postgres.NewRepository(conn)
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	githubSvc := githubrepo.NewService(postgresRepo, logger, commitSinceDate)
-	if err := githubSvc.Start(context.Background()); err != nil {
-		log.Fatal("failed to start background service: ", err)
+	if err := run(ctx, command, args, cfg, logger); err != nil {
+		stop()
+		log.Fatal(err)
 	}
+}
 
-	logger.Info("starting-watcher",
-		slog.String("since", commitSinceDate.Format(githubrepo.ISODateFormat)),
-	)
+// run runs command until it completes or ctx is cancelled
+func run(ctx context.Context, command string, args []string, cfg config.Config, logger *slog.Logger) error {
+	// one-shot commands only log problems, to stderr, so their output stays readable
+	oneShotLogger := newLogger(os.Stderr, slog.LevelWarn)
 
-	addr := fmt.Sprintf("%s:%s", httpHost, httpPort)
-	apiServer := httpserver.NewServer(addr, postgresRepo, githubSvc, logger)
-	if err := apiServer.Start(); err != nil {
-		log.Fatal("failed to start http server on: ", addr)
+	switch command {
+	case "", "all":
+		return serve(ctx, cfg, modeAll, logger)
+	case "serve":
+		return serve(ctx, cfg, modeAPI, logger)
+	case "worker":
+		return serve(ctx, cfg, modeWorker, logger)
+	case "sync":
+		return runSync(ctx, cfg, args, oneShotLogger)
+	case "import":
+		return runImport(ctx, cfg, args, oneShotLogger)
+	case "migrate":
+		if err := runMigrate(ctx, cfg.Database, args, logger); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+		return nil
+	case "export":
+		return runExport(ctx, cfg, args, oneShotLogger)
+	default:
+		return fmt.Errorf("unknown command %q, run with -h for usage", command)
 	}
 }
+
+// newLogger returns a json logger writing records of level and above to w
+func newLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(logging.NewContextHandler(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+}
@@ -0,0 +1,208 @@
+// Package client is a typed client of the github-repo-stats http api
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/response"
+)
+
+// Config represents the settings of a Client
+type Config struct {
+	// HTTPClient sends the requests, http.DefaultClient is used when it is nil
+	HTTPClient *http.Client
+	// Attempts is the number of times a request is sent before its error is returned
+	Attempts int
+	// Backoff is the wait before the first retry, it doubles on every retry.
+	// a Retry-After header of the response takes precedence
+	Backoff time.Duration
+}
+
+// DefaultConfig returns the settings used when none are configured
+func DefaultConfig() Config {
+	return Config{
+		HTTPClient: http.DefaultClient,
+		Attempts:   3,
+		Backoff:    500 * time.Millisecond,
+	}
+}
+
+// Client represents a client of the github-repo-stats http api, it is safe for concurrent use
+type Client struct {
+	baseURL string
+	config  Config
+}
+
+// New returns a Client of the api served at baseURL, e.g. http://localhost:9000
+func New(baseURL string, config Config) *Client {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	if config.Attempts < 1 {
+		config.Attempts = 1
+	}
+
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		config:  config,
+	}
+}
+
+// envelope represents a successful response of the api, data is decoded by the caller
+type envelope struct {
+	Data  json.RawMessage `json:"data"`
+	Meta  *response.Meta  `json:"meta"`
+	Links *response.Links `json:"links"`
+}
+
+// get decodes the data of the response to a GET request for path into data
+func (c *Client) get(ctx context.Context, path string, query url.Values, data interface{}) (envelope, error) {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	return c.do(ctx, http.MethodGet, path, nil, data)
+}
+
+// post decodes the data of the response to a POST request of body to path into data
+func (c *Client) post(ctx context.Context, path string, body, data interface{}) (envelope, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return envelope{}, fmt.Errorf("failed to encode request body: %w", err)
+	}
+
+	return c.do(ctx, http.MethodPost, path, b, data)
+}
+
+// do sends the request and decodes the response into data
+func (c *Client) do(ctx context.Context, method, path string, body []byte, data interface{}) (envelope, error) {
+	var env envelope
+	resp, err := c.sendWithRetries(ctx, method, path, body, "application/json")
+	if err != nil {
+		return env, err
+	}
+	defer resp.Body.Close()
+
+	return env, decode(resp, &env, data)
+}
+
+// sendWithRetries sends the request until it succeeds or the attempts are exhausted and returns the last response.
+// 429 responses are always retried, server errors and transport errors only for GET requests
+// since a POST may have been applied before it failed
+func (c *Client) sendWithRetries(ctx context.Context, method, path string, body []byte, accept string) (*http.Response, error) {
+	backoff := c.config.Backoff
+	for attempt := 1; ; attempt++ {
+		wait := backoff
+		resp, err := c.send(ctx, method, path, body, accept)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || method != http.MethodGet || attempt == c.config.Attempts {
+				return nil, err
+			}
+		case retryable(method, resp.StatusCode) && attempt < c.config.Attempts:
+			wait = retryAfter(resp.Header.Get("Retry-After"), backoff)
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body []byte, accept string) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.config.HTTPClient.Do(req)
+}
+
+// decode decodes a problem of a failed response as the error, or the envelope and its data otherwise
+func decode(resp *http.Response, env *envelope, data interface{}) error {
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeProblem(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(env); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if data != nil {
+		if err := json.Unmarshal(env.Data, data); err != nil {
+			return fmt.Errorf("failed to decode response data: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// decodeProblem returns the problem of a failed response
+func decodeProblem(resp *http.Response) *response.Problem {
+	problem := &response.Problem{}
+	if err := json.NewDecoder(resp.Body).Decode(problem); err != nil || problem.Status == 0 {
+		// the response did not come from the api, e.g. a proxy in front of it
+		return &response.Problem{
+			Title:  http.StatusText(resp.StatusCode),
+			Status: resp.StatusCode,
+			Detail: resp.Status,
+		}
+	}
+
+	return problem
+}
+
+// retryable reports whether a response of status to a method request is retried
+func retryable(method string, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+
+	return method == http.MethodGet && status >= http.StatusInternalServerError
+}
+
+// retryAfter returns the wait of a Retry-After header in seconds or as a date, backoff when it is missing
+func retryAfter(header string, backoff time.Duration) time.Duration {
+	if header == "" {
+		return backoff
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(time.Until(t), 0)
+	}
+
+	return backoff
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
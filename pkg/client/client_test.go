@@ -0,0 +1,292 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/client/clienttest"
+	"github.com/danielboakye/github-repo-stats/pkg/export"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns a client of handler which retries without waiting
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config := DefaultConfig()
+	config.Backoff = time.Millisecond
+	return New(server.URL, config)
+}
+
+// go test -timeout 30s -run ^TestGetCommits$ ./pkg/client -v
+func TestGetCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	c := newTestClient(t, clienttest.NewServer(t, "owner/repo", 10))
+
+	page, err := c.GetCommits(ctx, "owner/repo", repository.CommitFilter{}, 4, "")
+	require.NoError(err)
+	require.Len(page.Commits, 4)
+	assert.Equal(10, page.Total)
+	assert.Equal("commit 9\n\nbody", page.Commits[0].Message)
+	assert.NotEmpty(page.Next)
+	assert.Empty(page.Prev)
+	require.NotNil(page.SyncStatus)
+	assert.Equal(response.SyncStateSynced, page.SyncStatus.State)
+
+	page, err = c.GetCommits(ctx, "owner/repo", repository.CommitFilter{}, 4, page.Next)
+	require.NoError(err)
+	require.Len(page.Commits, 4)
+	assert.Equal("commit 5\n\nbody", page.Commits[0].Message)
+	assert.NotEmpty(page.Prev)
+
+	since := time.Date(2024, 5, 1, 0, 2, 0, 0, time.UTC)
+	filter := repository.CommitFilter{Author: "bob", Since: &since, Sort: repository.SortOldest}
+	page, err = c.GetCommits(ctx, "owner/repo", filter, 0, "")
+	require.NoError(err)
+	require.Len(page.Commits, 3)
+	assert.Equal("commit 3\n\nbody", page.Commits[0].Message)
+	assert.Equal("commit 9\n\nbody", page.Commits[2].Message)
+
+	_, err = c.GetCommits(ctx, "owner", repository.CommitFilter{}, 0, "")
+	var problem *response.Problem
+	require.ErrorAs(err, &problem)
+	assert.Equal(http.StatusBadRequest, problem.Status)
+	assert.Equal(response.CodeInvalidRequest, problem.Code)
+}
+
+// go test -timeout 30s -run ^TestCommitIterator$ ./pkg/client -v
+func TestCommitIterator(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	c := newTestClient(t, clienttest.NewServer(t, "owner/repo", 25))
+
+	for _, pageSize := range []int{1, 7, 25, 100} {
+		var messages []string
+		it := c.Commits(ctx, "owner/repo", repository.CommitFilter{Sort: repository.SortOldest}, pageSize)
+		for it.Next() {
+			messages = append(messages, it.Commit().Message)
+		}
+		assert.NoError(it.Err(), "page size %d", pageSize)
+		assert.Len(messages, 25, "page size %d", pageSize)
+		if len(messages) == 25 {
+			assert.Equal("commit 0\n\nbody", messages[0], "page size %d", pageSize)
+			assert.Equal("commit 24\n\nbody", messages[24], "page size %d", pageSize)
+		}
+		assert.False(it.Next(), "iterators stay done")
+	}
+
+	// the first sync of a newly tracked repo is pending
+	it := c.Commits(ctx, "owner/new", repository.CommitFilter{}, 10)
+	assert.False(it.Next())
+	assert.NoError(it.Err())
+
+	it = c.Commits(ctx, "owner", repository.CommitFilter{}, 10)
+	assert.False(it.Next())
+	var problem *response.Problem
+	assert.ErrorAs(it.Err(), &problem)
+}
+
+// go test -timeout 30s -run ^TestExportCommits$ ./pkg/client -v
+func TestExportCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	c := newTestClient(t, clienttest.NewServer(t, "owner/repo", 12))
+
+	var buf bytes.Buffer
+	written, err := c.ExportCommits(ctx, "owner/repo", repository.CommitFilter{Author: "bob", Sort: repository.SortOldest}, export.FormatCSV, &buf)
+	require.NoError(err)
+	assert.Equal(int64(buf.Len()), written)
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(err)
+	require.Len(records, 5)
+	assert.Equal(export.CSVHeader, records[0])
+	assert.Equal(fmt.Sprintf("%040d", 0), records[1][0])
+
+	buf.Reset()
+	_, err = c.ExportCommits(ctx, "owner/missing", repository.CommitFilter{}, export.FormatNDJSON, &buf)
+	var problem *response.Problem
+	require.ErrorAs(err, &problem)
+	assert.Equal(http.StatusNotFound, problem.Status)
+	assert.Zero(buf.Len())
+}
+
+// go test -timeout 30s -run ^TestLeaderBoardAndSearch$ ./pkg/client -v
+func TestLeaderBoardAndSearch(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	c := newTestClient(t, clienttest.NewServer(t, "owner/repo", 8))
+
+	stats, err := c.GetLeaderBoard(ctx, 2)
+	require.NoError(err)
+	assert.Equal([]repository.CommitStats{{AuthorName: "alice", CommitCount: 5}, {AuthorName: "bob", CommitCount: 3}}, stats)
+
+	results, err := c.SearchCommits(ctx, "body", []string{"owner/repo"}, 3, 0)
+	require.NoError(err)
+	assert.Len(results, 3)
+	for _, result := range results {
+		assert.Equal("owner/repo", result.RepositoryName)
+	}
+}
+
+// go test -timeout 30s -run ^TestRepositories$ ./pkg/client -v
+func TestRepositories(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	c := newTestClient(t, clienttest.NewServer(t, "owner/repo", 1))
+
+	status, err := c.TrackRepository(ctx, "owner/new")
+	require.NoError(err)
+	assert.Equal("owner/new", status.Repository.RepositoryName)
+	assert.Equal(response.SyncStatePending, status.SyncStatus.State)
+
+	_, err = c.TrackRepository(ctx, "owner/new")
+	var problem *response.Problem
+	require.ErrorAs(err, &problem)
+	assert.Equal(response.CodeConflict, problem.Code)
+
+	status, err = c.GetRepository(ctx, "owner/repo")
+	require.NoError(err)
+	assert.Equal("owner/repo", status.Repository.RepositoryName)
+	assert.Equal(response.SyncStateSynced, status.SyncStatus.State)
+
+	_, err = c.GetRepository(ctx, "owner/missing")
+	require.ErrorAs(err, &problem)
+	assert.Equal(http.StatusNotFound, problem.Status)
+
+	repos, err := c.GetRepositories(ctx)
+	require.NoError(err)
+	assert.Len(repos, 2)
+}
+
+// failFirst responds with status to the first n requests and passes the rest to next
+func failFirst(n int32, status int, next http.Handler) (http.Handler, *atomic.Int32) {
+	requests := &atomic.Int32{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= n {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), requests
+}
+
+// go test -timeout 30s -run ^TestRetries$ ./pkg/client -v
+func TestRetries(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	server := clienttest.NewServer(t, "owner/repo", 3)
+
+	testCases := []struct {
+		name     string
+		failures int32
+		status   int
+		call     func(c *Client) error
+		requests int32
+		err      int
+	}{
+		{
+			name:     "get retried after server errors",
+			failures: 2,
+			status:   http.StatusBadGateway,
+			call: func(c *Client) error {
+				_, err := c.GetLeaderBoard(ctx, 1)
+				return err
+			},
+			requests: 3,
+		},
+		{
+			name:     "attempts exhausted",
+			failures: 3,
+			status:   http.StatusServiceUnavailable,
+			call: func(c *Client) error {
+				_, err := c.GetRepositories(ctx)
+				return err
+			},
+			requests: 3,
+			err:      http.StatusServiceUnavailable,
+		},
+		{
+			name:     "post retried when rate limited",
+			failures: 1,
+			status:   http.StatusTooManyRequests,
+			call: func(c *Client) error {
+				_, err := c.TrackRepository(ctx, "owner/new")
+				return err
+			},
+			requests: 2,
+		},
+		{
+			name:     "post not retried after server errors",
+			failures: 1,
+			status:   http.StatusInternalServerError,
+			call: func(c *Client) error {
+				_, err := c.TrackRepository(ctx, "owner/other")
+				return err
+			},
+			requests: 1,
+			err:      http.StatusInternalServerError,
+		},
+		{
+			name:     "client errors not retried",
+			failures: 1,
+			status:   http.StatusNotFound,
+			call: func(c *Client) error {
+				_, err := c.GetRepository(ctx, "owner/repo")
+				return err
+			},
+			requests: 1,
+			err:      http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		handler, requests := failFirst(tc.failures, tc.status, server)
+		err := tc.call(newTestClient(t, handler))
+		assert.Equal(tc.requests, requests.Load(), tc.name)
+
+		if tc.err == 0 {
+			assert.NoError(err, tc.name)
+			continue
+		}
+		var problem *response.Problem
+		if assert.ErrorAs(err, &problem, tc.name) {
+			assert.Equal(tc.err, problem.Status, tc.name)
+		}
+	}
+}
+
+// go test -timeout 30s -run ^TestRetryCancelled$ ./pkg/client -v
+func TestRetryCancelled(t *testing.T) {
+	assert := assert.New(t)
+	handler, requests := failFirst(10, http.StatusServiceUnavailable, http.NotFoundHandler())
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := New(server.URL, Config{Attempts: 10, Backoff: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetLeaderBoard(ctx, 1)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(int32(1), requests.Load())
+}
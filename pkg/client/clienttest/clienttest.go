@@ -0,0 +1,52 @@
+// Package clienttest provides the api server the client and the command line client are tested against
+package clienttest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/db/memory"
+	"github.com/danielboakye/github-repo-stats/pkg/httpserver"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/services/githubrepo"
+	"github.com/stretchr/testify/require"
+)
+
+// NewServer returns an api server backed by a memory repository with count synced commits of repoName.
+// commit i is made at minute i of 2024-05-01 with the message "commit i\n\nbody", every third commit from
+// the first one is authored by bob and the others by alice
+func NewServer(t *testing.T, repoName string, count int) *httpserver.Server {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+
+	repoID, err := repo.CreateRepository(ctx, repoName)
+	require.NoError(t, err)
+	for i := 0; i < count; i++ {
+		author := "alice"
+		if i%3 == 0 {
+			author = "bob"
+		}
+		err := repo.SaveCommit(ctx, repository.GithubCommit{
+			RepositoryID: repoID,
+			CommitHash:   fmt.Sprintf("%040d", i),
+			Message:      fmt.Sprintf("commit %d\n\nbody", i),
+			AuthorName:   author,
+			AuthorEmail:  author + "@example.com",
+			Date:         time.Date(2024, 5, 1, 0, i, 0, 0, time.UTC),
+			URL:          "https://github.com/" + repoName,
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.UpdateCommitLastSyncTime(ctx, repoID, time.Now()))
+
+	config := githubrepo.DefaultConfig()
+	config.Since = time.Now()
+	svc := githubrepo.NewService(repo, logger, config)
+
+	return httpserver.NewServer("", httpserver.DefaultTimeouts(), repo, svc, httpserver.Options{API: true}, logger)
+}
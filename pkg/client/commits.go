@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/export"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/response"
+)
+
+// CommitPage represents a page of commits of a repository
+type CommitPage struct {
+	Commits []*repository.GithubCommit
+	// Total is the number of commits matching the filter across all pages
+	Total int
+	// Next and Prev are the cursors of the neighbouring pages, empty when there is none
+	Next string
+	Prev string
+	// SyncStatus tells whether the commits of the repository have been pulled yet
+	SyncStatus *response.SyncStatus
+}
+
+// GetCommits returns a page of up to limit commits of repoName matching filter, starting at cursor
+// or at the first page when cursor is empty. the repository is tracked if it is not, in which case the page
+// is empty until its first sync completes
+func (c *Client) GetCommits(ctx context.Context, repoName string, filter repository.CommitFilter, limit int, cursor string) (*CommitPage, error) {
+	query := commitFilterQuery(filter)
+	query.Set("repoName", repoName)
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	page := &CommitPage{}
+	env, err := c.get(ctx, "/v1/commits", query, &page.Commits)
+	if err != nil {
+		return nil, err
+	}
+	if env.Meta != nil {
+		if env.Meta.Total != nil {
+			page.Total = *env.Meta.Total
+		}
+		if env.Meta.Cursor != nil {
+			page.Next, page.Prev = env.Meta.Cursor.Next, env.Meta.Cursor.Prev
+		}
+		page.SyncStatus = env.Meta.SyncStatus
+	}
+
+	return page, nil
+}
+
+// commitFilterQuery returns the query params of filter
+func commitFilterQuery(filter repository.CommitFilter) url.Values {
+	query := url.Values{}
+	if filter.Author != "" {
+		query.Set("author", filter.Author)
+	}
+	if filter.Since != nil {
+		query.Set("since", filter.Since.UTC().Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		query.Set("until", filter.Until.UTC().Format(time.RFC3339))
+	}
+	if filter.Path != "" {
+		query.Set("path", filter.Path)
+	}
+	if filter.ExcludeMerges {
+		query.Set("excludeMerges", "true")
+	}
+	if filter.Message != "" {
+		query.Set("message", filter.Message)
+	}
+	if filter.Sort != "" {
+		query.Set("sort", string(filter.Sort))
+	}
+
+	return query
+}
+
+// CommitIterator iterates over every commit of a repository matching a filter, fetching a page at a time.
+//
+//	it := c.Commits(ctx, "owner/name", repository.CommitFilter{}, 100)
+//	for it.Next() {
+//		commit := it.Commit()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type CommitIterator struct {
+	client   *Client
+	ctx      context.Context
+	repoName string
+	filter   repository.CommitFilter
+	pageSize int
+
+	page    []*repository.GithubCommit
+	commit  *repository.GithubCommit
+	cursor  string
+	fetched bool
+	err     error
+}
+
+// Commits returns an iterator over the commits of repoName matching filter, pageSize commits are requested at once
+func (c *Client) Commits(ctx context.Context, repoName string, filter repository.CommitFilter, pageSize int) *CommitIterator {
+	return &CommitIterator{
+		client:   c,
+		ctx:      ctx,
+		repoName: repoName,
+		filter:   filter,
+		pageSize: pageSize,
+	}
+}
+
+// Next advances to the next commit, it returns false when there are no more commits or a request failed
+func (it *CommitIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || (it.fetched && it.cursor == "") {
+			it.commit = nil
+			return false
+		}
+
+		page, err := it.client.GetCommits(it.ctx, it.repoName, it.filter, it.pageSize, it.cursor)
+		if err != nil {
+			it.err = err
+			continue
+		}
+		it.page, it.cursor, it.fetched = page.Commits, page.Next, true
+	}
+
+	it.commit, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Commit returns the current commit
+func (it *CommitIterator) Commit() *repository.GithubCommit {
+	return it.commit
+}
+
+// Err returns the error which stopped the iteration, nil when every commit was returned
+func (it *CommitIterator) Err() error {
+	return it.err
+}
+
+// ExportCommits writes every commit of the tracked repository repoName matching filter to w in format as it is streamed
+// and returns the number of bytes written. the export is not retried once it started, a failure halfway truncates it
+func (c *Client) ExportCommits(ctx context.Context, repoName string, filter repository.CommitFilter, format export.Format, w io.Writer) (int64, error) {
+	query := commitFilterQuery(filter)
+	query.Set("repoName", repoName)
+
+	resp, err := c.sendWithRetries(ctx, http.MethodGet, "/v1/commits/export?"+query.Encode(), nil, format.ContentType())
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return 0, decodeProblem(resp)
+	}
+
+	return io.Copy(w, resp.Body)
+}
+
+// SearchCommits returns up to limit commits whose messages match q, ranked by relevance and skipping offset results.
+// every tracked repository is searched when repoNames is empty
+func (c *Client) SearchCommits(ctx context.Context, q string, repoNames []string, limit, offset int) ([]*repository.CommitSearchResult, error) {
+	query := url.Values{}
+	query.Set("q", q)
+	if len(repoNames) > 0 {
+		query.Set("repoName", strings.Join(repoNames, ","))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+
+	var results []*repository.CommitSearchResult
+	if _, err := c.get(ctx, "/v1/commits/search", query, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetLeaderBoard returns the limit authors with the most commits across tracked repositories
+func (c *Client) GetLeaderBoard(ctx context.Context, limit int) ([]repository.CommitStats, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var stats []repository.CommitStats
+	if _, err := c.get(ctx, "/v1/leaderboard", query, &stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/response"
+)
+
+// RepositoryStatus represents a tracked repository and how far its sync has progressed
+type RepositoryStatus struct {
+	Repository repository.GithubRepository `json:"repository"`
+	SyncStatus *response.SyncStatus        `json:"sync_status,omitempty"`
+}
+
+// GetRepositories returns the tracked repositories
+func (c *Client) GetRepositories(ctx context.Context) ([]*repository.GithubRepository, error) {
+	var repos []*repository.GithubRepository
+	if _, err := c.get(ctx, "/v1/repositories", nil, &repos); err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+// GetRepository returns the tracked repository repoName, the error is a 404 *response.Problem when it is not tracked
+func (c *Client) GetRepository(ctx context.Context, repoName string) (*RepositoryStatus, error) {
+	// the owner of gitlab repos in subgroups is their namespace, its slashes are encoded
+	i := strings.LastIndex(repoName, "/")
+	if i < 0 {
+		return nil, fmt.Errorf("repository name must be in the format 'owner/name', got %q", repoName)
+	}
+	owner, name := repoName[:i], repoName[i+1:]
+
+	status := &RepositoryStatus{}
+	env, err := c.get(ctx, "/v1/repositories/"+url.PathEscape(owner)+"/"+url.PathEscape(name), nil, &status.Repository)
+	if err != nil {
+		return nil, err
+	}
+	if env.Meta != nil {
+		status.SyncStatus = env.Meta.SyncStatus
+	}
+
+	return status, nil
+}
+
+// TrackRepository starts tracking repoName, the error is a 409 *response.Problem when it is already tracked
+func (c *Client) TrackRepository(ctx context.Context, repoName string) (*RepositoryStatus, error) {
+	body := struct {
+		RepositoryName string `json:"repository_name"`
+	}{repoName}
+
+	status := &RepositoryStatus{}
+	env, err := c.post(ctx, "/v1/repositories", body, &status.Repository)
+	if err != nil {
+		return nil, err
+	}
+	if env.Meta != nil {
+		status.SyncStatus = env.Meta.SyncStatus
+	}
+
+	return status, nil
+}
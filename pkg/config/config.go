@@ -0,0 +1,376 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/db"
+	"github.com/danielboakye/github-repo-stats/pkg/httpserver"
+	"github.com/danielboakye/github-repo-stats/pkg/services/githubrepo"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported stores
+const (
+	StorePostgres = "postgres"
+	StoreSQLite   = "sqlite"
+	StoreMemory   = "memory"
+)
+
+// ConfigEnvVar represents env variable for the path of the config file
+const ConfigEnvVar = "GHSTATS_CONFIG"
+
+// Config represents the configuration of the application.
+// every setting is read from the config file, then overridden by its env variable and then by its flag.
+// secrets have no flag so they do not show up in the command line of the process
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Github    GithubConfig    `yaml:"github"`
+	Gitlab    GitlabConfig    `yaml:"gitlab"`
+	Gitea     GiteaConfig     `yaml:"gitea"`
+	Bitbucket BitbucketConfig `yaml:"bitbucket"`
+	Sync      SyncConfig      `yaml:"sync"`
+	Database  DatabaseConfig  `yaml:"database"`
+}
+
+// ServerConfig represents the http server settings
+type ServerConfig struct {
+	Host              string        `yaml:"host" env:"GHSTATS_HOST" flag:"host" usage:"The host address where the application will run"`
+	Port              string        `yaml:"port" env:"GHSTATS_PORT" flag:"port" usage:"The http server port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"GHSTATS_HTTP_READ_HEADER_TIMEOUT" flag:"http-read-header-timeout" usage:"maximum duration for reading request headers"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"GHSTATS_HTTP_READ_TIMEOUT" flag:"http-read-timeout" usage:"maximum duration for reading an entire request"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"GHSTATS_HTTP_WRITE_TIMEOUT" flag:"http-write-timeout" usage:"maximum duration before timing out writes of a response"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"GHSTATS_HTTP_IDLE_TIMEOUT" flag:"http-idle-timeout" usage:"maximum amount of time to wait for the next request on a keep-alive connection"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"GHSTATS_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"maximum amount of time to wait for requests and syncs to finish on shutdown"`
+}
+
+// GithubConfig represents the github api settings
+type GithubConfig struct {
+	Token         string `yaml:"token" env:"GITHUB_TOKEN" usage:"github token used to authenticate api requests" redact:"all"`
+	APIURL        string `yaml:"api_url" env:"GHSTATS_GITHUB_API_URL" flag:"github-api-url" usage:"base url of the github rest api"`
+	GraphQLURL    string `yaml:"graphql_url" env:"GHSTATS_GITHUB_GRAPHQL_URL" flag:"github-graphql-url" usage:"url of the github graphql api repositories with the graphql fetch mode are fetched with"`
+	WebhookSecret string `yaml:"webhook_secret" env:"GHSTATS_GITHUB_WEBHOOK_SECRET" usage:"secret github webhooks are signed with, github webhooks are disabled when it is empty" redact:"all"`
+}
+
+// GitlabConfig represents the gitlab api settings used for gitlab: repositories
+type GitlabConfig struct {
+	Token         string `yaml:"token" env:"GITLAB_TOKEN" usage:"gitlab access token used to authenticate api requests" redact:"all"`
+	APIURL        string `yaml:"api_url" env:"GHSTATS_GITLAB_API_URL" flag:"gitlab-api-url" usage:"base url of the gitlab rest api"`
+	WebhookSecret string `yaml:"webhook_secret" env:"GHSTATS_GITLAB_WEBHOOK_SECRET" usage:"secret token of gitlab webhooks, gitlab webhooks are disabled when it is empty" redact:"all"`
+}
+
+// GiteaConfig represents the gitea api settings used for gitea: repositories
+type GiteaConfig struct {
+	Token         string `yaml:"token" env:"GITEA_TOKEN" usage:"gitea access token used to authenticate api requests" redact:"all"`
+	APIURL        string `yaml:"api_url" env:"GHSTATS_GITEA_API_URL" flag:"gitea-api-url" usage:"base url of the gitea rest api"`
+	WebhookSecret string `yaml:"webhook_secret" env:"GHSTATS_GITEA_WEBHOOK_SECRET" usage:"secret gitea webhooks are signed with, gitea webhooks are disabled when it is empty" redact:"all"`
+}
+
+// BitbucketConfig represents the bitbucket cloud api settings used for bitbucket: repositories
+type BitbucketConfig struct {
+	Token         string `yaml:"token" env:"BITBUCKET_TOKEN" usage:"bitbucket access token used to authenticate api requests" redact:"all"`
+	APIURL        string `yaml:"api_url" env:"GHSTATS_BITBUCKET_API_URL" flag:"bitbucket-api-url" usage:"base url of the bitbucket rest api"`
+	WebhookSecret string `yaml:"webhook_secret" env:"GHSTATS_BITBUCKET_WEBHOOK_SECRET" usage:"secret bitbucket webhooks are signed with, bitbucket webhooks are disabled when it is empty" redact:"all"`
+}
+
+// SyncConfig represents the settings of the repository watcher
+type SyncConfig struct {
+	Since           string        `yaml:"since" env:"GHSTATS_SINCE" flag:"since" usage:"date to start pulling commits from"`
+	Interval        time.Duration `yaml:"interval" env:"GHSTATS_SYNC_INTERVAL" flag:"sync-interval" usage:"wait between syncs of all tracked repositories"`
+	Backoff         time.Duration `yaml:"backoff" env:"GHSTATS_SYNC_BACKOFF" flag:"sync-backoff" usage:"initial wait after the github rate limit is reached, doubles on every retry"`
+	QueueSize       int           `yaml:"queue_size" env:"GHSTATS_SYNC_QUEUE_SIZE" flag:"sync-queue-size" usage:"number of newly tracked repositories that can wait for their first sync"`
+	Concurrency     int           `yaml:"concurrency" env:"GHSTATS_SYNC_CONCURRENCY" flag:"sync-concurrency" usage:"maximum number of repositories synced at once"`
+	PendingInterval time.Duration `yaml:"pending_interval" env:"GHSTATS_SYNC_PENDING_INTERVAL" flag:"sync-pending-interval" usage:"how often a worker looks for repositories tracked through the api"`
+}
+
+// DatabaseConfig represents the storage backend settings
+type DatabaseConfig struct {
+	Store           string        `yaml:"store" env:"GHSTATS_STORE" flag:"store" usage:"storage backend: postgres, sqlite or memory, defaults to the scheme of the database url"`
+	URL             string        `yaml:"url" env:"DATABASE_URL,POSTGRES_URL" usage:"database connection url" redact:"password"`
+	AutoMigrate     bool          `yaml:"auto_migrate" env:"GHSTATS_AUTO_MIGRATE" flag:"auto-migrate" usage:"apply pending database migrations on startup"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"GHSTATS_DB_MAX_OPEN_CONNS" flag:"db-max-open-conns" usage:"maximum number of open database connections"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"GHSTATS_DB_MAX_IDLE_CONNS" flag:"db-max-idle-conns" usage:"maximum number of idle database connections"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"GHSTATS_DB_CONN_MAX_LIFETIME" flag:"db-conn-max-lifetime" usage:"maximum amount of time a database connection may be reused"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"GHSTATS_DB_CONN_MAX_IDLE_TIME" flag:"db-conn-max-idle-time" usage:"maximum amount of time a database connection may be idle"`
+	ConnectAttempts int           `yaml:"connect_attempts" env:"GHSTATS_DB_CONNECT_ATTEMPTS" flag:"db-connect-attempts" usage:"number of attempts to reach the database on startup"`
+	ConnectInterval time.Duration `yaml:"connect_interval" env:"GHSTATS_DB_CONNECT_INTERVAL" flag:"db-connect-interval" usage:"initial wait between attempts to reach the database on startup"`
+	PgxPool         bool          `yaml:"pgxpool" env:"GHSTATS_DB_PGXPOOL" flag:"db-pgxpool" usage:"use a native pgxpool for postgres connections"`
+}
+
+// Default returns the configuration used when nothing is configured
+func Default() Config {
+	timeouts := httpserver.DefaultTimeouts()
+	github := githubrepo.DefaultConfig()
+	pool := db.DefaultPoolConfig()
+	retry := db.DefaultRetryConfig()
+
+	return Config{
+		Server: ServerConfig{
+			Host:              "localhost",
+			Port:              "9000",
+			ReadHeaderTimeout: timeouts.ReadHeader,
+			ReadTimeout:       timeouts.Read,
+			WriteTimeout:      timeouts.Write,
+			IdleTimeout:       timeouts.Idle,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Github: GithubConfig{
+			APIURL:     github.APIURL,
+			GraphQLURL: github.GraphQLURL,
+		},
+		Gitlab: GitlabConfig{
+			APIURL: github.Gitlab.APIURL,
+		},
+		Gitea: GiteaConfig{
+			APIURL: github.Gitea.APIURL,
+		},
+		Bitbucket: BitbucketConfig{
+			APIURL: github.Bitbucket.APIURL,
+		},
+		Sync: SyncConfig{
+			Since:           defaultSince(),
+			Interval:        github.SyncInterval,
+			Backoff:         github.Backoff,
+			QueueSize:       github.QueueSize,
+			Concurrency:     github.Concurrency,
+			PendingInterval: github.PendingInterval,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    pool.MaxOpenConns,
+			MaxIdleConns:    pool.MaxIdleConns,
+			ConnMaxLifetime: pool.ConnMaxLifetime,
+			ConnMaxIdleTime: pool.ConnMaxIdleTime,
+			ConnectAttempts: retry.Attempts,
+			ConnectInterval: retry.Interval,
+		},
+	}
+}
+
+// defaultSince returns the date one year ago, commits older than it are not pulled by default
+func defaultSince() string {
+	return time.Now().Add(-365 * 24 * time.Hour).UTC().Format(githubrepo.ISODateFormat)
+}
+
+// Load returns the default configuration overridden by the yaml file at path, skipped when path is empty,
+// then by env variables looked up with getenv and then by the flags set on the command line.
+// flags may be nil when no flags are registered
+func Load(path string, getenv func(string) string, flags *Flags) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := cfg.loadEnv(getenv); err != nil {
+		return cfg, err
+	}
+
+	if flags != nil {
+		flags.apply(&cfg)
+	}
+
+	// an empty since, e.g. from `make start` without SINCE, means the default
+	if cfg.Sync.Since == "" {
+		cfg.Sync.Since = defaultSince()
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+	default:
+		return fmt.Errorf("unsupported config file format %q, use .yaml or .yml", ext)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) loadEnv(getenv func(string) string) error {
+	for _, s := range c.settings() {
+		for _, name := range s.env {
+			raw := getenv(name)
+			if raw == "" {
+				continue
+			}
+			if err := s.Set(raw); err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// Validate returns every invalid setting of the configuration
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(setting, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", setting, fmt.Sprintf(format, args...)))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		invalid("server.port", "must be a number between 1 and 65535, got %q", c.Server.Port)
+	}
+	for _, d := range []struct {
+		setting string
+		value   time.Duration
+	}{
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+		{"sync.interval", c.Sync.Interval},
+		{"sync.backoff", c.Sync.Backoff},
+		{"sync.pending_interval", c.Sync.PendingInterval},
+		{"database.connect_interval", c.Database.ConnectInterval},
+	} {
+		if d.value <= 0 {
+			invalid(d.setting, "must be positive, got %s", d.value)
+		}
+	}
+
+	for _, api := range []struct {
+		setting string
+		value   string
+	}{
+		{"github.api_url", c.Github.APIURL},
+		{"github.graphql_url", c.Github.GraphQLURL},
+		{"gitlab.api_url", c.Gitlab.APIURL},
+		{"gitea.api_url", c.Gitea.APIURL},
+		{"bitbucket.api_url", c.Bitbucket.APIURL},
+	} {
+		if u, err := url.Parse(api.value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid(api.setting, "must be an absolute http(s) url, got %q", api.value)
+		}
+	}
+
+	if _, err := time.Parse(githubrepo.ISODateFormat, c.Sync.Since); err != nil {
+		invalid("sync.since", "must be an ISO 8601 date time (YYYY-MM-DDTHH:MM:SSZ), got %q", c.Sync.Since)
+	}
+	if c.Sync.QueueSize < 0 {
+		invalid("sync.queue_size", "must not be negative, got %d", c.Sync.QueueSize)
+	}
+	if c.Sync.Concurrency < 1 {
+		invalid("sync.concurrency", "must be at least 1, got %d", c.Sync.Concurrency)
+	}
+
+	switch c.Database.Store {
+	case "", StorePostgres, StoreSQLite, StoreMemory:
+	default:
+		invalid("database.store", "must be one of %s, %s, %s, got %q", StorePostgres, StoreSQLite, StoreMemory, c.Database.Store)
+	}
+	if c.Database.URL == "" && c.Database.Store != StoreMemory {
+		invalid("database.url", "is required unless database.store is %s", StoreMemory)
+	}
+	if c.Database.MaxOpenConns < 0 {
+		invalid("database.max_open_conns", "must not be negative, got %d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 {
+		invalid("database.max_idle_conns", "must not be negative, got %d", c.Database.MaxIdleConns)
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		invalid("database.conn_max_lifetime", "must not be negative, got %s", c.Database.ConnMaxLifetime)
+	}
+	if c.Database.ConnMaxIdleTime < 0 {
+		invalid("database.conn_max_idle_time", "must not be negative, got %s", c.Database.ConnMaxIdleTime)
+	}
+	if c.Database.ConnectAttempts < 1 {
+		invalid("database.connect_attempts", "must be at least 1, got %d", c.Database.ConnectAttempts)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Addr returns the address the http server listens on
+func (c ServerConfig) Addr() string {
+	return fmt.Sprintf("%s:%s", c.Host, c.Port)
+}
+
+// Timeouts returns the timeouts of the http server
+func (c ServerConfig) Timeouts() httpserver.Timeouts {
+	return httpserver.Timeouts{
+		ReadHeader: c.ReadHeaderTimeout,
+		Read:       c.ReadTimeout,
+		Write:      c.WriteTimeout,
+		Idle:       c.IdleTimeout,
+	}
+}
+
+// Service returns the settings of the github service and the other providers
+func (c Config) Service() githubrepo.Config {
+	// since is validated up front
+	since, _ := time.Parse(githubrepo.ISODateFormat, c.Sync.Since)
+
+	return githubrepo.Config{
+		APIURL:          c.Github.APIURL,
+		GraphQLURL:      c.Github.GraphQLURL,
+		Token:           c.Github.Token,
+		Since:           since,
+		SyncInterval:    c.Sync.Interval,
+		Backoff:         c.Sync.Backoff,
+		QueueSize:       c.Sync.QueueSize,
+		Concurrency:     c.Sync.Concurrency,
+		PendingInterval: c.Sync.PendingInterval,
+		WebhookSecret:   c.Github.WebhookSecret,
+		Gitlab: githubrepo.ProviderConfig{
+			APIURL:        c.Gitlab.APIURL,
+			Token:         c.Gitlab.Token,
+			WebhookSecret: c.Gitlab.WebhookSecret,
+		},
+		Gitea: githubrepo.ProviderConfig{
+			APIURL:        c.Gitea.APIURL,
+			Token:         c.Gitea.Token,
+			WebhookSecret: c.Gitea.WebhookSecret,
+		},
+		Bitbucket: githubrepo.ProviderConfig{
+			APIURL:        c.Bitbucket.APIURL,
+			Token:         c.Bitbucket.Token,
+			WebhookSecret: c.Bitbucket.WebhookSecret,
+		},
+	}
+}
+
+// Pool returns the connection pool settings of the database
+func (c DatabaseConfig) Pool() db.PoolConfig {
+	return db.PoolConfig{
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxLifetime: c.ConnMaxLifetime,
+		ConnMaxIdleTime: c.ConnMaxIdleTime,
+	}
+}
+
+// Retry returns the startup retry settings of the database
+func (c DatabaseConfig) Retry() db.RetryConfig {
+	return db.RetryConfig{
+		Attempts: c.ConnectAttempts,
+		Interval: c.ConnectInterval,
+	}
+}
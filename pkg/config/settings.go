@@ -0,0 +1,209 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets in printed configs
+const redacted = "REDACTED"
+
+// keywordPassword matches the password of a keyword/value dsn, e.g. host=db password='s3 cret'
+var keywordPassword = regexp.MustCompile(`password\s*=\s*('(?:[^'\\]|\\.)*'|\S*)`)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setting represents a single configurable value of a Config section
+type setting struct {
+	section string
+	key     string
+	env     []string
+	flag    string
+	usage   string
+	redact  string
+	value   reflect.Value
+}
+
+// settings returns the settings of c in declaration order, their values point into c
+func (c *Config) settings() []setting {
+	var settings []setting
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		sectionName := sections.Type().Field(i).Tag.Get("yaml")
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			s := setting{
+				section: sectionName,
+				key:     field.Tag.Get("yaml"),
+				flag:    field.Tag.Get("flag"),
+				usage:   field.Tag.Get("usage"),
+				redact:  field.Tag.Get("redact"),
+				value:   section.Field(j),
+			}
+			if env := field.Tag.Get("env"); env != "" {
+				s.env = strings.Split(env, ",")
+			}
+			settings = append(settings, s)
+		}
+	}
+
+	return settings
+}
+
+// name returns the path of the setting in the config file
+func (s setting) name() string {
+	return s.section + "." + s.key
+}
+
+// String implements flag.Value
+func (s setting) String() string {
+	if !s.value.IsValid() {
+		return ""
+	}
+
+	switch {
+	case s.value.Type() == durationType:
+		return time.Duration(s.value.Int()).String()
+	case s.value.Kind() == reflect.Int:
+		return strconv.FormatInt(s.value.Int(), 10)
+	case s.value.Kind() == reflect.Bool:
+		return strconv.FormatBool(s.value.Bool())
+	default:
+		return s.value.String()
+	}
+}
+
+// Set implements flag.Value
+func (s setting) Set(raw string) error {
+	switch {
+	case s.value.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(d))
+	case s.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(n))
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		s.value.SetBool(b)
+	default:
+		s.value.SetString(raw)
+	}
+
+	return nil
+}
+
+// IsBoolFlag allows bool settings to be set with just -flag
+func (s setting) IsBoolFlag() bool {
+	return s.value.IsValid() && s.value.Kind() == reflect.Bool
+}
+
+// redactedString returns the printable value of the setting with secrets redacted
+func (s setting) redactedString() string {
+	value := s.String()
+	if value == "" {
+		return value
+	}
+
+	switch s.redact {
+	case "all":
+		return redacted
+	case "password":
+		u, err := url.Parse(value)
+		if err != nil {
+			return redacted
+		}
+		if u.Scheme == "" {
+			return keywordPassword.ReplaceAllString(value, "password=xxxxx")
+		}
+		if query := u.Query(); query.Has("password") {
+			query.Set("password", "xxxxx")
+			u.RawQuery = query.Encode()
+		}
+		return u.Redacted()
+	default:
+		return value
+	}
+}
+
+// Flags represents the config flags registered on a flag set
+type Flags struct {
+	fs     *flag.FlagSet
+	values *Config
+}
+
+// RegisterFlags registers a flag for every setting with one on fs, flags override the file and env only when they are set
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	values := Default()
+	for _, s := range values.settings() {
+		if s.flag == "" {
+			continue
+		}
+		fs.Var(s, s.flag, s.usage)
+	}
+
+	return &Flags{fs: fs, values: &values}
+}
+
+// apply copies the flags set on the command line into cfg
+func (f *Flags) apply(cfg *Config) {
+	set := map[string]bool{}
+	f.fs.Visit(func(fl *flag.Flag) {
+		set[fl.Name] = true
+	})
+
+	values := f.values.settings()
+	for i, s := range cfg.settings() {
+		if s.flag != "" && set[s.flag] {
+			s.value.Set(values[i].value)
+		}
+	}
+}
+
+// Print writes the configuration as yaml with secrets redacted
+func (c Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	var section *yaml.Node
+	for _, s := range c.settings() {
+		if section == nil || root.Content[len(root.Content)-2].Value != s.section {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.section}, section)
+		}
+
+		value := &yaml.Node{}
+		var encoded interface{} = s.redactedString()
+		if s.value.Kind() == reflect.Bool || (s.value.Kind() == reflect.Int && s.value.Type() != durationType) {
+			encoded = s.value.Interface()
+		}
+		if err := value.Encode(encoded); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", s.name(), err)
+		}
+		section.Content = append(section.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.key}, value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
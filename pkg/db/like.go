@@ -0,0 +1,11 @@
+package db
+
+import "strings"
+
+// likeEscaper escapes LIKE wildcards so user input is matched literally with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes s for use in a LIKE pattern
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
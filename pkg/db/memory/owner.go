@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/google/uuid"
+)
+
+// copyOwner returns a copy of owner which does not share its slices and pointer fields
+func copyOwner(owner *repository.OwnerSubscription) *repository.OwnerSubscription {
+	o := *owner
+	o.Include = append([]string(nil), owner.Include...)
+	o.Exclude = append([]string(nil), owner.Exclude...)
+	if owner.LastSyncedAt != nil {
+		lastSyncedAt := *owner.LastSyncedAt
+		o.LastSyncedAt = &lastSyncedAt
+	}
+	return &o
+}
+
+// CreateOwner implements repository.Repository
+func (m *Repository) CreateOwner(ctx context.Context, owner repository.OwnerSubscription) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ownerID := uuid.New().String()
+	for _, stored := range m.owners {
+		if stored.Name == owner.Name {
+			return ownerID, fmt.Errorf("could not insert owner subscription (%s): %w", owner.Name, repository.ErrConflict)
+		}
+	}
+
+	owner.ID = ownerID
+	owner.LastSyncedAt = nil
+	owner.CreatedAt = time.Now()
+	m.owners[ownerID] = copyOwner(&owner)
+
+	return ownerID, nil
+}
+
+// GetOwners implements repository.Repository
+func (m *Repository) GetOwners(ctx context.Context) ([]*repository.OwnerSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var owners []*repository.OwnerSubscription
+	for _, owner := range m.owners {
+		owners = append(owners, copyOwner(owner))
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].CreatedAt.Before(owners[j].CreatedAt)
+	})
+
+	return owners, nil
+}
+
+// GetOwnerByName implements repository.Repository
+func (m *Repository) GetOwnerByName(ctx context.Context, name string) (repository.OwnerSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, owner := range m.owners {
+		if owner.Name == name {
+			return *copyOwner(owner), nil
+		}
+	}
+
+	return repository.OwnerSubscription{}, repository.ErrNotFound
+}
+
+// UpdateOwnerLastSyncTime implements repository.Repository
+func (m *Repository) UpdateOwnerLastSyncTime(ctx context.Context, ownerID string, syncTime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.owners[ownerID]; ok {
+		stored.LastSyncedAt = &syncTime
+	}
+
+	return nil
+}
+
+// GetRepositoriesByOwner implements repository.Repository
+func (m *Repository) GetRepositoriesByOwner(ctx context.Context, ownerID string) ([]*repository.GithubRepository, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var repositories []*repository.GithubRepository
+	for _, repo := range m.repositories {
+		if repo.OwnerID != nil && *repo.OwnerID == ownerID {
+			repositories = append(repositories, copyRepository(repo))
+		}
+	}
+	sort.Slice(repositories, func(i, j int) bool {
+		return repositories[i].RepositoryName < repositories[j].RepositoryName
+	})
+
+	return repositories, nil
+}
+
+// UpdateRepositoryOwner implements repository.Repository
+func (m *Repository) UpdateRepositoryOwner(ctx context.Context, repoID, ownerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.repositories[repoID]; ok {
+		stored.OwnerID = &ownerID
+	}
+
+	return nil
+}
+
+// UpdateRepositoryDeletedAt implements repository.Repository
+func (m *Repository) UpdateRepositoryDeletedAt(ctx context.Context, repoID string, deletedAt *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.repositories[repoID]; ok {
+		stored.DeletedAt = nil
+		if deletedAt != nil {
+			d := *deletedAt
+			stored.DeletedAt = &d
+		}
+	}
+
+	return nil
+}
+
+// GetOwnerStats implements repository.Repository
+func (m *Repository) GetOwnerStats(ctx context.Context, ownerID string, limit int) (repository.OwnerStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var stats repository.OwnerStats
+	repoIDs := map[string]bool{}
+	for _, repo := range m.repositories {
+		if repo.OwnerID == nil || *repo.OwnerID != ownerID {
+			continue
+		}
+		if repo.DeletedAt != nil {
+			stats.DeletedRepositories++
+			continue
+		}
+		repoIDs[repo.ID] = true
+		stats.Repositories++
+		stats.StarsCount += repo.StarsCount
+		stats.ForksCount += repo.ForksCount
+		stats.OpenIssuesCount += repo.OpenIssuesCount
+		stats.WatchersCount += repo.WatchersCount
+	}
+
+	counts := map[string]int{}
+	for key, commit := range m.commits {
+		if repoIDs[key.repoID] {
+			counts[commit.AuthorName]++
+			stats.Commits++
+		}
+	}
+	stats.Authors = len(counts)
+
+	for author, count := range counts {
+		stats.TopAuthors = append(stats.TopAuthors, repository.CommitStats{
+			AuthorName:  author,
+			CommitCount: count,
+		})
+	}
+	sort.Slice(stats.TopAuthors, func(i, j int) bool {
+		if stats.TopAuthors[i].CommitCount != stats.TopAuthors[j].CommitCount {
+			return stats.TopAuthors[i].CommitCount > stats.TopAuthors[j].CommitCount
+		}
+		return stats.TopAuthors[i].AuthorName < stats.TopAuthors[j].AuthorName
+	})
+	stats.TopAuthors = paginate(stats.TopAuthors, limit, 0)
+
+	return stats, nil
+}
@@ -0,0 +1,317 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/google/uuid"
+)
+
+// commitKey mirrors the (commit_hash, repository_id) unique constraint in postgres
+type commitKey struct {
+	repoID string
+	hash   string
+}
+
+// Repository represents the in-memory implementation of repository.Repository
+type Repository struct {
+	mu           sync.RWMutex
+	repositories map[string]*repository.GithubRepository
+	commits      map[commitKey]repository.GithubCommit
+	owners       map[string]*repository.OwnerSubscription
+}
+
+// NewRepository initiates a new in-memory repository
+func NewRepository() repository.Repository {
+	return &Repository{
+		repositories: map[string]*repository.GithubRepository{},
+		commits:      map[commitKey]repository.GithubCommit{},
+		owners:       map[string]*repository.OwnerSubscription{},
+	}
+}
+
+// GetRepositories implements repository.Repository
+func (m *Repository) GetRepositories(ctx context.Context) ([]*repository.GithubRepository, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var repositories []*repository.GithubRepository
+	for _, repo := range m.repositories {
+		repositories = append(repositories, copyRepository(repo))
+	}
+	sort.Slice(repositories, func(i, j int) bool {
+		return repositories[i].CreatedAt.Before(repositories[j].CreatedAt)
+	})
+
+	return repositories, nil
+}
+
+// GetRepositoryByName implements repository.Repository
+func (m *Repository) GetRepositoryByName(ctx context.Context, name string) (repository.GithubRepository, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, repo := range m.repositories {
+		if repo.RepositoryName == name {
+			return *copyRepository(repo), nil
+		}
+	}
+
+	return repository.GithubRepository{}, repository.ErrNotFound
+}
+
+// CreateRepository implements repository.Repository
+func (m *Repository) CreateRepository(ctx context.Context, repoName string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	repoID := uuid.New().String()
+	for _, repo := range m.repositories {
+		if repo.RepositoryName == repoName {
+			return repoID, fmt.Errorf("could not insert repository (%s): %w", repoName, repository.ErrConflict)
+		}
+	}
+
+	m.repositories[repoID] = &repository.GithubRepository{
+		ID:             repoID,
+		RepositoryName: repoName,
+		Provider:       repository.ProviderOf(repoName),
+		FetchMode:      repository.FetchModeREST,
+		CreatedAt:      time.Now(),
+	}
+
+	return repoID, nil
+}
+
+// UpdateRepository implements repository.Repository
+func (m *Repository) UpdateRepository(ctx context.Context, repo *repository.GithubRepository) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.repositories[repo.ID]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	stored.Description = copyString(repo.Description)
+	stored.URL = copyString(repo.URL)
+	stored.Language = copyString(repo.Language)
+	stored.ForksCount = repo.ForksCount
+	stored.StarsCount = repo.StarsCount
+	stored.OpenIssuesCount = repo.OpenIssuesCount
+	stored.WatchersCount = repo.WatchersCount
+	stored.UpdatedAt = &now
+
+	return nil
+}
+
+// UpdateCommitLastSyncTime implements repository.Repository
+func (m *Repository) UpdateCommitLastSyncTime(ctx context.Context, repoID string, syncTime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.repositories[repoID]; ok {
+		stored.CommitLastPulledTime = &syncTime
+	}
+
+	return nil
+}
+
+// UpdateFetchMode implements repository.Repository
+func (m *Repository) UpdateFetchMode(ctx context.Context, repoID string, mode repository.FetchMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.repositories[repoID]; ok {
+		stored.FetchMode = mode
+	}
+
+	return nil
+}
+
+// SaveCommit implements repository.Repository
+func (m *Repository) SaveCommit(ctx context.Context, commit repository.GithubCommit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.repositories[commit.RepositoryID]; !ok {
+		return fmt.Errorf("could not insert commit: repository (%s) does not exist", commit.RepositoryID)
+	}
+
+	key := commitKey{repoID: commit.RepositoryID, hash: commit.CommitHash}
+	if _, ok := m.commits[key]; ok {
+		// duplicate commits are ignored like ON CONFLICT DO NOTHING
+		return nil
+	}
+	if commit.ID == "" {
+		commit.ID = uuid.New().String()
+	}
+	commit.Parents = append([]string(nil), commit.Parents...)
+	commit.Files = append([]string(nil), commit.Files...)
+	commit.PullRequests = append([]int(nil), commit.PullRequests...)
+	copyPointers(&commit)
+	m.commits[key] = commit
+
+	return nil
+}
+
+// copyPointers replaces the pointer fields of commit by copies so stored commits are not shared with callers
+func copyPointers(commit *repository.GithubCommit) {
+	if commit.CommitterDate != nil {
+		date := *commit.CommitterDate
+		commit.CommitterDate = &date
+	}
+	if commit.Stats != nil {
+		stats := *commit.Stats
+		commit.Stats = &stats
+	}
+}
+
+// GetCommitsByRepository implements repository.Repository
+func (m *Repository) GetCommitsByRepository(ctx context.Context, repoID string, filter repository.CommitFilter, page repository.Page) ([]*repository.GithubCommit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	descending := page.Descending(filter)
+	offset := page.Offset
+	if page.Cursor != nil {
+		offset = 0
+	}
+
+	var commits []*repository.GithubCommit
+	for key, commit := range m.commits {
+		if key.repoID != repoID || !filter.Matches(commit) {
+			continue
+		}
+		if page.Cursor != nil && !commitLess(page.Cursor.Date, page.Cursor.Hash, commit.Date, commit.CommitHash, descending) {
+			continue
+		}
+		c := commit
+		// ids and files are not returned by the sql implementations either
+		c.ID = ""
+		c.RepositoryID = ""
+		c.Files = nil
+		copyPointers(&c)
+		commits = append(commits, &c)
+	}
+	sort.Slice(commits, func(i, j int) bool {
+		return commitLess(commits[i].Date, commits[i].CommitHash, commits[j].Date, commits[j].CommitHash, descending)
+	})
+
+	commits = paginate(commits, page.Limit, offset)
+	if page.Reversed() {
+		slices.Reverse(commits)
+	}
+
+	return commits, nil
+}
+
+// commitLess reports whether commit a is read before commit b in the given order,
+// commits with the same date are ordered by hash the same way as the sql implementations
+func commitLess(aDate time.Time, aHash string, bDate time.Time, bHash string, descending bool) bool {
+	if !aDate.Equal(bDate) {
+		return aDate.After(bDate) == descending
+	}
+	if descending {
+		return aHash < bHash
+	}
+	return aHash > bHash
+}
+
+// StreamCommitsByRepository implements repository.Repository
+func (m *Repository) StreamCommitsByRepository(ctx context.Context, repoID string, filter repository.CommitFilter, fn func(*repository.GithubCommit) error) error {
+	// the commits are copied so fn may use the repository
+	commits, err := m.GetCommitsByRepository(ctx, repoID, filter, repository.Page{Limit: math.MaxInt})
+	if err != nil {
+		return err
+	}
+
+	for _, commit := range commits {
+		if err := fn(commit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CountCommitsByRepository implements repository.Repository
+func (m *Repository) CountCommitsByRepository(ctx context.Context, repoID string, filter repository.CommitFilter) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int
+	for key, commit := range m.commits {
+		if key.repoID == repoID && filter.Matches(commit) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// GetLeaderBoard implements repository.Repository
+func (m *Repository) GetLeaderBoard(ctx context.Context, limit int) ([]repository.CommitStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, commit := range m.commits {
+		counts[commit.AuthorName]++
+	}
+
+	var leaderboard []repository.CommitStats
+	for author, count := range counts {
+		leaderboard = append(leaderboard, repository.CommitStats{
+			AuthorName:  author,
+			CommitCount: count,
+		})
+	}
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].CommitCount != leaderboard[j].CommitCount {
+			return leaderboard[i].CommitCount > leaderboard[j].CommitCount
+		}
+		return leaderboard[i].AuthorName < leaderboard[j].AuthorName
+	})
+
+	return paginate(leaderboard, limit, 0), nil
+}
+
+// paginate applies LIMIT/OFFSET semantics to items
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items
+}
+
+// copyRepository returns a copy of repo which does not share its pointer fields
+func copyRepository(repo *repository.GithubRepository) *repository.GithubRepository {
+	r := *repo
+	r.OwnerID = copyString(repo.OwnerID)
+	if repo.DeletedAt != nil {
+		deletedAt := *repo.DeletedAt
+		r.DeletedAt = &deletedAt
+	}
+	return &r
+}
+
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
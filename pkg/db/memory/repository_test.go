@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/repository/repositorytest"
+)
+
+// go test -timeout 30s -run ^TestConformance$ ./pkg/db/memory -v
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.Repository {
+		return NewRepository()
+	})
+}
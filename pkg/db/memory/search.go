@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+
+	// snippetWords is the number of words around the first match included in a snippet
+	snippetWords = 20
+)
+
+// SearchCommits implements repository.Repository.
+// it matches words without stemming, unlike the postgres and sqlite full-text indexes
+func (m *Repository) SearchCommits(ctx context.Context, search repository.CommitSearch) ([]*repository.CommitSearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	repoIDs := map[string]bool{}
+	for _, id := range search.RepositoryIDs {
+		repoIDs[id] = true
+	}
+
+	var results []*repository.CommitSearchResult
+	for key, commit := range m.commits {
+		if len(repoIDs) > 0 && !repoIDs[key.repoID] {
+			continue
+		}
+
+		rank, snippet, ok := matchMessage(search.Query, commit.Message)
+		if !ok {
+			continue
+		}
+
+		c := commit
+		c.ID = ""
+		c.RepositoryID = ""
+		c.Files = nil
+		results = append(results, &repository.CommitSearchResult{
+			GithubCommit:   c,
+			RepositoryName: m.repositories[key.repoID].RepositoryName,
+			Rank:           rank,
+			Snippet:        snippet,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Date.After(results[j].Date)
+	})
+
+	return paginate(results, search.Limit, search.Offset), nil
+}
+
+// messageWord represents a word of a commit message and its position in the message
+type messageWord struct {
+	text       string
+	start, end int
+}
+
+func splitMessage(message string) []messageWord {
+	var (
+		words []messageWord
+		start = -1
+	)
+	for i, r := range message {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && start < 0 {
+			start = i
+		}
+		if !isWordRune && start >= 0 {
+			words = append(words, messageWord{text: strings.ToLower(message[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, messageWord{text: strings.ToLower(message[start:]), start: start, end: len(message)})
+	}
+
+	return words
+}
+
+// matchMessage reports whether every term of query matches message,
+// the rank is the number of matches and the snippet highlights them
+func matchMessage(query repository.SearchQuery, message string) (float64, string, bool) {
+	words := splitMessage(message)
+	highlighted := make([]bool, len(words))
+
+	var matches int
+	for _, term := range query.Terms {
+		termMatches := 0
+		for i := 0; i+len(term.Words) <= len(words); i++ {
+			if !matchTerm(term, words[i:i+len(term.Words)]) {
+				continue
+			}
+			termMatches++
+			for j := i; j < i+len(term.Words); j++ {
+				highlighted[j] = true
+			}
+		}
+		if termMatches == 0 {
+			return 0, "", false
+		}
+		matches += termMatches
+	}
+
+	return float64(matches), snippet(message, words, highlighted), true
+}
+
+func matchTerm(term repository.SearchTerm, words []messageWord) bool {
+	for i, w := range term.Words {
+		last := i == len(term.Words)-1
+		if last && term.Prefix {
+			if !strings.HasPrefix(words[i].text, w) {
+				return false
+			}
+			continue
+		}
+		if words[i].text != w {
+			return false
+		}
+	}
+
+	return true
+}
+
+// snippet returns the words of message around the first highlighted word with highlights wrapped in marks
+func snippet(message string, words []messageWord, highlighted []bool) string {
+	first := 0
+	for i, h := range highlighted {
+		if h {
+			first = i
+			break
+		}
+	}
+
+	from := first - snippetWords/2
+	if from < 0 {
+		from = 0
+	}
+	to := from + snippetWords
+	if to > len(words) {
+		to = len(words)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("... ")
+	}
+	pos := words[from].start
+	for i := from; i < to; i++ {
+		b.WriteString(message[pos:words[i].start])
+		if highlighted[i] {
+			b.WriteString(highlightStart + message[words[i].start:words[i].end] + highlightStop)
+		} else {
+			b.WriteString(message[words[i].start:words[i].end])
+		}
+		pos = words[i].end
+	}
+	if to < len(words) {
+		b.WriteString(" ...")
+	}
+
+	return b.String()
+}
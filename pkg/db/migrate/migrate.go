@@ -0,0 +1,333 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationsTable is the table used to record applied migrations
+const migrationsTable = "schema_migrations"
+
+// migrationFilePattern matches files of format {version}_{name}.{up|down}.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration represents a single versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status represents a migration and whether it has been applied to the database
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// Migrator applies versioned migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	begin      []string
+}
+
+// New loads the migrations in fsys and returns a migrator for db.
+// every migration must have an up file, down files are optional.
+// begin are the statements starting the transaction each migration is applied in, BEGIN by default. they must also lock out
+// other processes migrating db until the transaction ends, e.g. a server and a worker started together with auto migrate
+func New(db *sql.DB, fsys fs.FS, begin ...string) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if len(begin) == 0 {
+		begin = []string{"BEGIN"}
+	}
+
+	return &Migrator{db: db, migrations: migrations, begin: begin}, nil
+}
+
+// Load reads all migrations from the root of fsys sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		parts := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version (%s): %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration (%s): %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		}
+		if m.Name != parts[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names: %s and %s", version, m.Name, parts[2])
+		}
+
+		if parts[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s is missing an up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrations returns all known migrations sorted by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// ensureMigrationsTable creates the migrations table, holding the lock as concurrent creates of a table may fail
+func (m *Migrator) ensureMigrationsTable(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (
+			version BIGINT NOT NULL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+		)
+	`
+	err := m.tx(ctx, func(conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, query)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("could not create %s table: %w", migrationsTable, err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	if err := m.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM `+migrationsTable)
+	if err != nil {
+		return nil, fmt.Errorf("could not load applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// Status returns every known migration with the time it was applied, if at all
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Version returns the highest applied migration version, 0 if none has been applied
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+
+	return version, nil
+}
+
+// Pending returns migrations that have not been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Up applies all pending migrations in order and returns the ones applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		// version and name are validated by migrationFilePattern so they are safe to inline,
+		// which keeps the bookkeeping queries independent of the driver's placeholder style
+		record := fmt.Sprintf(
+			`INSERT INTO %s (version, name) VALUES (%d, '%s')`,
+			migrationsTable, migration.Version, migration.Name,
+		)
+		ran, err := m.run(ctx, migration.Version, false, migration.Up, record)
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if ran {
+			done = append(done, migration)
+		}
+	}
+
+	return done, nil
+}
+
+// Down rolls back the last n applied migrations in reverse order and returns the ones rolled back
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < n; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+
+		record := fmt.Sprintf(`DELETE FROM %s WHERE version = %d`, migrationsTable, migration.Version)
+		ran, err := m.run(ctx, migration.Version, true, migration.Down, record)
+		if err != nil {
+			return done, fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if ran {
+			done = append(done, migration)
+		}
+	}
+
+	return done, nil
+}
+
+// run executes a migration script and its bookkeeping statement in a single transaction when the migration of version
+// is still in the applied state once the lock is held, another process may have migrated db in the meantime.
+// it returns whether the script was executed
+func (m *Migrator) run(ctx context.Context, version int64, applied bool, script, record string) (bool, error) {
+	var ran bool
+	err := m.tx(ctx, func(conn *sql.Conn) error {
+		var count int
+		query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE version = %d`, migrationsTable, version)
+		if err := conn.QueryRowContext(ctx, query).Scan(&count); err != nil {
+			return err
+		}
+		if (count > 0) != applied {
+			return nil
+		}
+
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, record); err != nil {
+			return err
+		}
+		ran = true
+		return nil
+	})
+
+	return ran, err
+}
+
+// tx runs fn in a transaction started with the begin statements on a single connection,
+// the transaction is committed when fn succeeds and rolled back otherwise
+func (m *Migrator) tx(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// the statements are sent as is since database/sql has no way to begin a transaction with them
+	rollback := func() {
+		conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK")
+	}
+	for _, stmt := range m.begin {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			rollback()
+			return err
+		}
+	}
+	if err := fn(conn); err != nil {
+		rollback()
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		rollback()
+		return err
+	}
+
+	return nil
+}
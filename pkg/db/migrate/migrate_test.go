@@ -0,0 +1,92 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// go test -timeout 30s -run ^TestLoad$ ./pkg/db/migrate -v
+func TestLoad(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	migrations, err := Load(fstest.MapFS{
+		"0002_add_index.up.sql":        {Data: []byte("CREATE INDEX idx ON t(c);")},
+		"0001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"0001_initial_schema.down.sql": {Data: []byte("DROP TABLE t;")},
+	})
+	require.NoError(err)
+	require.Len(migrations, 2)
+
+	assert.Equal(int64(1), migrations[0].Version)
+	assert.Equal("initial_schema", migrations[0].Name)
+	assert.Equal("CREATE TABLE t (c INT);", migrations[0].Up)
+	assert.Equal("DROP TABLE t;", migrations[0].Down)
+
+	assert.Equal(int64(2), migrations[1].Version)
+	assert.Equal("add_index", migrations[1].Name)
+	assert.Empty(migrations[1].Down)
+}
+
+// go test -timeout 30s -run ^TestLoadInvalid$ ./pkg/db/migrate -v
+func TestLoadInvalid(t *testing.T) {
+	for name, files := range map[string]fstest.MapFS{
+		"bad file name":   {"initial.sql": {Data: []byte("SELECT 1;")}},
+		"missing up file": {"0001_initial.down.sql": {Data: []byte("SELECT 1;")}},
+		"conflicting names": {
+			"0001_initial.up.sql": {Data: []byte("SELECT 1;")},
+			"0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(files)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// go test -timeout 30s -run ^TestRunRechecksLockedState$ ./pkg/db/migrate -v
+func TestRunRechecksLockedState(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(err)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db, fstest.MapFS{
+		"0001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"0001_initial_schema.down.sql": {Data: []byte("DROP TABLE t;")},
+		"0002_broken.up.sql":           {Data: []byte("CREATE TABLE u (c INT); INSERT INTO missing VALUES (1);")},
+	}, "BEGIN IMMEDIATE")
+	require.NoError(err)
+	require.NoError(m.ensureMigrationsTable(ctx))
+	initial := m.Migrations()[0]
+	record := `INSERT INTO schema_migrations (version, name) VALUES (1, 'initial_schema')`
+
+	ran, err := m.run(ctx, initial.Version, false, initial.Up, record)
+	require.NoError(err)
+	assert.True(ran)
+
+	// another process found the migration pending before it was applied, it is skipped once the lock is held
+	ran, err = m.run(ctx, initial.Version, false, initial.Up, record)
+	require.NoError(err)
+	assert.False(ran)
+
+	// a failed migration is rolled back as a whole
+	_, err = m.Up(ctx)
+	assert.Error(err)
+	_, err = db.ExecContext(ctx, "SELECT * FROM u")
+	assert.Error(err)
+	version, err := m.Version(ctx)
+	require.NoError(err)
+	assert.Equal(int64(1), version)
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// PoolConfig represents connection pool settings of a sql database
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// DefaultPoolConfig returns the pool settings used when none are configured
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    25,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+}
+
+// Apply sets the pool settings on db
+func (c PoolConfig) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
+
+// RetryConfig represents how connectivity is verified on startup
+type RetryConfig struct {
+	Attempts int
+	Interval time.Duration
+}
+
+// DefaultRetryConfig returns the startup retry settings used when none are configured
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		Attempts: 5,
+		Interval: 2 * time.Second,
+	}
+}
+
+// PingWithRetry pings db until it responds or the attempts are exhausted,
+// the interval between attempts doubles after every failure
+func PingWithRetry(ctx context.Context, db *sql.DB, retry RetryConfig, logger *slog.Logger) error {
+	var err error
+	interval := retry.Interval
+	for attempt := 1; attempt <= retry.Attempts; attempt++ {
+		if err = db.PingContext(ctx); err == nil {
+			return nil
+		}
+		if attempt == retry.Attempts {
+			break
+		}
+
+		logger.Warn("db-ping-failed:retrying-after-backoff",
+			slog.Int("attempt", attempt),
+			slog.String("backoffDuration", interval.String()),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+
+	return fmt.Errorf("failed to reach db after %d attempts: %w", retry.Attempts, err)
+}
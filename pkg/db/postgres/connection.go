@@ -1,21 +1,88 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"log/slog"
 
-	_ "github.com/jackc/pgx/v5/stdlib" // Import the pgx driver for database/sql
+	"github.com/danielboakye/github-repo-stats/pkg/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib" // Import the pgx driver for database/sql
 )
 
 // PostgresURLEnvVar represents env variable for postgres connection url
 const PostgresURLEnvVar = "POSTGRES_URL"
 
-// NewConnection starts a new postgres db connection
-func NewConnection(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("pgx", dsn)
+// Config represents postgres connection settings
+type Config struct {
+	URL   string
+	Pool  db.PoolConfig
+	Retry db.RetryConfig
+	// UsePgxPool backs database/sql with a native pgxpool instead of the database/sql pool
+	UsePgxPool bool
+}
+
+// poolConnector closes the pgxpool when the *sql.DB using it is closed
+type poolConnector struct {
+	driver.Connector
+	pool *pgxpool.Pool
+}
+
+// Close implements io.Closer which is called by sql.DB.Close
+func (c poolConnector) Close() error {
+	c.pool.Close()
+	return nil
+}
+
+// NewConnection starts a new postgres db connection and verifies it is reachable
+func NewConnection(ctx context.Context, cfg Config, logger *slog.Logger) (*sql.DB, error) {
+	var conn *sql.DB
+	if cfg.UsePgxPool {
+		pool, err := newPool(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		conn = sql.OpenDB(poolConnector{Connector: stdlib.GetPoolConnector(pool), pool: pool})
+		// idle connections are managed by the pgxpool, see stdlib.OpenDBFromPool
+		conn.SetMaxIdleConns(0)
+	} else {
+		var err error
+		conn, err = sql.Open("pgx", cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to db: %w", err)
+		}
+		cfg.Pool.Apply(conn)
+	}
+
+	if err := db.PingWithRetry(ctx, conn, cfg.Retry, logger); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func newPool(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse db url: %w", err)
+	}
+	if cfg.Pool.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(cfg.Pool.MaxOpenConns)
+	}
+	if cfg.Pool.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.Pool.ConnMaxLifetime
+	}
+	if cfg.Pool.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.Pool.ConnMaxIdleTime
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to db: %w", err)
 	}
 
-	return db, nil
+	return pool, nil
 }
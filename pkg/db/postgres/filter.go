@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/db"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// whereBuilder collects the clauses of a WHERE condition and their positional args
+type whereBuilder struct {
+	clauses []string
+	args    []any
+}
+
+// arg adds a positional arg and returns its placeholder
+func (w *whereBuilder) arg(value any) string {
+	w.args = append(w.args, value)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+// add adds a clause that must be true
+func (w *whereBuilder) add(clause string) {
+	w.clauses = append(w.clauses, clause)
+}
+
+// String returns the clauses joined by AND
+func (w *whereBuilder) String() string {
+	return strings.Join(w.clauses, " AND ")
+}
+
+// addCommitFilter adds the clauses of filter on the commits table
+func (w *whereBuilder) addCommitFilter(filter repository.CommitFilter) {
+	if filter.Author != "" {
+		author := w.arg(strings.ToLower(filter.Author))
+		w.add(fmt.Sprintf("(lower(author_name) = %[1]s OR lower(author_email) = %[1]s OR lower(author_login) = %[1]s)", author))
+	}
+	if filter.Since != nil {
+		w.add("commit_date >= " + w.arg(filter.Since.UTC()))
+	}
+	if filter.Until != nil {
+		w.add("commit_date <= " + w.arg(filter.Until.UTC()))
+	}
+	if filter.ExcludeMerges {
+		w.add("parent_count <= 1")
+	}
+	if filter.Message != "" {
+		w.add(fmt.Sprintf(`commit_message ILIKE %s ESCAPE '\'`, w.arg("%"+db.EscapeLike(filter.Message)+"%")))
+	}
+	if filter.Path != "" {
+		path := strings.TrimSuffix(filter.Path, "/")
+		w.add(fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM commit_files f
+			WHERE f.repository_id = commits.repository_id AND f.commit_hash = commits.commit_hash
+			AND (f.path = %s OR f.path LIKE %s ESCAPE '\')
+		)`, w.arg(path), w.arg(db.EscapeLike(path)+"/%")))
+	}
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullTime returns NULL for nil times
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+// deltaArgs returns the additions, deletions and files_changed args of stats, NULL when stats is nil
+func deltaArgs(stats *repository.CommitDelta) (additions, deletions, filesChanged sql.NullInt64) {
+	if stats == nil {
+		return
+	}
+	return sql.NullInt64{Int64: int64(stats.Additions), Valid: true},
+		sql.NullInt64{Int64: int64(stats.Deletions), Valid: true},
+		sql.NullInt64{Int64: int64(stats.FilesChanged), Valid: true}
+}
+
+// addCursor adds the keyset condition of cursor for commits read in the given order
+func (w *whereBuilder) addCursor(cursor *repository.Cursor, descending bool) {
+	date, hash := w.arg(cursor.Date.UTC()), w.arg(cursor.Hash)
+	if descending {
+		w.add(fmt.Sprintf("(commit_date < %[1]s OR (commit_date = %[1]s AND commit_hash > %[2]s))", date, hash))
+		return
+	}
+	w.add(fmt.Sprintf("(commit_date > %[1]s OR (commit_date = %[1]s AND commit_hash < %[2]s))", date, hash))
+}
+
+// commitOrder returns the ORDER BY of commits read in the given order,
+// commits with the same date are ordered by hash to match idx_commits_repository_date
+func commitOrder(descending bool) string {
+	if descending {
+		return "commit_date DESC, commit_hash ASC"
+	}
+	return "commit_date ASC, commit_hash DESC"
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"database/sql"
+	"embed"
+	"io/fs"
+
+	"github.com/danielboakye/github-repo-stats/pkg/db/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations returns the embedded postgres schema migrations
+func Migrations() fs.FS {
+	migrations, _ := fs.Sub(migrationFiles, "migrations")
+	return migrations
+}
+
+// NewMigrator returns a migrator for the embedded postgres schema migrations
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	// the advisory lock is held until each migration is committed so processes sharing the database migrate one at a time
+	return migrate.New(db, Migrations(), "BEGIN", "SELECT pg_advisory_xact_lock(hashtext('schema_migrations'))")
+}
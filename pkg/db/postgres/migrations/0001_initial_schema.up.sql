@@ -1,6 +1,8 @@
+-- IF NOT EXISTS lets databases created before migrations were introduced adopt this version in place
+
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
-CREATE TABLE repository (
+CREATE TABLE IF NOT EXISTS repository (
     id uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
     repository_name VARCHAR(255) NOT NULL,
     description TEXT,
@@ -16,7 +18,7 @@ CREATE TABLE repository (
     UNIQUE (repository_name)
 );
 
-CREATE TABLE commits (
+CREATE TABLE IF NOT EXISTS commits (
     id uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
     commit_hash VARCHAR(100) NOT NULL,
     repository_id uuid REFERENCES repository(id) ON DELETE CASCADE,
@@ -30,10 +32,10 @@ CREATE TABLE commits (
 );
 
 -- Index on repository lookup on name
-CREATE INDEX idx_repository_name ON repository(repository_name);
+CREATE INDEX IF NOT EXISTS idx_repository_name ON repository(repository_name);
 
 -- Index for loading commits on repository_id FK
-CREATE INDEX idx_commits_repository_id ON commits(repository_id);
+CREATE INDEX IF NOT EXISTS idx_commits_repository_id ON commits(repository_id);
 
 -- Index for efficiently retrieving the top N commit authors
-CREATE INDEX idx_commits_author_name ON commits(author_name);
+CREATE INDEX IF NOT EXISTS idx_commits_author_name ON commits(author_name);
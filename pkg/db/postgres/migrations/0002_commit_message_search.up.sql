@@ -0,0 +1,6 @@
+-- Full-text search document of the commit message, kept in sync by postgres
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS commit_message_tsv tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', coalesce(commit_message, ''))) STORED;
+
+-- Index for full-text search over commit messages
+CREATE INDEX IF NOT EXISTS idx_commits_commit_message_tsv ON commits USING GIN (commit_message_tsv);
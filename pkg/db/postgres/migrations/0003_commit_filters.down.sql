@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS commit_files;
+
+ALTER TABLE commits DROP COLUMN IF EXISTS parent_count;
+ALTER TABLE commits DROP COLUMN IF EXISTS parents;
+ALTER TABLE commits DROP COLUMN IF EXISTS author_login;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_commits_repository_id ON commits(repository_id);
+
+DROP INDEX IF EXISTS idx_commits_repository_date;
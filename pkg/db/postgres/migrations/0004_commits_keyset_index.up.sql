@@ -0,0 +1,5 @@
+-- Index for paging commits of a repository by (commit_date, commit_hash),
+-- it also covers loading commits on the repository_id FK
+CREATE INDEX IF NOT EXISTS idx_commits_repository_date ON commits(repository_id, commit_date DESC, commit_hash);
+
+DROP INDEX IF EXISTS idx_commits_repository_id;
@@ -0,0 +1,6 @@
+ALTER TABLE commits DROP COLUMN IF EXISTS files_changed;
+ALTER TABLE commits DROP COLUMN IF EXISTS deletions;
+ALTER TABLE commits DROP COLUMN IF EXISTS additions;
+ALTER TABLE commits DROP COLUMN IF EXISTS committer_date;
+ALTER TABLE commits DROP COLUMN IF EXISTS committer_email;
+ALTER TABLE commits DROP COLUMN IF EXISTS committer_name;
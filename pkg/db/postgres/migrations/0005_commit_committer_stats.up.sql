@@ -0,0 +1,9 @@
+-- Committer of a commit, which differs from the author for rebased, cherry-picked or patch-applied commits
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS committer_name VARCHAR(255);
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS committer_email VARCHAR(255);
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS committer_date TIMESTAMP;
+
+-- Lines and files changed by a commit, NULL unless the source loads diffs
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS additions INT;
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS deletions INT;
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS files_changed INT;
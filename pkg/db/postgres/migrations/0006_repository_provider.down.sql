@@ -0,0 +1 @@
+ALTER TABLE repository DROP COLUMN IF EXISTS provider;
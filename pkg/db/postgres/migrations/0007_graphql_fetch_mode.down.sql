@@ -0,0 +1,2 @@
+ALTER TABLE commits DROP COLUMN IF EXISTS pull_requests;
+ALTER TABLE repository DROP COLUMN IF EXISTS fetch_mode;
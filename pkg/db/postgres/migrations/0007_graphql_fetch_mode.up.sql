@@ -0,0 +1,5 @@
+-- Api the commits of a repository are fetched with, graphql is only supported for github repositories
+ALTER TABLE repository ADD COLUMN IF NOT EXISTS fetch_mode VARCHAR(20) NOT NULL DEFAULT 'rest';
+
+-- Space separated numbers of the pull requests of a commit, only saved by the github graphql api
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS pull_requests TEXT NOT NULL DEFAULT '';
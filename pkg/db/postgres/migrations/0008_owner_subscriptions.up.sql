@@ -0,0 +1,21 @@
+-- Github organizations and users whose repositories are tracked, named {kind}:{login}
+CREATE TABLE IF NOT EXISTS owner_subscriptions (
+    id uuid NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+    owner_name VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    -- Space separated globs of the names of the repositories tracked and skipped
+    include_patterns TEXT NOT NULL DEFAULT '',
+    exclude_patterns TEXT NOT NULL DEFAULT '',
+    skip_forks BOOLEAN NOT NULL DEFAULT FALSE,
+    skip_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    last_synced_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    UNIQUE (owner_name)
+);
+
+-- Owner subscription a repository is tracked by, deleted_at is set once it is no longer listed for the owner
+ALTER TABLE repository ADD COLUMN IF NOT EXISTS owner_id uuid REFERENCES owner_subscriptions(id) ON DELETE SET NULL;
+ALTER TABLE repository ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Index for loading the repositories of an owner subscription
+CREATE INDEX IF NOT EXISTS idx_repository_owner_id ON repository(owner_id);
@@ -3,13 +3,23 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/tracing"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// uniqueViolationCode represents the postgres error code for unique constraint violations
+const uniqueViolationCode = "23505"
+
 // Repository represents the postgres implementation of repository.Repository
 type Repository struct {
 	db *sql.DB
@@ -20,27 +30,65 @@ func NewRepository(db *sql.DB) repository.Repository {
 	return &Repository{db: db}
 }
 
-// ErrRecordNotFound represents no record found in postgres datastore
-var ErrRecordNotFound = sql.ErrNoRows
+// mapError translates postgres errors into repository sentinel errors
+func mapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return fmt.Errorf("%s: %w", pgErr.ConstraintName, repository.ErrConflict)
+	}
+
+	return err
+}
+
+// startSpan starts a span for the repository method name
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "postgres."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", name),
+		),
+	)
+}
+
+// endSpan ends span recording err, a missing row is an expected outcome and not recorded as an error
+func endSpan(span trace.Span, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
 
 // GetRepositories implements repository.Repository
-func (p *Repository) GetRepositories(ctx context.Context) ([]*repository.GithubRepository, error) {
+func (p *Repository) GetRepositories(ctx context.Context) (_ []*repository.GithubRepository, err error) {
+	ctx, span := startSpan(ctx, "GetRepositories")
+	defer func() { endSpan(span, err) }()
+
 	var repositories []*repository.GithubRepository
 	query := `
-        SELECT id, repository_name, commit_last_pulled_time 
+        SELECT id, repository_name, provider, fetch_mode, commit_last_pulled_time, owner_id, deleted_at
         FROM repository
     `
 	rows, err := p.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		repo := &repository.GithubRepository{}
 		err := rows.Scan(
 			&repo.ID,
 			&repo.RepositoryName,
+			&repo.Provider,
+			&repo.FetchMode,
 			&repo.CommitLastPulledTime,
+			&repo.OwnerID,
+			&repo.DeletedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -48,28 +96,43 @@ func (p *Repository) GetRepositories(ctx context.Context) ([]*repository.GithubR
 		repositories = append(repositories, repo)
 	}
 
-	err = rows.Err()
-	if err == sql.ErrNoRows {
-		return nil, ErrRecordNotFound
-	}
-	if err != nil {
-		return nil, err
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
 	}
 
 	return repositories, nil
 }
 
 // GetRepositoryByName implements repository.Repository
-func (p *Repository) GetRepositoryByName(ctx context.Context, name string) (repository.GithubRepository, error) {
-	var repo repository.GithubRepository
-	query := `
-        SELECT id, repository_name, commit_last_pulled_time, description, url, language, forks_count, stars_count, open_issues_count, watchers_count, created_at, updated_at
+func (p *Repository) GetRepositoryByName(ctx context.Context, name string) (_ repository.GithubRepository, err error) {
+	ctx, span := startSpan(ctx, "GetRepositoryByName")
+	defer func() { endSpan(span, err) }()
+
+	query := fmt.Sprintf(`
+        SELECT %s
         FROM repository
         WHERE repository_name = $1
-    `
-	err := p.db.QueryRowContext(ctx, query, name).Scan(
+    `, repositoryColumns)
+	repo, err := scanRepository(p.db.QueryRowContext(ctx, query, name))
+	if err != nil {
+		return repo, mapError(err)
+	}
+
+	return repo, nil
+}
+
+// repositoryColumns are the columns of repository read by scanRepository
+const repositoryColumns = `id, repository_name, provider, fetch_mode, commit_last_pulled_time, description, url, language,
+			forks_count, stars_count, open_issues_count, watchers_count, created_at, updated_at, owner_id, deleted_at`
+
+// scanRepository scans a row of repositoryColumns
+func scanRepository(row interface{ Scan(...any) error }) (repository.GithubRepository, error) {
+	var repo repository.GithubRepository
+	err := row.Scan(
 		&repo.ID,
 		&repo.RepositoryName,
+		&repo.Provider,
+		&repo.FetchMode,
 		&repo.CommitLastPulledTime,
 		&repo.Description,
 		&repo.URL,
@@ -80,34 +143,36 @@ func (p *Repository) GetRepositoryByName(ctx context.Context, name string) (repo
 		&repo.WatchersCount,
 		&repo.CreatedAt,
 		&repo.UpdatedAt,
+		&repo.OwnerID,
+		&repo.DeletedAt,
 	)
-	if err == sql.ErrNoRows {
-		return repo, ErrRecordNotFound
-	}
-	if err != nil {
-		return repo, err
-	}
 
-	return repo, nil
+	return repo, err
 }
 
 // CreateRepository implements repository.Repository
-func (p *Repository) CreateRepository(ctx context.Context, repoName string) (string, error) {
+func (p *Repository) CreateRepository(ctx context.Context, repoName string) (_ string, err error) {
+	ctx, span := startSpan(ctx, "CreateRepository")
+	defer func() { endSpan(span, err) }()
+
 	repoID := uuid.New().String()
 	query := `
-		INSERT INTO repository (id, repository_name)
-		VALUES ($1,$2)
+		INSERT INTO repository (id, repository_name, provider)
+		VALUES ($1,$2,$3)
 		`
-	_, err := p.db.ExecContext(ctx, query, repoID, repoName)
+	_, err = p.db.ExecContext(ctx, query, repoID, repoName, repository.ProviderOf(repoName))
 	if err != nil {
-		return repoID, fmt.Errorf("could not insert repository: %w", err)
+		return repoID, fmt.Errorf("could not insert repository: %w", mapError(err))
 	}
 
 	return repoID, nil
 }
 
 // UpdateRepository implements repository.Repository
-func (p *Repository) UpdateRepository(ctx context.Context, repo *repository.GithubRepository) error {
+func (p *Repository) UpdateRepository(ctx context.Context, repo *repository.GithubRepository) (err error) {
+	ctx, span := startSpan(ctx, "UpdateRepository")
+	defer func() { endSpan(span, err) }()
+
 	query := `
         UPDATE repository
         SET 
@@ -121,7 +186,7 @@ func (p *Repository) UpdateRepository(ctx context.Context, repo *repository.Gith
             updated_at = $8
         WHERE id = $9
     `
-	_, err := p.db.ExecContext(ctx, query,
+	_, err = p.db.ExecContext(ctx, query,
 		repo.Description,
 		repo.URL,
 		repo.Language,
@@ -140,14 +205,17 @@ func (p *Repository) UpdateRepository(ctx context.Context, repo *repository.Gith
 }
 
 // UpdateCommitLastSyncTime implements repository.Repository
-func (p *Repository) UpdateCommitLastSyncTime(ctx context.Context, repoID string, syncTime time.Time) error {
+func (p *Repository) UpdateCommitLastSyncTime(ctx context.Context, repoID string, syncTime time.Time) (err error) {
+	ctx, span := startSpan(ctx, "UpdateCommitLastSyncTime")
+	defer func() { endSpan(span, err) }()
+
 	query := `
 	UPDATE repository
 	SET 
 		commit_last_pulled_time = $1
 	WHERE id = $2
 	`
-	_, err := p.db.ExecContext(ctx, query,
+	_, err = p.db.ExecContext(ctx, query,
 		syncTime,
 		repoID,
 	)
@@ -158,72 +226,232 @@ func (p *Repository) UpdateCommitLastSyncTime(ctx context.Context, repoID string
 	return nil
 }
 
+// UpdateFetchMode implements repository.Repository
+func (p *Repository) UpdateFetchMode(ctx context.Context, repoID string, mode repository.FetchMode) (err error) {
+	ctx, span := startSpan(ctx, "UpdateFetchMode")
+	defer func() { endSpan(span, err) }()
+
+	query := `
+	UPDATE repository
+	SET 
+		fetch_mode = $1
+	WHERE id = $2
+	`
+	_, err = p.db.ExecContext(ctx, query, mode, repoID)
+	if err != nil {
+		return fmt.Errorf("could not update repository: %w", err)
+	}
+
+	return nil
+}
+
 // SaveCommit implements repository.Repository
-func (p *Repository) SaveCommit(ctx context.Context, commit repository.GithubCommit) error {
+func (p *Repository) SaveCommit(ctx context.Context, commit repository.GithubCommit) (err error) {
+	ctx, span := startSpan(ctx, "SaveCommit")
+	defer func() { endSpan(span, err) }()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not insert commit: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO commits (commit_hash, repository_id, commit_message, author_name, author_email, commit_date, commit_url)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO commits (commit_hash, repository_id, commit_message, author_name, author_email, author_login, commit_date, commit_url, parents, parent_count,
+			committer_name, committer_email, committer_date, additions, deletions, files_changed, pull_requests)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
     	ON CONFLICT (commit_hash, repository_id) DO NOTHING`
-	_, err := p.db.ExecContext(ctx, query,
+	additions, deletions, filesChanged := deltaArgs(commit.Stats)
+	_, err = tx.ExecContext(ctx, query,
 		commit.CommitHash,
 		commit.RepositoryID,
 		commit.Message,
 		commit.AuthorName,
 		commit.AuthorEmail,
+		nullString(commit.AuthorLogin),
 		commit.Date,
 		commit.URL,
+		strings.Join(commit.Parents, " "),
+		len(commit.Parents),
+		nullString(commit.CommitterName),
+		nullString(commit.CommitterEmail),
+		nullTime(commit.CommitterDate),
+		additions,
+		deletions,
+		filesChanged,
+		repository.JoinNumbers(commit.PullRequests),
 	)
 	if err != nil {
-		return fmt.Errorf("could not insert repository: %w", err)
+		return fmt.Errorf("could not insert commit: %w", err)
 	}
 
-	return nil
+	filesQuery := `
+		INSERT INTO commit_files (repository_id, commit_hash, path)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+	for _, file := range commit.Files {
+		if _, err := tx.ExecContext(ctx, filesQuery, commit.RepositoryID, commit.CommitHash, file); err != nil {
+			return fmt.Errorf("could not insert commit file: %w", err)
+		}
+	}
+
+	return tx.Commit()
 }
 
 // GetCommitsByRepository implements repository.Repository
-func (p *Repository) GetCommitsByRepository(ctx context.Context, repoID string, limit, offset int) ([]*repository.GithubCommit, error) {
+func (p *Repository) GetCommitsByRepository(ctx context.Context, repoID string, filter repository.CommitFilter, page repository.Page) (_ []*repository.GithubCommit, err error) {
+	ctx, span := startSpan(ctx, "GetCommitsByRepository")
+	defer func() { endSpan(span, err) }()
+
 	var commits []*repository.GithubCommit
-	query := `
-        SELECT commit_hash, commit_message, author_name, author_email, commit_date, commit_url 
+	where := &whereBuilder{}
+	where.add("repository_id = " + where.arg(repoID))
+	where.addCommitFilter(filter)
+
+	descending := page.Descending(filter)
+	offset := page.Offset
+	if page.Cursor != nil {
+		where.addCursor(page.Cursor, descending)
+		offset = 0
+	}
+
+	query := fmt.Sprintf(`
+        SELECT %s
         FROM commits
-		WHERE repository_id=$1
-		ORDER BY commit_date DESC
-		LIMIT $2 OFFSET $3
-    `
-	rows, err := p.db.QueryContext(ctx, query, repoID, limit, offset)
+		WHERE %s
+		ORDER BY %s
+		LIMIT %s OFFSET %s
+    `, commitColumns, where.String(), commitOrder(descending), where.arg(page.Limit), where.arg(offset))
+	rows, err := p.db.QueryContext(ctx, query, where.args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		commit := &repository.GithubCommit{}
-		err := rows.Scan(
-			&commit.CommitHash,
-			&commit.Message,
-			&commit.AuthorName,
-			&commit.AuthorEmail,
-			&commit.Date,
-			&commit.URL,
-		)
+		commit, err := scanCommit(rows)
 		if err != nil {
 			return nil, err
 		}
 		commits = append(commits, commit)
 	}
 
-	err = rows.Err()
-	if err == sql.ErrNoRows {
-		return nil, ErrRecordNotFound
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	if page.Reversed() {
+		slices.Reverse(commits)
 	}
+
+	return commits, nil
+}
+
+// commitColumns are the columns of commits read by scanCommit
+const commitColumns = `commit_hash, commit_message, author_name, author_email, coalesce(author_login, ''), commit_date, commit_url, parents,
+			coalesce(committer_name, ''), coalesce(committer_email, ''), committer_date, additions, deletions, files_changed, pull_requests`
+
+// scanCommit scans a row of commitColumns
+func scanCommit(rows *sql.Rows) (*repository.GithubCommit, error) {
+	commit := &repository.GithubCommit{}
+	var (
+		parents, pullRequests              string
+		committerDate                      sql.NullTime
+		additions, deletions, filesChanged sql.NullInt64
+	)
+	err := rows.Scan(
+		&commit.CommitHash,
+		&commit.Message,
+		&commit.AuthorName,
+		&commit.AuthorEmail,
+		&commit.AuthorLogin,
+		&commit.Date,
+		&commit.URL,
+		&parents,
+		&commit.CommitterName,
+		&commit.CommitterEmail,
+		&committerDate,
+		&additions,
+		&deletions,
+		&filesChanged,
+		&pullRequests,
+	)
 	if err != nil {
 		return nil, err
 	}
+	commit.Parents = strings.Fields(parents)
+	commit.PullRequests = repository.SplitNumbers(pullRequests)
+	if committerDate.Valid {
+		commit.CommitterDate = &committerDate.Time
+	}
+	if additions.Valid {
+		commit.Stats = &repository.CommitDelta{
+			Additions:    int(additions.Int64),
+			Deletions:    int(deletions.Int64),
+			FilesChanged: int(filesChanged.Int64),
+		}
+	}
 
-	return commits, err
+	return commit, nil
+}
+
+// StreamCommitsByRepository implements repository.Repository, rows are read from the open query as fn consumes them
+func (p *Repository) StreamCommitsByRepository(ctx context.Context, repoID string, filter repository.CommitFilter, fn func(*repository.GithubCommit) error) (err error) {
+	ctx, span := startSpan(ctx, "StreamCommitsByRepository")
+	defer func() { endSpan(span, err) }()
+
+	where := &whereBuilder{}
+	where.add("repository_id = " + where.arg(repoID))
+	where.addCommitFilter(filter)
+
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM commits
+		WHERE %s
+		ORDER BY %s
+    `, commitColumns, where.String(), commitOrder(!filter.Ascending()))
+	rows, err := p.db.QueryContext(ctx, query, where.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		commit, err := scanCommit(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(commit); err != nil {
+			return err
+		}
+	}
+
+	return mapError(rows.Err())
+}
+
+// CountCommitsByRepository implements repository.Repository
+func (p *Repository) CountCommitsByRepository(ctx context.Context, repoID string, filter repository.CommitFilter) (_ int, err error) {
+	ctx, span := startSpan(ctx, "CountCommitsByRepository")
+	defer func() { endSpan(span, err) }()
+
+	where := &whereBuilder{}
+	where.add("repository_id = " + where.arg(repoID))
+	where.addCommitFilter(filter)
+
+	var count int
+	query := fmt.Sprintf(`SELECT count(*) FROM commits WHERE %s`, where.String())
+	if err := p.db.QueryRowContext(ctx, query, where.args...).Scan(&count); err != nil {
+		return 0, mapError(err)
+	}
+
+	return count, nil
 }
 
 // GetLeaderBoard implements repository.Repository
-func (p *Repository) GetLeaderBoard(ctx context.Context, limit int) ([]repository.CommitStats, error) {
+func (p *Repository) GetLeaderBoard(ctx context.Context, limit int) (_ []repository.CommitStats, err error) {
+	ctx, span := startSpan(ctx, "GetLeaderBoard")
+	defer func() { endSpan(span, err) }()
+
 	var leaderboard []repository.CommitStats
 	query := `
 	SELECT author_name, COUNT(id) as commit_count
@@ -236,6 +464,7 @@ func (p *Repository) GetLeaderBoard(ctx context.Context, limit int) ([]repositor
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		stat := repository.CommitStats{}
@@ -250,13 +479,9 @@ func (p *Repository) GetLeaderBoard(ctx context.Context, limit int) ([]repositor
 		leaderboard = append(leaderboard, stat)
 	}
 
-	err = rows.Err()
-	if err == sql.ErrNoRows {
-		return nil, ErrRecordNotFound
-	}
-	if err != nil {
-		return nil, err
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
 	}
 
-	return leaderboard, err
+	return leaderboard, nil
 }
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/danielboakye/github-repo-stats/pkg/db"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/repository/repositorytest"
+	"github.com/stretchr/testify/require"
+)
+
+// PostgresTestURLEnvVar represents env variable for the postgres database used by tests.
+// the database is migrated and truncated by the tests, never point it at real data
+const PostgresTestURLEnvVar = "POSTGRES_TEST_URL"
+
+// POSTGRES_TEST_URL=postgres://... go test -timeout 60s -run ^TestConformance$ ./pkg/db/postgres -v
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(PostgresTestURLEnvVar)
+	if dsn == "" {
+		t.Skipf("%s is not set", PostgresTestURLEnvVar)
+	}
+
+	conn, err := NewConnection(context.Background(), Config{
+		URL:   dsn,
+		Pool:  db.DefaultPoolConfig(),
+		Retry: db.RetryConfig{Attempts: 1},
+	}, slog.Default())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	migrator, err := NewMigrator(conn)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	repositorytest.Run(t, func(t *testing.T) repository.Repository {
+		_, err := conn.ExecContext(context.Background(), `TRUNCATE repository, commits`)
+		require.NoError(t, err)
+
+		return NewRepository(conn)
+	})
+}
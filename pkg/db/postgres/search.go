@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// headlineOptions configures ts_headline snippets to match the other stores
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=5, MaxFragments=2"
+
+// tsQuery converts query into to_tsquery syntax.
+// words only contain letters and digits so they are safe to use as tsquery operands
+func tsQuery(query repository.SearchQuery) string {
+	terms := make([]string, 0, len(query.Terms))
+	for _, term := range query.Terms {
+		phrase := strings.Join(term.Words, " <-> ")
+		if term.Prefix {
+			phrase += ":*"
+		}
+		terms = append(terms, "("+phrase+")")
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// SearchCommits implements repository.Repository
+func (p *Repository) SearchCommits(ctx context.Context, search repository.CommitSearch) (_ []*repository.CommitSearchResult, err error) {
+	ctx, span := startSpan(ctx, "SearchCommits")
+	defer func() { endSpan(span, err) }()
+
+	var results []*repository.CommitSearchResult
+	args := []any{tsQuery(search.Query), headlineOptions}
+
+	var repoFilter string
+	if len(search.RepositoryIDs) > 0 {
+		placeholders := make([]string, 0, len(search.RepositoryIDs))
+		for _, id := range search.RepositoryIDs {
+			args = append(args, id)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		repoFilter = "AND c.repository_id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	args = append(args, search.Limit, search.Offset)
+	query := fmt.Sprintf(`
+        SELECT c.commit_hash, c.commit_message, c.author_name, c.author_email, c.commit_date, c.commit_url, r.repository_name,
+            ts_rank(c.commit_message_tsv, q) AS rank,
+            ts_headline('english', coalesce(c.commit_message, ''), q, $2) AS snippet
+        FROM commits c
+        JOIN repository r ON r.id = c.repository_id
+        CROSS JOIN to_tsquery('english', $1) q
+        WHERE c.commit_message_tsv @@ q
+        %s
+        ORDER BY rank DESC, c.commit_date DESC
+        LIMIT $%d OFFSET $%d
+    `, repoFilter, len(args)-1, len(args))
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result := &repository.CommitSearchResult{}
+		err := rows.Scan(
+			&result.CommitHash,
+			&result.Message,
+			&result.AuthorName,
+			&result.AuthorEmail,
+			&result.Date,
+			&result.URL,
+			&result.RepositoryName,
+			&result.Rank,
+			&result.Snippet,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite" // Import the pure go sqlite driver for database/sql
+)
+
+// URLScheme represents the DSN scheme that selects the sqlite store, e.g. sqlite://data/github_repo_stats.db
+const URLScheme = "sqlite"
+
+// pragmas are applied to every new connection
+var pragmas = []string{
+	"foreign_keys(1)",
+	"busy_timeout(5000)",
+	"journal_mode(WAL)",
+}
+
+// NewConnection opens the sqlite database at dsn, creating it if it does not exist.
+// dsn is either a file path or a URL of format sqlite://{path}
+func NewConnection(dsn string) (*sql.DB, error) {
+	path, query, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(dsn, URLScheme+":"), "//"), "?")
+	if path == "" {
+		return nil, fmt.Errorf("sqlite database path is missing in dsn")
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sqlite dsn parameters: %w", err)
+	}
+	for _, pragma := range pragmas {
+		params.Add("_pragma", pragma)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to db: %w", err)
+	}
+
+	// sqlite allows a single writer at a time, sharing one connection
+	// avoids SQLITE_BUSY errors between the watcher goroutines
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open db: %w", err)
+	}
+
+	return db, nil
+}
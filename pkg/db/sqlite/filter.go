@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/db"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// whereBuilder collects the clauses of a WHERE condition and their args
+type whereBuilder struct {
+	clauses []string
+	args    []any
+}
+
+// add adds a clause that must be true with an arg for every ? placeholder in it
+func (w *whereBuilder) add(clause string, args ...any) {
+	w.clauses = append(w.clauses, clause)
+	w.args = append(w.args, args...)
+}
+
+// String returns the clauses joined by AND
+func (w *whereBuilder) String() string {
+	return strings.Join(w.clauses, " AND ")
+}
+
+// addCommitFilter adds the clauses of filter on the commits table
+func (w *whereBuilder) addCommitFilter(filter repository.CommitFilter) {
+	if filter.Author != "" {
+		w.add("(author_name = ? COLLATE NOCASE OR author_email = ? COLLATE NOCASE OR author_login = ? COLLATE NOCASE)",
+			filter.Author, filter.Author, filter.Author)
+	}
+	// dates are stored in UTC so they compare correctly as text
+	if filter.Since != nil {
+		w.add("commit_date >= ?", filter.Since.UTC())
+	}
+	if filter.Until != nil {
+		w.add("commit_date <= ?", filter.Until.UTC())
+	}
+	if filter.ExcludeMerges {
+		w.add("parent_count <= 1")
+	}
+	if filter.Message != "" {
+		// LIKE ignores case of ascii characters in sqlite
+		w.add(`commit_message LIKE ? ESCAPE '\'`, "%"+db.EscapeLike(filter.Message)+"%")
+	}
+	if filter.Path != "" {
+		// substr is used over LIKE as paths are case sensitive
+		dir := strings.TrimSuffix(filter.Path, "/") + "/"
+		w.add(`EXISTS (
+			SELECT 1 FROM commit_files f
+			WHERE f.repository_id = commits.repository_id AND f.commit_hash = commits.commit_hash
+			AND (f.path = ? OR substr(f.path, 1, ?) = ?)
+		)`, strings.TrimSuffix(dir, "/"), len(dir), dir)
+	}
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullTime returns NULL for nil times
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// deltaArgs returns the additions, deletions and files_changed args of stats, NULL when stats is nil
+func deltaArgs(stats *repository.CommitDelta) (additions, deletions, filesChanged sql.NullInt64) {
+	if stats == nil {
+		return
+	}
+	return sql.NullInt64{Int64: int64(stats.Additions), Valid: true},
+		sql.NullInt64{Int64: int64(stats.Deletions), Valid: true},
+		sql.NullInt64{Int64: int64(stats.FilesChanged), Valid: true}
+}
+
+// addCursor adds the keyset condition of cursor for commits read in the given order
+func (w *whereBuilder) addCursor(cursor *repository.Cursor, descending bool) {
+	date := cursor.Date.UTC()
+	if descending {
+		w.add("(commit_date < ? OR (commit_date = ? AND commit_hash > ?))", date, date, cursor.Hash)
+		return
+	}
+	w.add("(commit_date > ? OR (commit_date = ? AND commit_hash < ?))", date, date, cursor.Hash)
+}
+
+// commitOrder returns the ORDER BY of commits read in the given order,
+// commits with the same date are ordered by hash to match idx_commits_repository_date
+func commitOrder(descending bool) string {
+	if descending {
+		return "commit_date DESC, commit_hash ASC"
+	}
+	return "commit_date ASC, commit_hash DESC"
+}
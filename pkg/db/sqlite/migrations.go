@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"io/fs"
+
+	"github.com/danielboakye/github-repo-stats/pkg/db/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations returns the embedded sqlite schema migrations
+func Migrations() fs.FS {
+	migrations, _ := fs.Sub(migrationFiles, "migrations")
+	return migrations
+}
+
+// NewMigrator returns a migrator for the embedded sqlite schema migrations
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	// BEGIN IMMEDIATE takes the write lock of the database up front so processes sharing it migrate one at a time
+	return migrate.New(db, Migrations(), "BEGIN IMMEDIATE")
+}
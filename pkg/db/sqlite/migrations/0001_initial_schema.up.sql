@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS repository (
+    id TEXT NOT NULL PRIMARY KEY,
+    repository_name VARCHAR(255) NOT NULL,
+    description TEXT,
+    url VARCHAR(255),
+    language VARCHAR(100),
+    forks_count INT DEFAULT 0,
+    stars_count INT DEFAULT 0,
+    open_issues_count INT DEFAULT 0,
+    watchers_count INT DEFAULT 0,
+    commit_last_pulled_time TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMP,
+    UNIQUE (repository_name)
+);
+
+CREATE TABLE IF NOT EXISTS commits (
+    id TEXT NOT NULL PRIMARY KEY,
+    commit_hash VARCHAR(100) NOT NULL,
+    repository_id TEXT REFERENCES repository(id) ON DELETE CASCADE,
+    commit_message TEXT,
+    author_name VARCHAR(255),
+    author_email VARCHAR(255),
+    commit_date TIMESTAMP NOT NULL,
+    commit_url VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    UNIQUE (commit_hash, repository_id)
+);
+
+-- Index for loading commits on repository_id FK
+CREATE INDEX IF NOT EXISTS idx_commits_repository_id ON commits(repository_id);
+
+-- Index for efficiently retrieving the top N commit authors
+CREATE INDEX IF NOT EXISTS idx_commits_author_name ON commits(author_name);
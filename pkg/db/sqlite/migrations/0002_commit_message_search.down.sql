@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS commits_fts_update;
+
+DROP TRIGGER IF EXISTS commits_fts_delete;
+
+DROP TRIGGER IF EXISTS commits_fts_insert;
+
+DROP TABLE IF EXISTS commits_fts;
@@ -0,0 +1,23 @@
+-- Full-text search index over commit messages, kept in sync with commits by triggers
+CREATE VIRTUAL TABLE IF NOT EXISTS commits_fts USING fts5(
+    commit_message,
+    content='commits',
+    content_rowid='rowid',
+    tokenize='porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS commits_fts_insert AFTER INSERT ON commits BEGIN
+    INSERT INTO commits_fts(rowid, commit_message) VALUES (new.rowid, new.commit_message);
+END;
+
+CREATE TRIGGER IF NOT EXISTS commits_fts_delete AFTER DELETE ON commits BEGIN
+    INSERT INTO commits_fts(commits_fts, rowid, commit_message) VALUES ('delete', old.rowid, old.commit_message);
+END;
+
+CREATE TRIGGER IF NOT EXISTS commits_fts_update AFTER UPDATE OF commit_message ON commits BEGIN
+    INSERT INTO commits_fts(commits_fts, rowid, commit_message) VALUES ('delete', old.rowid, old.commit_message);
+    INSERT INTO commits_fts(rowid, commit_message) VALUES (new.rowid, new.commit_message);
+END;
+
+-- Index commits saved before this migration
+INSERT INTO commits_fts(commits_fts) VALUES ('rebuild');
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS commit_files;
+
+ALTER TABLE commits DROP COLUMN parent_count;
+ALTER TABLE commits DROP COLUMN parents;
+ALTER TABLE commits DROP COLUMN author_login;
@@ -0,0 +1,16 @@
+ALTER TABLE commits ADD COLUMN author_login VARCHAR(255);
+
+-- Space separated parent hashes, parent_count is kept for filtering out merge commits
+ALTER TABLE commits ADD COLUMN parents TEXT NOT NULL DEFAULT '';
+ALTER TABLE commits ADD COLUMN parent_count INT NOT NULL DEFAULT 0;
+
+-- Files changed by a commit, only saved by sources that load them
+CREATE TABLE IF NOT EXISTS commit_files (
+    repository_id TEXT NOT NULL REFERENCES repository(id) ON DELETE CASCADE,
+    commit_hash VARCHAR(100) NOT NULL,
+    path TEXT NOT NULL,
+    PRIMARY KEY (repository_id, commit_hash, path)
+);
+
+-- Index for filtering commits by path
+CREATE INDEX IF NOT EXISTS idx_commit_files_path ON commit_files(repository_id, path);
@@ -0,0 +1,6 @@
+ALTER TABLE commits DROP COLUMN files_changed;
+ALTER TABLE commits DROP COLUMN deletions;
+ALTER TABLE commits DROP COLUMN additions;
+ALTER TABLE commits DROP COLUMN committer_date;
+ALTER TABLE commits DROP COLUMN committer_email;
+ALTER TABLE commits DROP COLUMN committer_name;
@@ -0,0 +1,2 @@
+-- Git hosting service of a repository, repositories of other providers than github are named {provider}:{path}
+ALTER TABLE repository ADD COLUMN provider VARCHAR(20) NOT NULL DEFAULT 'github';
@@ -0,0 +1,2 @@
+ALTER TABLE commits DROP COLUMN pull_requests;
+ALTER TABLE repository DROP COLUMN fetch_mode;
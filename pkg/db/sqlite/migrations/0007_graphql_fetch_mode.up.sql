@@ -0,0 +1,5 @@
+-- Api the commits of a repository are fetched with, graphql is only supported for github repositories
+ALTER TABLE repository ADD COLUMN fetch_mode VARCHAR(20) NOT NULL DEFAULT 'rest';
+
+-- Space separated numbers of the pull requests of a commit, only saved by the github graphql api
+ALTER TABLE commits ADD COLUMN pull_requests TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_repository_owner_id;
+ALTER TABLE repository DROP COLUMN deleted_at;
+ALTER TABLE repository DROP COLUMN owner_id;
+DROP TABLE IF EXISTS owner_subscriptions;
@@ -0,0 +1,252 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/google/uuid"
+)
+
+// ownerColumns are the columns of owner_subscriptions read by scanOwner
+const ownerColumns = `id, owner_name, kind, include_patterns, exclude_patterns, skip_forks, skip_archived, last_synced_at, created_at`
+
+// scanOwner scans a row of ownerColumns
+func scanOwner(row interface{ Scan(...any) error }) (repository.OwnerSubscription, error) {
+	var (
+		owner            repository.OwnerSubscription
+		include, exclude string
+	)
+	err := row.Scan(
+		&owner.ID,
+		&owner.Name,
+		&owner.Kind,
+		&include,
+		&exclude,
+		&owner.SkipForks,
+		&owner.SkipArchived,
+		&owner.LastSyncedAt,
+		&owner.CreatedAt,
+	)
+	owner.Include = strings.Fields(include)
+	owner.Exclude = strings.Fields(exclude)
+
+	return owner, err
+}
+
+// CreateOwner implements repository.Repository
+func (p *Repository) CreateOwner(ctx context.Context, owner repository.OwnerSubscription) (string, error) {
+	ownerID := uuid.New().String()
+	query := `
+		INSERT INTO owner_subscriptions (id, owner_name, kind, include_patterns, exclude_patterns, skip_forks, skip_archived)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		`
+	_, err := p.db.ExecContext(ctx, query,
+		ownerID,
+		owner.Name,
+		owner.Kind,
+		strings.Join(owner.Include, " "),
+		strings.Join(owner.Exclude, " "),
+		owner.SkipForks,
+		owner.SkipArchived,
+	)
+	if err != nil {
+		return ownerID, fmt.Errorf("could not insert owner subscription: %w", mapError(err))
+	}
+
+	return ownerID, nil
+}
+
+// GetOwners implements repository.Repository
+func (p *Repository) GetOwners(ctx context.Context) ([]*repository.OwnerSubscription, error) {
+	var owners []*repository.OwnerSubscription
+	query := fmt.Sprintf(`SELECT %s FROM owner_subscriptions ORDER BY created_at, owner_name`, ownerColumns)
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		owner, err := scanOwner(rows)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, &owner)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return owners, nil
+}
+
+// GetOwnerByName implements repository.Repository
+func (p *Repository) GetOwnerByName(ctx context.Context, name string) (repository.OwnerSubscription, error) {
+	query := fmt.Sprintf(`SELECT %s FROM owner_subscriptions WHERE owner_name = ?`, ownerColumns)
+	owner, err := scanOwner(p.db.QueryRowContext(ctx, query, name))
+	if err != nil {
+		return owner, mapError(err)
+	}
+
+	return owner, nil
+}
+
+// UpdateOwnerLastSyncTime implements repository.Repository
+func (p *Repository) UpdateOwnerLastSyncTime(ctx context.Context, ownerID string, syncTime time.Time) error {
+	query := `
+	UPDATE owner_subscriptions
+	SET
+		last_synced_at = ?
+	WHERE id = ?
+	`
+	_, err := p.db.ExecContext(ctx, query, syncTime.UTC(), ownerID)
+	if err != nil {
+		return fmt.Errorf("could not update owner subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetRepositoriesByOwner implements repository.Repository
+func (p *Repository) GetRepositoriesByOwner(ctx context.Context, ownerID string) ([]*repository.GithubRepository, error) {
+	var repositories []*repository.GithubRepository
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM repository
+        WHERE owner_id = ?
+        ORDER BY repository_name
+    `, repositoryColumns)
+	rows, err := p.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		repo, err := scanRepository(rows)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, &repo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return repositories, nil
+}
+
+// UpdateRepositoryOwner implements repository.Repository
+func (p *Repository) UpdateRepositoryOwner(ctx context.Context, repoID, ownerID string) error {
+	query := `
+	UPDATE repository
+	SET
+		owner_id = ?
+	WHERE id = ?
+	`
+	_, err := p.db.ExecContext(ctx, query, ownerID, repoID)
+	if err != nil {
+		return fmt.Errorf("could not update repository: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRepositoryDeletedAt implements repository.Repository
+func (p *Repository) UpdateRepositoryDeletedAt(ctx context.Context, repoID string, deletedAt *time.Time) error {
+	query := `
+	UPDATE repository
+	SET
+		deleted_at = ?
+	WHERE id = ?
+	`
+	_, err := p.db.ExecContext(ctx, query, nullTime(deletedAt), repoID)
+	if err != nil {
+		return fmt.Errorf("could not update repository: %w", err)
+	}
+
+	return nil
+}
+
+// GetOwnerStats implements repository.Repository
+func (p *Repository) GetOwnerStats(ctx context.Context, ownerID string, limit int) (repository.OwnerStats, error) {
+	var stats repository.OwnerStats
+	query := `
+	SELECT
+		coalesce(sum(CASE WHEN deleted_at IS NULL THEN 1 ELSE 0 END), 0),
+		coalesce(sum(CASE WHEN deleted_at IS NULL THEN 0 ELSE 1 END), 0),
+		coalesce(sum(CASE WHEN deleted_at IS NULL THEN stars_count ELSE 0 END), 0),
+		coalesce(sum(CASE WHEN deleted_at IS NULL THEN forks_count ELSE 0 END), 0),
+		coalesce(sum(CASE WHEN deleted_at IS NULL THEN open_issues_count ELSE 0 END), 0),
+		coalesce(sum(CASE WHEN deleted_at IS NULL THEN watchers_count ELSE 0 END), 0)
+	FROM repository
+	WHERE owner_id = ?
+	`
+	err := p.db.QueryRowContext(ctx, query, ownerID).Scan(
+		&stats.Repositories,
+		&stats.DeletedRepositories,
+		&stats.StarsCount,
+		&stats.ForksCount,
+		&stats.OpenIssuesCount,
+		&stats.WatchersCount,
+	)
+	if err != nil {
+		return stats, mapError(err)
+	}
+
+	commitsQuery := `
+	SELECT count(c.id), count(DISTINCT c.author_name)
+	FROM commits c
+	JOIN repository r ON r.id = c.repository_id
+	WHERE r.owner_id = ? AND r.deleted_at IS NULL
+	`
+	if err := p.db.QueryRowContext(ctx, commitsQuery, ownerID).Scan(&stats.Commits, &stats.Authors); err != nil {
+		return stats, mapError(err)
+	}
+
+	authorsQuery := `
+	SELECT c.author_name, count(c.id) AS commit_count
+	FROM commits c
+	JOIN repository r ON r.id = c.repository_id
+	WHERE r.owner_id = ? AND r.deleted_at IS NULL
+	GROUP BY c.author_name
+	ORDER BY commit_count DESC, c.author_name
+	LIMIT ?
+	`
+	stats.TopAuthors, err = queryCommitStats(ctx, p.db, authorsQuery, ownerID, limit)
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// queryCommitStats reads the author and commit count rows of query
+func queryCommitStats(ctx context.Context, db *sql.DB, query string, args ...any) ([]repository.CommitStats, error) {
+	var stats []repository.CommitStats
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		stat := repository.CommitStats{}
+		if err := rows.Scan(&stat.AuthorName, &stat.CommitCount); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return stats, nil
+}
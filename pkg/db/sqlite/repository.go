@@ -0,0 +1,429 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Repository represents the sqlite implementation of repository.Repository
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository initiates a new sqlite repository
+func NewRepository(db *sql.DB) repository.Repository {
+	return &Repository{db: db}
+}
+
+// mapError translates sqlite errors into repository sentinel errors
+func mapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return fmt.Errorf("%s: %w", sqliteErr.Error(), repository.ErrConflict)
+	}
+
+	return err
+}
+
+// GetRepositories implements repository.Repository
+func (p *Repository) GetRepositories(ctx context.Context) ([]*repository.GithubRepository, error) {
+	var repositories []*repository.GithubRepository
+	query := `
+        SELECT id, repository_name, provider, fetch_mode, commit_last_pulled_time, owner_id, deleted_at
+        FROM repository
+    `
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		repo := &repository.GithubRepository{}
+		err := rows.Scan(
+			&repo.ID,
+			&repo.RepositoryName,
+			&repo.Provider,
+			&repo.FetchMode,
+			&repo.CommitLastPulledTime,
+			&repo.OwnerID,
+			&repo.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, repo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return repositories, nil
+}
+
+// GetRepositoryByName implements repository.Repository
+func (p *Repository) GetRepositoryByName(ctx context.Context, name string) (repository.GithubRepository, error) {
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM repository
+        WHERE repository_name = ?
+    `, repositoryColumns)
+	repo, err := scanRepository(p.db.QueryRowContext(ctx, query, name))
+	if err != nil {
+		return repo, mapError(err)
+	}
+
+	return repo, nil
+}
+
+// repositoryColumns are the columns of repository read by scanRepository
+const repositoryColumns = `id, repository_name, provider, fetch_mode, commit_last_pulled_time, description, url, language,
+			forks_count, stars_count, open_issues_count, watchers_count, created_at, updated_at, owner_id, deleted_at`
+
+// scanRepository scans a row of repositoryColumns
+func scanRepository(row interface{ Scan(...any) error }) (repository.GithubRepository, error) {
+	var repo repository.GithubRepository
+	err := row.Scan(
+		&repo.ID,
+		&repo.RepositoryName,
+		&repo.Provider,
+		&repo.FetchMode,
+		&repo.CommitLastPulledTime,
+		&repo.Description,
+		&repo.URL,
+		&repo.Language,
+		&repo.ForksCount,
+		&repo.StarsCount,
+		&repo.OpenIssuesCount,
+		&repo.WatchersCount,
+		&repo.CreatedAt,
+		&repo.UpdatedAt,
+		&repo.OwnerID,
+		&repo.DeletedAt,
+	)
+
+	return repo, err
+}
+
+// CreateRepository implements repository.Repository
+func (p *Repository) CreateRepository(ctx context.Context, repoName string) (string, error) {
+	repoID := uuid.New().String()
+	query := `
+		INSERT INTO repository (id, repository_name, provider)
+		VALUES (?,?,?)
+		`
+	_, err := p.db.ExecContext(ctx, query, repoID, repoName, repository.ProviderOf(repoName))
+	if err != nil {
+		return repoID, fmt.Errorf("could not insert repository: %w", mapError(err))
+	}
+
+	return repoID, nil
+}
+
+// UpdateRepository implements repository.Repository
+func (p *Repository) UpdateRepository(ctx context.Context, repo *repository.GithubRepository) error {
+	query := `
+        UPDATE repository
+        SET 
+            description = ?,
+            url = ?,
+            language = ?,
+            forks_count = ?,
+            stars_count = ?,
+            open_issues_count = ?,
+            watchers_count = ?,
+            updated_at = ?
+        WHERE id = ?
+    `
+	_, err := p.db.ExecContext(ctx, query,
+		repo.Description,
+		repo.URL,
+		repo.Language,
+		repo.ForksCount,
+		repo.StarsCount,
+		repo.OpenIssuesCount,
+		repo.WatchersCount,
+		time.Now().UTC(),
+		repo.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("could not update repository: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateCommitLastSyncTime implements repository.Repository
+func (p *Repository) UpdateCommitLastSyncTime(ctx context.Context, repoID string, syncTime time.Time) error {
+	query := `
+	UPDATE repository
+	SET 
+		commit_last_pulled_time = ?
+	WHERE id = ?
+	`
+	_, err := p.db.ExecContext(ctx, query,
+		syncTime.UTC(),
+		repoID,
+	)
+	if err != nil {
+		return fmt.Errorf("could not update repository: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateFetchMode implements repository.Repository
+func (p *Repository) UpdateFetchMode(ctx context.Context, repoID string, mode repository.FetchMode) error {
+	query := `
+	UPDATE repository
+	SET 
+		fetch_mode = ?
+	WHERE id = ?
+	`
+	_, err := p.db.ExecContext(ctx, query, mode, repoID)
+	if err != nil {
+		return fmt.Errorf("could not update repository: %w", err)
+	}
+
+	return nil
+}
+
+// SaveCommit implements repository.Repository
+func (p *Repository) SaveCommit(ctx context.Context, commit repository.GithubCommit) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not insert commit: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO commits (id, commit_hash, repository_id, commit_message, author_name, author_email, author_login, commit_date, commit_url, parents, parent_count,
+			committer_name, committer_email, committer_date, additions, deletions, files_changed, pull_requests)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    	ON CONFLICT (commit_hash, repository_id) DO NOTHING`
+	commitID := commit.ID
+	if commitID == "" {
+		commitID = uuid.New().String()
+	}
+	additions, deletions, filesChanged := deltaArgs(commit.Stats)
+	_, err = tx.ExecContext(ctx, query,
+		commitID,
+		commit.CommitHash,
+		commit.RepositoryID,
+		commit.Message,
+		commit.AuthorName,
+		commit.AuthorEmail,
+		nullString(commit.AuthorLogin),
+		// dates are stored in UTC so they sort correctly as text
+		commit.Date.UTC(),
+		commit.URL,
+		strings.Join(commit.Parents, " "),
+		len(commit.Parents),
+		nullString(commit.CommitterName),
+		nullString(commit.CommitterEmail),
+		nullTime(commit.CommitterDate),
+		additions,
+		deletions,
+		filesChanged,
+		repository.JoinNumbers(commit.PullRequests),
+	)
+	if err != nil {
+		return fmt.Errorf("could not insert commit: %w", err)
+	}
+
+	filesQuery := `
+		INSERT INTO commit_files (repository_id, commit_hash, path)
+		VALUES (?, ?, ?)
+		ON CONFLICT DO NOTHING`
+	for _, file := range commit.Files {
+		if _, err := tx.ExecContext(ctx, filesQuery, commit.RepositoryID, commit.CommitHash, file); err != nil {
+			return fmt.Errorf("could not insert commit file: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetCommitsByRepository implements repository.Repository
+func (p *Repository) GetCommitsByRepository(ctx context.Context, repoID string, filter repository.CommitFilter, page repository.Page) ([]*repository.GithubCommit, error) {
+	var commits []*repository.GithubCommit
+	where := &whereBuilder{}
+	where.add("repository_id = ?", repoID)
+	where.addCommitFilter(filter)
+
+	descending := page.Descending(filter)
+	offset := page.Offset
+	if page.Cursor != nil {
+		where.addCursor(page.Cursor, descending)
+		offset = 0
+	}
+
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM commits
+		WHERE %s
+		ORDER BY %s
+		LIMIT ? OFFSET ?
+    `, commitColumns, where.String(), commitOrder(descending))
+	rows, err := p.db.QueryContext(ctx, query, append(where.args, page.Limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		commit, err := scanCommit(rows)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	if page.Reversed() {
+		slices.Reverse(commits)
+	}
+
+	return commits, nil
+}
+
+// commitColumns are the columns of commits read by scanCommit
+const commitColumns = `commit_hash, commit_message, author_name, author_email, coalesce(author_login, ''), commit_date, commit_url, parents,
+			coalesce(committer_name, ''), coalesce(committer_email, ''), committer_date, additions, deletions, files_changed, pull_requests`
+
+// scanCommit scans a row of commitColumns
+func scanCommit(rows *sql.Rows) (*repository.GithubCommit, error) {
+	commit := &repository.GithubCommit{}
+	var (
+		parents, pullRequests              string
+		committerDate                      sql.NullTime
+		additions, deletions, filesChanged sql.NullInt64
+	)
+	err := rows.Scan(
+		&commit.CommitHash,
+		&commit.Message,
+		&commit.AuthorName,
+		&commit.AuthorEmail,
+		&commit.AuthorLogin,
+		&commit.Date,
+		&commit.URL,
+		&parents,
+		&commit.CommitterName,
+		&commit.CommitterEmail,
+		&committerDate,
+		&additions,
+		&deletions,
+		&filesChanged,
+		&pullRequests,
+	)
+	if err != nil {
+		return nil, err
+	}
+	commit.Parents = strings.Fields(parents)
+	commit.PullRequests = repository.SplitNumbers(pullRequests)
+	if committerDate.Valid {
+		commit.CommitterDate = &committerDate.Time
+	}
+	if additions.Valid {
+		commit.Stats = &repository.CommitDelta{
+			Additions:    int(additions.Int64),
+			Deletions:    int(deletions.Int64),
+			FilesChanged: int(filesChanged.Int64),
+		}
+	}
+
+	return commit, nil
+}
+
+// streamBatchSize is the number of commits StreamCommitsByRepository reads at once. the single connection of the pool
+// is released between batches so a slow consumer does not block other queries
+const streamBatchSize = 1000
+
+// StreamCommitsByRepository implements repository.Repository, commits are read in batches paged by cursor
+func (p *Repository) StreamCommitsByRepository(ctx context.Context, repoID string, filter repository.CommitFilter, fn func(*repository.GithubCommit) error) error {
+	page := repository.Page{Limit: streamBatchSize}
+	for {
+		commits, err := p.GetCommitsByRepository(ctx, repoID, filter, page)
+		if err != nil {
+			return err
+		}
+		for _, commit := range commits {
+			if err := fn(commit); err != nil {
+				return err
+			}
+		}
+		if len(commits) < streamBatchSize {
+			return nil
+		}
+		page.Cursor = repository.NewCursor(commits[len(commits)-1], false)
+	}
+}
+
+// CountCommitsByRepository implements repository.Repository
+func (p *Repository) CountCommitsByRepository(ctx context.Context, repoID string, filter repository.CommitFilter) (int, error) {
+	where := &whereBuilder{}
+	where.add("repository_id = ?", repoID)
+	where.addCommitFilter(filter)
+
+	var count int
+	query := fmt.Sprintf(`SELECT count(*) FROM commits WHERE %s`, where.String())
+	if err := p.db.QueryRowContext(ctx, query, where.args...).Scan(&count); err != nil {
+		return 0, mapError(err)
+	}
+
+	return count, nil
+}
+
+// GetLeaderBoard implements repository.Repository
+func (p *Repository) GetLeaderBoard(ctx context.Context, limit int) ([]repository.CommitStats, error) {
+	var leaderboard []repository.CommitStats
+	query := `
+	SELECT author_name, COUNT(id) as commit_count
+	FROM commits
+	GROUP BY author_name
+	ORDER BY commit_count DESC
+	LIMIT ?
+    `
+	rows, err := p.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		stat := repository.CommitStats{}
+		err := rows.Scan(
+			&stat.AuthorName,
+			&stat.CommitCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		leaderboard = append(leaderboard, stat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return leaderboard, nil
+}
@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/repository/repositorytest"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -timeout 30s -run ^TestConformance$ ./pkg/db/sqlite -v
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.Repository {
+		db, err := NewConnection(URLScheme + "://" + filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := NewMigrator(db)
+		require.NoError(t, err)
+		_, err = migrator.Up(context.Background())
+		require.NoError(t, err)
+
+		return NewRepository(db)
+	})
+}
+
+// go test -timeout 30s -run ^TestOwnerForeignKey$ ./pkg/db/sqlite -v
+func TestOwnerForeignKey(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	db, err := NewConnection(URLScheme + "://" + filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(err)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := NewMigrator(db)
+	require.NoError(err)
+	_, err = migrator.Up(ctx)
+	require.NoError(err)
+	repo := NewRepository(db)
+
+	ownerID, err := repo.CreateOwner(ctx, repository.OwnerSubscription{Name: "org:owner", Kind: repository.OwnerKindOrg})
+	require.NoError(err)
+	repoID, err := repo.CreateRepository(ctx, "owner/name")
+	require.NoError(err)
+	require.NoError(repo.UpdateRepositoryOwner(ctx, repoID, ownerID))
+	require.Error(repo.UpdateRepositoryOwner(ctx, repoID, "missing"), "owner_id references an owner subscription")
+
+	// repositories outlive the subscription they were tracked by
+	_, err = db.ExecContext(ctx, "DELETE FROM owner_subscriptions WHERE id = ?", ownerID)
+	require.NoError(err)
+	stored, err := repo.GetRepositoryByName(ctx, "owner/name")
+	require.NoError(err)
+	require.Nil(stored.OwnerID)
+
+	// the foreign key does not prevent rolling back
+	_, err = migrator.Down(ctx, 1)
+	require.NoError(err)
+	_, err = migrator.Up(ctx)
+	require.NoError(err)
+}
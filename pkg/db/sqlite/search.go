@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// ftsQuery converts query into fts5 MATCH syntax.
+// words only contain letters and digits so they are safe to quote as fts5 strings
+func ftsQuery(query repository.SearchQuery) string {
+	terms := make([]string, 0, len(query.Terms))
+	for _, term := range query.Terms {
+		phrase := `"` + strings.Join(term.Words, " ") + `"`
+		if term.Prefix {
+			phrase += "*"
+		}
+		terms = append(terms, phrase)
+	}
+
+	return strings.Join(terms, " AND ")
+}
+
+// SearchCommits implements repository.Repository
+func (p *Repository) SearchCommits(ctx context.Context, search repository.CommitSearch) ([]*repository.CommitSearchResult, error) {
+	var results []*repository.CommitSearchResult
+	args := []any{ftsQuery(search.Query)}
+
+	var repoFilter string
+	if len(search.RepositoryIDs) > 0 {
+		repoFilter = "AND c.repository_id IN (?" + strings.Repeat(", ?", len(search.RepositoryIDs)-1) + ")"
+		for _, id := range search.RepositoryIDs {
+			args = append(args, id)
+		}
+	}
+
+	args = append(args, search.Limit, search.Offset)
+	// bm25 scores better matches lower, it is negated to rank like the other stores
+	query := fmt.Sprintf(`
+        SELECT c.commit_hash, c.commit_message, c.author_name, c.author_email, c.commit_date, c.commit_url, r.repository_name,
+            -bm25(commits_fts) AS rank,
+            snippet(commits_fts, 0, '<mark>', '</mark>', '...', 20) AS snippet
+        FROM commits_fts
+        JOIN commits c ON c.rowid = commits_fts.rowid
+        JOIN repository r ON r.id = c.repository_id
+        WHERE commits_fts MATCH ?
+        %s
+        ORDER BY rank DESC, c.commit_date DESC
+        LIMIT ? OFFSET ?
+    `, repoFilter)
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result := &repository.CommitSearchResult{}
+		err := rows.Scan(
+			&result.CommitHash,
+			&result.Message,
+			&result.AuthorName,
+			&result.AuthorEmail,
+			&result.Date,
+			&result.URL,
+			&result.RepositoryName,
+			&result.Rank,
+			&result.Snippet,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return results, nil
+}
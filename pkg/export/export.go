@@ -0,0 +1,259 @@
+// Package export writes commits as csv, ndjson or parquet one commit at a time
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// Format represents an export file format
+type Format string
+
+// Export formats
+const (
+	FormatNDJSON  Format = "ndjson"
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+)
+
+// Formats lists the supported formats, the first one is the default
+var Formats = []Format{FormatNDJSON, FormatCSV, FormatParquet}
+
+// mediaTypes maps the media types accepted for every format, the first one is sent as the content type
+var mediaTypes = map[Format][]string{
+	FormatNDJSON:  {"application/x-ndjson", "application/ndjson", "application/jsonl"},
+	FormatCSV:     {"text/csv"},
+	FormatParquet: {"application/vnd.apache.parquet", "application/x-parquet"},
+}
+
+// parquetRowGroupSize is the number of commits buffered before a parquet row group is written,
+// it bounds the memory of parquet exports
+const parquetRowGroupSize = 10000
+
+// CSVHeader represents the columns of csv exports
+var CSVHeader = []string{
+	"commit_hash", "date", "author_name", "author_email", "author_login", "message", "url", "parents",
+	"committer_name", "committer_email", "committer_date", "additions", "deletions", "files_changed", "pull_requests",
+}
+
+// ParseFormat returns the format named s
+func ParseFormat(s string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(s, string(format)) {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown export format %q, must be one of: %s, %s, %s", s, FormatNDJSON, FormatCSV, FormatParquet)
+}
+
+// ContentType returns the media type of f
+func (f Format) ContentType() string {
+	return mediaTypes[f][0]
+}
+
+// Negotiate returns the format preferred by an Accept header, the default format when the header is empty or accepts anything.
+// ok is false when no format is acceptable
+func Negotiate(accept string) (_ Format, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return Formats[0], true
+	}
+
+	type acceptedRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []acceptedRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, acceptedRange{mediaType: mediaType, q: q})
+		}
+	}
+	// ranges of the same quality keep the order of the header
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	for _, r := range ranges {
+		for _, format := range Formats {
+			for _, mediaType := range mediaTypes[format] {
+				if matchesRange(r.mediaType, mediaType) {
+					return format, true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// matchesRange reports whether mediaType is in the media range of an Accept header, e.g. text/* or */*
+func matchesRange(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// Writer writes commits in an export format
+type Writer interface {
+	Write(commit *repository.GithubCommit) error
+	// Close writes anything buffered, it does not close the underlying writer
+	Close() error
+}
+
+// NewWriter returns a Writer of format to w
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		// write errors are buffered and reported by Close
+		_ = writer.Write(CSVHeader)
+		return &csvWriter{writer: writer}, nil
+	case FormatParquet:
+		return &parquetWriter{
+			writer: parquet.NewGenericWriter[parquetCommit](w,
+				parquet.Compression(&zstd.Codec{}),
+				parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+			),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ndjsonWriter writes commits as one json object per line
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(commit *repository.GithubCommit) error {
+	return n.encoder.Encode(commit)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// csvWriter writes commits as csv rows after a header row
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) Write(commit *repository.GithubCommit) error {
+	var committerDate, additions, deletions, filesChanged string
+	if commit.CommitterDate != nil {
+		committerDate = commit.CommitterDate.UTC().Format(time.RFC3339)
+	}
+	if commit.Stats != nil {
+		additions = strconv.Itoa(commit.Stats.Additions)
+		deletions = strconv.Itoa(commit.Stats.Deletions)
+		filesChanged = strconv.Itoa(commit.Stats.FilesChanged)
+	}
+
+	return c.writer.Write([]string{
+		commit.CommitHash,
+		commit.Date.UTC().Format(time.RFC3339),
+		commit.AuthorName,
+		commit.AuthorEmail,
+		commit.AuthorLogin,
+		commit.Message,
+		commit.URL,
+		strings.Join(commit.Parents, " "),
+		commit.CommitterName,
+		commit.CommitterEmail,
+		committerDate,
+		additions,
+		deletions,
+		filesChanged,
+		repository.JoinNumbers(commit.PullRequests),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// parquetCommit represents the columns of parquet exports
+type parquetCommit struct {
+	CommitHash  string    `parquet:"commit_hash"`
+	Date        time.Time `parquet:"date,timestamp(millisecond)"`
+	AuthorName  string    `parquet:"author_name"`
+	AuthorEmail string    `parquet:"author_email"`
+	AuthorLogin string    `parquet:"author_login,optional"`
+	Message     string    `parquet:"message"`
+	URL         string    `parquet:"url"`
+	Parents     []string  `parquet:"parents,list"`
+	// committer and stats are null when the source did not load them, zero optional values are written as null.
+	// committer_date is in unix milliseconds as pointers to time.Time cannot be timestamps
+	CommitterName  string  `parquet:"committer_name,optional"`
+	CommitterEmail string  `parquet:"committer_email,optional"`
+	CommitterDate  int64   `parquet:"committer_date,optional,timestamp(millisecond)"`
+	Additions      *int64  `parquet:"additions,optional"`
+	Deletions      *int64  `parquet:"deletions,optional"`
+	FilesChanged   *int64  `parquet:"files_changed,optional"`
+	PullRequests   []int64 `parquet:"pull_requests,list"`
+}
+
+// parquetWriter writes commits as parquet row groups of parquetRowGroupSize commits
+type parquetWriter struct {
+	writer *parquet.GenericWriter[parquetCommit]
+}
+
+func (p *parquetWriter) Write(commit *repository.GithubCommit) error {
+	row := parquetCommit{
+		CommitHash:     commit.CommitHash,
+		Date:           commit.Date.UTC(),
+		AuthorName:     commit.AuthorName,
+		AuthorEmail:    commit.AuthorEmail,
+		AuthorLogin:    commit.AuthorLogin,
+		Message:        commit.Message,
+		URL:            commit.URL,
+		Parents:        commit.Parents,
+		CommitterName:  commit.CommitterName,
+		CommitterEmail: commit.CommitterEmail,
+	}
+	if commit.CommitterDate != nil {
+		row.CommitterDate = commit.CommitterDate.UnixMilli()
+	}
+	if commit.Stats != nil {
+		additions, deletions, filesChanged := int64(commit.Stats.Additions), int64(commit.Stats.Deletions), int64(commit.Stats.FilesChanged)
+		row.Additions, row.Deletions, row.FilesChanged = &additions, &deletions, &filesChanged
+	}
+	for _, number := range commit.PullRequests {
+		row.PullRequests = append(row.PullRequests, int64(number))
+	}
+
+	_, err := p.writer.Write([]parquetCommit{row})
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.writer.Close()
+}
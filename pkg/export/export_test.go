@@ -0,0 +1,149 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -timeout 30s -run ^TestNegotiate$ ./pkg/export -v
+func TestNegotiate(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		accept string
+		format Format
+		ok     bool
+	}{
+		{accept: "", format: FormatNDJSON, ok: true},
+		{accept: "*/*", format: FormatNDJSON, ok: true},
+		{accept: "text/csv", format: FormatCSV, ok: true},
+		{accept: "text/*", format: FormatCSV, ok: true},
+		{accept: "application/vnd.apache.parquet", format: FormatParquet, ok: true},
+		{accept: "application/x-parquet", format: FormatParquet, ok: true},
+		{accept: "application/x-ndjson; charset=utf-8", format: FormatNDJSON, ok: true},
+		{accept: "text/csv;q=0.5, application/vnd.apache.parquet", format: FormatParquet, ok: true},
+		{accept: "text/csv, application/vnd.apache.parquet", format: FormatCSV, ok: true},
+		{accept: "text/csv;q=0, */*;q=0.1", format: FormatNDJSON, ok: true},
+		{accept: "application/xml", ok: false},
+		{accept: "text/csv;q=0", ok: false},
+	}
+
+	for _, tc := range testCases {
+		format, ok := Negotiate(tc.accept)
+		assert.Equal(tc.ok, ok, tc.accept)
+		assert.Equal(tc.format, format, tc.accept)
+	}
+}
+
+var committedAt = time.Date(2024, 4, 30, 9, 15, 0, 0, time.UTC)
+
+var testCommits = []*repository.GithubCommit{
+	{
+		CommitHash:  "a1",
+		Message:     "Merge branch 'main'\n\nwith \"quotes\", commas",
+		AuthorName:  "Alice",
+		AuthorEmail: "alice@example.com",
+		AuthorLogin: "alice-gh",
+		Date:        time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		URL:         "https://github.com/owner/name/commit/a1",
+		Parents:     []string{"b2", "c3"},
+	},
+	{
+		CommitHash:     "b2",
+		Message:        "Initial commit",
+		AuthorName:     "Bob",
+		AuthorEmail:    "bob@example.com",
+		Date:           time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC),
+		URL:            "https://github.com/owner/name/commit/b2",
+		CommitterName:  "Carol",
+		CommitterEmail: "carol@example.com",
+		CommitterDate:  &committedAt,
+		Stats:          &repository.CommitDelta{Additions: 10, Deletions: 2, FilesChanged: 3},
+		PullRequests:   []int{4, 15},
+	},
+}
+
+// write writes testCommits in format and returns the output
+func write(t *testing.T, format Format) []byte {
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, format)
+	require.NoError(t, err)
+	for _, commit := range testCommits {
+		require.NoError(t, writer.Write(commit))
+	}
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+// go test -timeout 30s -run ^TestWriters$ ./pkg/export -v
+func TestWriters(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var commits []*repository.GithubCommit
+	decoder := json.NewDecoder(bytes.NewReader(write(t, FormatNDJSON)))
+	for decoder.More() {
+		commit := &repository.GithubCommit{}
+		require.NoError(decoder.Decode(commit))
+		commits = append(commits, commit)
+	}
+	assert.Equal(testCommits, commits)
+
+	records, err := csv.NewReader(bytes.NewReader(write(t, FormatCSV))).ReadAll()
+	require.NoError(err)
+	assert.Equal([][]string{
+		CSVHeader,
+		{"a1", "2024-05-01T12:30:00Z", "Alice", "alice@example.com", "alice-gh", "Merge branch 'main'\n\nwith \"quotes\", commas", "https://github.com/owner/name/commit/a1", "b2 c3", "", "", "", "", "", "", ""},
+		{"b2", "2024-04-30T08:00:00Z", "Bob", "bob@example.com", "", "Initial commit", "https://github.com/owner/name/commit/b2", "", "Carol", "carol@example.com", "2024-04-30T09:15:00Z", "10", "2", "3", "4 15"},
+	}, records)
+
+	data := write(t, FormatParquet)
+	rows, err := parquet.Read[parquetCommit](bytes.NewReader(data), int64(len(data)))
+	require.NoError(err)
+	require.Len(rows, 2)
+	assert.Equal("a1", rows[0].CommitHash)
+	assert.True(testCommits[0].Date.Equal(rows[0].Date))
+	assert.Equal([]string{"b2", "c3"}, rows[0].Parents)
+	assert.Equal(testCommits[0].Message, rows[0].Message)
+	assert.Equal("", rows[1].AuthorLogin)
+	assert.Empty(rows[1].Parents)
+	assert.Zero(rows[0].CommitterDate)
+	assert.Nil(rows[0].Additions)
+	assert.Equal("Carol", rows[1].CommitterName)
+	assert.Equal(committedAt.UnixMilli(), rows[1].CommitterDate)
+	require.NotNil(rows[1].Additions)
+	assert.Equal(int64(10), *rows[1].Additions)
+	assert.Empty(rows[0].PullRequests)
+	assert.Equal([]int64{4, 15}, rows[1].PullRequests)
+
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(err)
+	var columns []string
+	for _, field := range file.Schema().Fields() {
+		columns = append(columns, field.Name())
+	}
+	assert.Equal(strings.Join(CSVHeader, ","), strings.Join(columns, ","), "parquet columns match the csv header")
+}
+
+// go test -timeout 30s -run ^TestParseFormat$ ./pkg/export -v
+func TestParseFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	format, err := ParseFormat("Parquet")
+	assert.NoError(err)
+	assert.Equal(FormatParquet, format)
+	assert.Equal("application/vnd.apache.parquet", format.ContentType())
+
+	_, err = ParseFormat("xml")
+	assert.EqualError(err, `unknown export format "xml", must be one of: ndjson, csv, parquet`)
+}
@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/response"
+	"github.com/danielboakye/github-repo-stats/pkg/services/githubrepo"
+	"github.com/go-chi/chi/middleware"
+)
+
+// handlerFunc is an http handler that returns errors for the server to write
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// handle adapts h to an http.HandlerFunc writing the errors it returns as problems,
+// path names the handler in logs
+func (s *Server) handle(path string, h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h(w, r); err != nil {
+			s.writeError(w, r, path, err)
+		}
+	}
+}
+
+// problem maps err to its problem, repository and webhook sentinel errors are mapped to their http status codes
+// and any other error is logged and reported as an internal error
+func (s *Server) problem(r *http.Request, path string, err error) *response.Problem {
+	var problem *response.Problem
+	switch {
+	case errors.As(err, &problem):
+		// copied as problems may be shared between requests
+		p := *problem
+		return &p
+	case errors.Is(err, repository.ErrNotFound):
+		return response.NotFound(err.Error())
+	case errors.Is(err, repository.ErrConflict):
+		return response.Conflict(err.Error())
+	case errors.Is(err, provider.ErrInvalidSignature):
+		return response.NewProblem(http.StatusUnauthorized, response.CodeInvalidSignature, err.Error())
+	case errors.Is(err, provider.ErrWebhookTooLarge):
+		return response.NewProblem(http.StatusRequestEntityTooLarge, response.CodePayloadTooLarge, err.Error())
+	case errors.Is(err, provider.ErrInvalidWebhook):
+		return response.InvalidRequest(err.Error())
+	case errors.Is(err, githubrepo.ErrWebhookDisabled):
+		return response.NotFound(err.Error())
+	default:
+		s.logger.ErrorContext(r.Context(), "internal-error",
+			slog.String("path", path),
+			slog.String("error", err.Error()),
+		)
+		return response.InternalError()
+	}
+}
+
+// writeError writes err as an application/problem+json response
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, path string, err error) {
+	problem := s.problem(r, path, err)
+	problem.RequestID = middleware.GetReqID(r.Context())
+
+	if err := response.WriteProblem(w, problem); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", path),
+		)
+	}
+}
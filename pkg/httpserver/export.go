@@ -0,0 +1,121 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/export"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/response"
+)
+
+const (
+	// formatQueryParam selects the export format instead of the Accept header, for clients that can not set headers
+	formatQueryParam = "format"
+
+	// exportFlushInterval is the number of commits written between flushes of an export to the client
+	exportFlushInterval = 1000
+)
+
+// fileNameReplacer turns repository names into file names
+var fileNameReplacer = strings.NewReplacer("/", "_", ":", "_")
+
+// committedWriter records whether anything was written to w, after which the status of the response is sent
+type committedWriter struct {
+	w         io.Writer
+	committed bool
+}
+
+func (c *committedWriter) Write(p []byte) (int, error) {
+	c.committed = true
+	return c.w.Write(p)
+}
+
+// ExportCommits is the http handler streaming every commit of a tracked repo matching the filter query params
+// as ndjson, csv or parquet, the format is negotiated from the Accept header
+func (s *Server) ExportCommits(w http.ResponseWriter, r *http.Request) error {
+	repoName := strings.TrimSpace(r.URL.Query().Get(repoNameQueryParam))
+	if repoName == "" {
+		return response.InvalidRequest("repoName is missing")
+	}
+	repoName, err := NormalizeRepoName(repoName)
+	if err != nil {
+		return response.InvalidRequest(err.Error())
+	}
+
+	filter, err := parseCommitFilter(r.URL.Query())
+	if err != nil {
+		return response.InvalidRequest(err.Error())
+	}
+
+	var format export.Format
+	if v := r.URL.Query().Get(formatQueryParam); v != "" {
+		if format, err = export.ParseFormat(v); err != nil {
+			return response.InvalidRequest(err.Error())
+		}
+	} else {
+		var ok bool
+		if format, ok = export.Negotiate(r.Header.Get("Accept")); !ok {
+			return response.NewProblem(http.StatusNotAcceptable, response.CodeNotAcceptable,
+				fmt.Sprintf("commits can be exported as %s, %s or %s",
+					export.FormatNDJSON.ContentType(), export.FormatCSV.ContentType(), export.FormatParquet.ContentType()))
+		}
+	}
+
+	// exports of large repositories outlive the write timeout of the server
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, fileNameReplacer.Replace(repoName), format))
+	out := &committedWriter{w: w}
+	writer, err := export.NewWriter(out, format)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = s.githubSvc.StreamCommits(r.Context(), repoName, filter, func(commit *repository.GithubCommit) error {
+		if err := writer.Write(commit); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushInterval != 0 {
+			return nil
+		}
+		// a failed flush means the client is gone
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil && !out.committed {
+		w.Header().Del("Content-Disposition")
+		return err
+	}
+	if err != nil {
+		// the status is sent, the client only sees a truncated body
+		s.logger.ErrorContext(r.Context(), "export-failed",
+			slog.String("format", string(format)),
+			slog.Int("commits", count),
+			slog.String("error", err.Error()),
+		)
+		return nil
+	}
+
+	s.logger.InfoContext(r.Context(), "export-complete",
+		slog.String("format", string(format)),
+		slog.Int("commits", count),
+	)
+	return nil
+}
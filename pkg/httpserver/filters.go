@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+const (
+	authorQueryParam        = "author"
+	sinceQueryParam         = "since"
+	untilQueryParam         = "until"
+	pathQueryParam          = "path"
+	excludeMergesQueryParam = "excludeMerges"
+	messageQueryParam       = "message"
+	sortQueryParam          = "sort"
+)
+
+// parseCommitFilter validates the commit filter query params
+func parseCommitFilter(query url.Values) (repository.CommitFilter, error) {
+	var (
+		filter = repository.CommitFilter{
+			Author:  query.Get(authorQueryParam),
+			Path:    query.Get(pathQueryParam),
+			Message: query.Get(messageQueryParam),
+			Sort:    repository.SortOrder(query.Get(sortQueryParam)),
+		}
+		err error
+	)
+
+	if filter.Since, err = parseDateParam(query, sinceQueryParam, repository.ParseDate); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = parseDateParam(query, untilQueryParam, repository.ParseUntil); err != nil {
+		return filter, err
+	}
+
+	if v := query.Get(excludeMergesQueryParam); v != "" {
+		if filter.ExcludeMerges, err = strconv.ParseBool(v); err != nil {
+			return filter, fmt.Errorf("%s must be true or false", excludeMergesQueryParam)
+		}
+	}
+
+	return filter, filter.Normalize()
+}
+
+// parseDateParam parses an ISO 8601 date or date time query param with parse
+func parseDateParam(query url.Values, name string, parse func(string) (time.Time, error)) (*time.Time, error) {
+	v := strings.TrimSpace(query.Get(name))
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", name, err)
+	}
+
+	return &t, nil
+}
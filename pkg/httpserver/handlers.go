@@ -1,49 +1,68 @@
 package httpserver
 
 import (
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
 	"github.com/danielboakye/github-repo-stats/pkg/response"
+	"github.com/go-chi/chi"
 )
 
 const (
 	repoNameQueryParam = "repoName"
 	limitQueryParam    = "limit"
 	offsetQueryParam   = "offset"
+	cursorQueryParam   = "cursor"
+	searchQueryParam   = "q"
+	providerQueryParam = "provider"
+
+	ownerURLParam    = "owner"
+	nameURLParam     = "name"
+	providerURLParam = "provider"
+
+	// maxPageSize limits the number of commits returned in a single page
+	maxPageSize = 100
 )
 
-// ValidateRepoName checks if the repository name is in the correct "owner/name" format.
-// for tracking in the context of dynamic owner repos requiring full repo name with owner prefix is necessary
-// as repo names are only unique per owner not in the whole github
-func ValidateRepoName(repoName string) error {
-	parts := strings.Split(repoName, "/")
-	if len(parts) != 2 {
-		return fmt.Errorf("repository name must be in the format 'owner/name'")
-	}
+// TrackRepositoryRequest represents the request body for tracking a new repository
+type TrackRepositoryRequest struct {
+	RepositoryName string `json:"repository_name"`
+	// FetchMode is rest unless graphql is requested for a github repository
+	FetchMode string `json:"fetch_mode,omitempty"`
+}
+
+// UpdateRepositoryRequest represents the request body for updating a tracked repository
+type UpdateRepositoryRequest struct {
+	FetchMode string `json:"fetch_mode"`
+}
 
-	owner, name := parts[0], parts[1]
-	if owner == "" || name == "" {
-		return fmt.Errorf("both owner and repository name must be non-empty")
+// NormalizeRepoName returns the name a repository given by a user is tracked by, e.g. owner/name for github:owner/name.
+// repositories of other providers than github are named {provider}:{path}, e.g. gitlab:group/subgroup/project
+func NormalizeRepoName(repoName string) (string, error) {
+	name, err := repository.ParseRepoName(strings.ToLower(strings.TrimSpace(repoName)))
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return name.String(), nil
 }
 
 // GetCommits is the http handler for GetCommits in github svc
-func (s *Server) GetCommits(w http.ResponseWriter, r *http.Request) {
-	repoName := strings.ToLower(strings.TrimSpace(r.URL.Query().Get(repoNameQueryParam)))
+func (s *Server) GetCommits(w http.ResponseWriter, r *http.Request) error {
+	repoName := strings.TrimSpace(r.URL.Query().Get(repoNameQueryParam))
 	if repoName == "" {
-		response.InvalidRequest(w, "repoName is missing")
-		return
+		return response.InvalidRequest("repoName is missing")
 	}
-	err := ValidateRepoName(repoName)
+	repoName, err := NormalizeRepoName(repoName)
 	if err != nil {
-		response.InvalidRequest(w, err.Error())
-		return
+		return response.InvalidRequest(err.Error())
 	}
 
 	limitStr := r.URL.Query().Get(limitQueryParam)
@@ -51,42 +70,188 @@ func (s *Server) GetCommits(w http.ResponseWriter, r *http.Request) {
 	if err != nil || limit <= 0 {
 		limit = 5
 	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
 
 	offsetStr := r.URL.Query().Get(offsetQueryParam)
 	offset, err := strconv.Atoi(offsetStr)
-	if err != nil {
+	if err != nil || offset < 0 {
 		offset = 0
 	}
 
-	commits, err := s.githubSvc.GetCommits(r.Context(), repoName, limit, offset)
+	page := repository.Page{Limit: limit, Offset: offset}
+	if token := r.URL.Query().Get(cursorQueryParam); token != "" {
+		if offset > 0 {
+			return response.InvalidRequest("cursor and offset can not be used together")
+		}
+		page.Cursor, err = repository.DecodeCursor(token)
+		if err != nil {
+			return response.InvalidRequest(err.Error())
+		}
+	}
+
+	filter, err := parseCommitFilter(r.URL.Query())
+	if err != nil {
+		return response.InvalidRequest(err.Error())
+	}
+
+	commitPage, err := s.githubSvc.GetCommits(r.Context(), repoName, filter, page)
 	if err != nil {
-		s.logger.Error("failed-getting-commits",
+		return err
+	}
+	commits := commitPage.Commits
+	if commits == nil {
+		commits = []*repository.GithubCommit{}
+	}
+
+	statusCode := http.StatusOK
+	meta := &response.Meta{
+		Total:      &commitPage.Total,
+		Limit:      limit,
+		Offset:     offset,
+		SyncStatus: syncStatus(commitPage.LastSyncedAt),
+	}
+	// commits are still being pulled for the first time
+	if len(commits) == 0 && page.Cursor == nil && commitPage.LastSyncedAt == nil {
+		statusCode = http.StatusAccepted
+		meta.SyncStatus.Message = fmt.Sprintf("%s is now being tracked. Please check back later", repoName)
+	}
+	if commitPage.Next != nil || commitPage.Prev != nil {
+		meta.Cursor = &response.CursorMeta{}
+		if commitPage.Next != nil {
+			meta.Cursor.Next = commitPage.Next.Encode()
+		}
+		if commitPage.Prev != nil {
+			meta.Cursor.Prev = commitPage.Prev.Encode()
+		}
+	}
+
+	links := &response.Links{Self: r.URL.RequestURI()}
+	if meta.Cursor != nil {
+		links.Next = pageURL(r, cursorQueryParam, meta.Cursor.Next)
+		links.Prev = pageURL(r, cursorQueryParam, meta.Cursor.Prev)
+	}
+	setLinkHeader(w, links)
+
+	if err := response.Data(w, statusCode, commits, meta, links); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
 			slog.String("path", "getCommits"),
-			slog.String("error", err.Error()),
 		)
-		response.InternalError(w)
-		return
-	}
-	if len(commits) == 0 {
-		if err := response.JSON(w, http.StatusAccepted, map[string]string{
-			"message": fmt.Sprintf("%s is now being tracked. Please check back later", repoName),
-		}); err != nil {
-			s.logger.Error("failed-encoding-json",
-				slog.String("path", "getCommits"),
-			)
+	}
+
+	return nil
+}
+
+// syncStatus returns the sync status of a repository last synced at lastSyncedAt
+func syncStatus(lastSyncedAt *time.Time) *response.SyncStatus {
+	if lastSyncedAt == nil {
+		return &response.SyncStatus{State: response.SyncStatePending}
+	}
+
+	return &response.SyncStatus{State: response.SyncStateSynced, LastSyncedAt: lastSyncedAt}
+}
+
+// pageURL returns the url of the request with param set to value, empty when value is empty.
+// offset is replaced when param is cursor as the two can not be combined
+func pageURL(r *http.Request, param, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	query := r.URL.Query()
+	if param == cursorQueryParam {
+		query.Del(offsetQueryParam)
+	}
+	query.Set(param, value)
+
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// setLinkHeader sets the Link header to the urls of the next and previous pages
+func setLinkHeader(w http.ResponseWriter, links *response.Links) {
+	var values []string
+	if links.Next != "" {
+		values = append(values, fmt.Sprintf(`<%s>; rel="next"`, links.Next))
+	}
+	if links.Prev != "" {
+		values = append(values, fmt.Sprintf(`<%s>; rel="prev"`, links.Prev))
+	}
+
+	if len(values) > 0 {
+		w.Header().Set("Link", strings.Join(values, ", "))
+	}
+}
+
+// SearchCommits is the http handler for SearchCommits in github svc
+func (s *Server) SearchCommits(w http.ResponseWriter, r *http.Request) error {
+	q := strings.TrimSpace(r.URL.Query().Get(searchQueryParam))
+	if q == "" {
+		return response.InvalidRequest("q is missing")
+	}
+	query, err := repository.ParseSearchQuery(q)
+	if err != nil {
+		return response.InvalidRequest(err.Error())
+	}
+
+	// repoName may be repeated or comma separated to search many repos
+	var repoNames []string
+	for _, value := range r.URL.Query()[repoNameQueryParam] {
+		for _, repoName := range strings.Split(value, ",") {
+			if strings.TrimSpace(repoName) == "" {
+				continue
+			}
+			repoName, err := NormalizeRepoName(repoName)
+			if err != nil {
+				return response.InvalidRequest(err.Error())
+			}
+			repoNames = append(repoNames, repoName)
 		}
-		return
 	}
 
-	if err := response.JSON(w, http.StatusOK, commits); err != nil {
-		s.logger.Error("failed-encoding-json",
-			slog.String("path", "getCommits"),
+	limit, err := strconv.Atoi(r.URL.Query().Get(limitQueryParam))
+	if err != nil || limit <= 0 {
+		limit = 5
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	offset, err := strconv.Atoi(r.URL.Query().Get(offsetQueryParam))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	results, err := s.githubSvc.SearchCommits(r.Context(), query, repoNames, limit, offset)
+	if err != nil {
+		return err
+	}
+	if results == nil {
+		results = []*repository.CommitSearchResult{}
+	}
+
+	// a full page may be followed by more results, the total is not counted for searches
+	links := &response.Links{Self: r.URL.RequestURI()}
+	if len(results) == limit {
+		links.Next = pageURL(r, offsetQueryParam, strconv.Itoa(offset+limit))
+	}
+	if offset > 0 {
+		links.Prev = pageURL(r, offsetQueryParam, strconv.Itoa(max(offset-limit, 0)))
+	}
+	setLinkHeader(w, links)
+
+	meta := &response.Meta{Limit: limit, Offset: offset}
+	if err := response.Data(w, http.StatusOK, results, meta, links); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "searchCommits"),
 		)
 	}
+
+	return nil
 }
 
 // GetLeaderBoard is the http handler for GetLeaderBoard in github svc
-func (s *Server) GetLeaderBoard(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetLeaderBoard(w http.ResponseWriter, r *http.Request) error {
 	countStr := r.URL.Query().Get(limitQueryParam)
 	count, err := strconv.Atoi(countStr)
 	if err != nil || count <= 0 {
@@ -95,39 +260,203 @@ func (s *Server) GetLeaderBoard(w http.ResponseWriter, r *http.Request) {
 
 	leaderBoard, err := s.githubSvc.GetLeaderBoard(r.Context(), count)
 	if err != nil {
-		s.logger.Error("failed-getting-leader-board",
-			slog.String("path", "getLeaderBoard"),
-			slog.String("error", err.Error()),
-		)
-		response.InternalError(w)
-		return
+		return err
 	}
+	if leaderBoard == nil {
+		leaderBoard = []repository.CommitStats{}
+	}
+
+	statusCode := http.StatusOK
+	meta := &response.Meta{Limit: count}
 	if len(leaderBoard) == 0 {
-		if err := response.JSON(w, http.StatusAccepted, map[string]string{
-			"message": "no repositories are currently being tracked",
-		}); err != nil {
-			s.logger.Error("failed-encoding-json",
-				slog.String("path", "getLeaderBoard"),
-			)
+		statusCode = http.StatusAccepted
+		meta.SyncStatus = &response.SyncStatus{
+			State:   response.SyncStateNotTracked,
+			Message: "no repositories are currently being tracked",
 		}
-		return
 	}
 
-	if err := response.JSON(w, http.StatusOK, leaderBoard); err != nil {
-		s.logger.Error("failed-encoding-json",
+	if err := response.Data(w, statusCode, leaderBoard, meta, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
 			slog.String("path", "getLeaderBoard"),
 		)
 	}
+
+	return nil
 }
 
-// NotFoundHandler handles all unfamiliar routes
-func (s *Server) NotFoundHandler(w http.ResponseWriter, r *http.Request) {
-	if err := response.JSON(w, http.StatusNotFound, map[string]string{
-		"error":   "Not Found",
-		"message": "The requested resource could not be found",
-	}); err != nil {
-		s.logger.Error("failed-encoding-json",
-			slog.String("path", "notFound"),
+// GetRepositories is the http handler for GetRepositories in github svc, the provider query param lists the repos of one provider
+func (s *Server) GetRepositories(w http.ResponseWriter, r *http.Request) error {
+	var filter repository.Provider
+	if v := r.URL.Query().Get(providerQueryParam); v != "" {
+		p, err := repository.ParseProvider(strings.ToLower(v))
+		if err != nil {
+			return response.InvalidRequest(err.Error())
+		}
+		filter = p
+	}
+
+	repos, err := s.githubSvc.GetRepositories(r.Context())
+	if err != nil {
+		return err
+	}
+	if filter != "" {
+		var filtered []*repository.GithubRepository
+		for _, repo := range repos {
+			if repo.Provider == filter {
+				filtered = append(filtered, repo)
+			}
+		}
+		repos = filtered
+	}
+	if repos == nil {
+		repos = []*repository.GithubRepository{}
+	}
+
+	total := len(repos)
+	if err := response.Data(w, http.StatusOK, repos, &response.Meta{Total: &total}, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "getRepositories"),
+		)
+	}
+
+	return nil
+}
+
+// repoNameParam returns the normalized name of the repository in the path of r
+func repoNameParam(r *http.Request) (string, error) {
+	// the owner of gitlab repos in subgroups is their encoded namespace, e.g. gitlab:group%2Fsubgroup/project
+	owner, err := url.PathUnescape(chi.URLParam(r, ownerURLParam))
+	if err != nil {
+		return "", response.InvalidRequest("invalid repository owner")
+	}
+	repoName, err := NormalizeRepoName(owner + "/" + chi.URLParam(r, nameURLParam))
+	if err != nil {
+		return "", response.InvalidRequest(err.Error())
+	}
+
+	return repoName, nil
+}
+
+// GetRepository is the http handler for GetRepository in github svc
+func (s *Server) GetRepository(w http.ResponseWriter, r *http.Request) error {
+	repoName, err := repoNameParam(r)
+	if err != nil {
+		return err
+	}
+
+	repo, err := s.githubSvc.GetRepository(r.Context(), repoName)
+	if err != nil {
+		return err
+	}
+
+	meta := &response.Meta{SyncStatus: syncStatus(repo.CommitLastPulledTime)}
+	if err := response.Data(w, http.StatusOK, repo, meta, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "getRepository"),
 		)
 	}
+
+	return nil
+}
+
+// TrackRepository is the http handler for TrackRepository in github svc
+func (s *Server) TrackRepository(w http.ResponseWriter, r *http.Request) error {
+	var req TrackRepositoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return response.InvalidRequest("invalid request body")
+	}
+
+	if strings.TrimSpace(req.RepositoryName) == "" {
+		return response.InvalidRequest("repository_name is missing")
+	}
+	repoName, err := NormalizeRepoName(req.RepositoryName)
+	if err != nil {
+		return response.InvalidRequest(err.Error())
+	}
+	var mode repository.FetchMode
+	if req.FetchMode != "" {
+		if mode, err = repository.ParseFetchMode(req.FetchMode, repository.ProviderOf(repoName)); err != nil {
+			return response.InvalidRequest(err.Error())
+		}
+	}
+
+	repo, err := s.githubSvc.TrackRepository(r.Context(), repoName, mode)
+	if err != nil {
+		return err
+	}
+
+	meta := &response.Meta{SyncStatus: syncStatus(repo.CommitLastPulledTime)}
+	if err := response.Data(w, http.StatusCreated, repo, meta, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "trackRepository"),
+		)
+	}
+
+	return nil
+}
+
+// UpdateRepository is the http handler for changing the fetch mode of a tracked repository in github svc
+func (s *Server) UpdateRepository(w http.ResponseWriter, r *http.Request) error {
+	repoName, err := repoNameParam(r)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateRepositoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return response.InvalidRequest("invalid request body")
+	}
+	if req.FetchMode == "" {
+		return response.InvalidRequest("fetch_mode is missing")
+	}
+	mode, err := repository.ParseFetchMode(req.FetchMode, repository.ProviderOf(repoName))
+	if err != nil {
+		return response.InvalidRequest(err.Error())
+	}
+
+	repo, err := s.githubSvc.UpdateFetchMode(r.Context(), repoName, mode)
+	if err != nil {
+		return err
+	}
+
+	meta := &response.Meta{SyncStatus: syncStatus(repo.CommitLastPulledTime)}
+	if err := response.Data(w, http.StatusOK, repo, meta, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "updateRepository"),
+		)
+	}
+
+	return nil
+}
+
+// Webhook is the http handler for push webhooks of a provider, a sync of the pushed repo is queued when it is tracked
+func (s *Server) Webhook(w http.ResponseWriter, r *http.Request) error {
+	p, err := repository.ParseProvider(chi.URLParam(r, providerURLParam))
+	if err != nil {
+		return response.NotFound(err.Error())
+	}
+
+	result, err := s.githubSvc.HandleWebhook(r.Context(), p, r)
+	if err != nil {
+		return err
+	}
+
+	if err := response.Data(w, http.StatusAccepted, result, nil, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "webhook"),
+		)
+	}
+
+	return nil
+}
+
+// NotFoundHandler handles all unfamiliar routes
+func (s *Server) NotFoundHandler(w http.ResponseWriter, r *http.Request) error {
+	return response.NewProblem(http.StatusNotFound, response.CodeRouteNotFound, "The requested resource could not be found")
+}
+
+// MethodNotAllowedHandler handles requests to known routes with unsupported methods
+func (s *Server) MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) error {
+	return response.NewProblem(http.StatusMethodNotAllowed, response.CodeMethodNotAllowed, fmt.Sprintf("method %s is not allowed", r.Method))
 }
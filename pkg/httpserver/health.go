@@ -0,0 +1,197 @@
+package httpserver
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/response"
+)
+
+const (
+	// readinessTimeout bounds how long a readiness check waits for its components
+	readinessTimeout = 2 * time.Second
+	// watcherStaleAfter is how old the watcher heartbeat may get before the watcher is reported down
+	watcherStaleAfter = 5 * time.Minute
+)
+
+// Component statuses reported by readiness checks
+const (
+	statusUp          = "up"
+	statusDown        = "down"
+	statusRateLimited = "rate_limited"
+)
+
+// PoolStats represents database connection pool stats in readiness responses
+type PoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+}
+
+// MigrationDetails represents the migrations section of readiness responses
+type MigrationDetails struct {
+	Version int64 `json:"version"`
+	Pending int   `json:"pending"`
+}
+
+// WatcherDetails represents the watcher section of readiness responses
+type WatcherDetails struct {
+	LastHeartbeat *time.Time `json:"last_heartbeat"`
+	Age           string     `json:"age,omitempty"`
+}
+
+// ComponentStatus represents the health of a single component in readiness responses
+type ComponentStatus struct {
+	Status  string      `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ReadinessResponse represents the readiness response
+type ReadinessResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// LivenessResponse represents the liveness response
+type LivenessResponse struct {
+	Status string `json:"status"`
+	Uptime string `json:"uptime"`
+}
+
+// Liveness reports that the process is alive, it does not check any dependency
+// so a dependency outage does not get the process restarted
+func (s *Server) Liveness(w http.ResponseWriter, r *http.Request) {
+	res := LivenessResponse{Status: "alive", Uptime: time.Since(s.startedAt).Round(time.Second).String()}
+	if err := response.JSON(w, http.StatusOK, res); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "liveness"),
+		)
+	}
+}
+
+// Readiness reports whether the server is ready to handle requests with the status of every component,
+// it is not ready when any component is down. a rate limited github is reachable and does not fail readiness.
+// the watcher and github are only checked when the watcher runs in the same process
+func (s *Server) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]func(context.Context) ComponentStatus{}
+	if s.options.Watcher {
+		checks["watcher"] = s.checkWatcher
+		checks["github"] = s.checkGithub
+	}
+	if s.options.DB != nil {
+		checks["database"] = s.checkDatabase
+	}
+	if s.options.Migrator != nil {
+		checks["migrations"] = s.checkMigrations
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	res := ReadinessResponse{Status: "ready", Components: map[string]ComponentStatus{}}
+	statusCode := http.StatusOK
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) ComponentStatus) {
+			defer wg.Done()
+			status := check(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			res.Components[name] = status
+			if status.Status == statusDown {
+				res.Status = "unavailable"
+				statusCode = http.StatusServiceUnavailable
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	if err := response.JSON(w, statusCode, res); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "readiness"),
+		)
+	}
+}
+
+func (s *Server) checkDatabase(ctx context.Context) ComponentStatus {
+	stats := s.options.DB.Stats()
+	status := ComponentStatus{
+		Status: statusUp,
+		Details: PoolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDuration:       stats.WaitDuration.String(),
+		},
+	}
+	if err := s.options.DB.PingContext(ctx); err != nil {
+		status.Status = statusDown
+		status.Error = err.Error()
+	}
+
+	return status
+}
+
+func (s *Server) checkMigrations(ctx context.Context) ComponentStatus {
+	version, err := s.options.Migrator.Version(ctx)
+	if err != nil {
+		return ComponentStatus{Status: statusDown, Error: err.Error()}
+	}
+	pending, err := s.options.Migrator.Pending(ctx)
+	if err != nil {
+		return ComponentStatus{Status: statusDown, Error: err.Error()}
+	}
+
+	status := ComponentStatus{Status: statusUp, Details: MigrationDetails{Version: version, Pending: len(pending)}}
+	if len(pending) > 0 {
+		status.Status = statusDown
+		status.Error = "migrations are pending, run the migrate command or start with -auto-migrate"
+	}
+
+	return status
+}
+
+func (s *Server) checkWatcher(ctx context.Context) ComponentStatus {
+	heartbeat := s.githubSvc.LastHeartbeat()
+	if heartbeat.IsZero() {
+		return ComponentStatus{Status: statusDown, Error: "watcher has not started", Details: WatcherDetails{}}
+	}
+
+	age := time.Since(heartbeat)
+	status := ComponentStatus{
+		Status:  statusUp,
+		Details: WatcherDetails{LastHeartbeat: &heartbeat, Age: age.Round(time.Second).String()},
+	}
+	if age > watcherStaleAfter {
+		status.Status = statusDown
+		status.Error = "watcher heartbeat is stale"
+	}
+
+	return status
+}
+
+func (s *Server) checkGithub(ctx context.Context) ComponentStatus {
+	github := s.githubSvc.GithubStatus(ctx)
+	switch {
+	case !github.Reachable:
+		return ComponentStatus{Status: statusDown, Error: github.Error, Details: github}
+	case github.RateLimited:
+		return ComponentStatus{Status: statusRateLimited, Details: github}
+	default:
+		return ComponentStatus{Status: statusUp, Details: github}
+	}
+}
@@ -1,44 +1,111 @@
 package httpserver
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/danielboakye/github-repo-stats/pkg/db/migrate"
 	"github.com/danielboakye/github-repo-stats/pkg/repository"
 	"github.com/danielboakye/github-repo-stats/pkg/services/githubrepo"
 	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// Timeouts represents the timeouts of the http server
+type Timeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// DefaultTimeouts returns the timeouts used when none are configured
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		ReadHeader: 5 * time.Second,
+		Read:       15 * time.Second,
+		Write:      30 * time.Second,
+		Idle:       2 * time.Minute,
+	}
+}
+
+// Options represents the optional dependencies and features of a Server
+type Options struct {
+	// DB and Migrator are used for readiness checks, they are nil for stores without a database
+	DB       *sql.DB
+	Migrator *migrate.Migrator
+	// API serves the /v1 routes, servers without it only serve health checks and metrics
+	API bool
+	// Watcher reports that the watcher runs in the same process, readiness then checks it and github
+	Watcher bool
+}
+
 // Server represents an HTTP server
 type Server struct {
 	addr       string
+	httpServer *http.Server
 	router     *chi.Mux
 	logger     *slog.Logger
 	repository repository.Repository
 	githubSvc  *githubrepo.Service
+	options    Options
+	startedAt  time.Time
 }
 
 // NewServer creates and returns a new Server instance
-func NewServer(addr string, r repository.Repository, githubSvc *githubrepo.Service, logger *slog.Logger) *Server {
+func NewServer(addr string, timeouts Timeouts, r repository.Repository, githubSvc *githubrepo.Service, options Options, logger *slog.Logger) *Server {
 	router := chi.NewRouter()
-	router.Use(middleware.Logger)
 
 	s := &Server{
-		addr:       addr,
+		addr: addr,
+		httpServer: &http.Server{
+			Addr:              addr,
+			Handler:           router,
+			ReadHeaderTimeout: timeouts.ReadHeader,
+			ReadTimeout:       timeouts.Read,
+			WriteTimeout:      timeouts.Write,
+			IdleTimeout:       timeouts.Idle,
+		},
 		router:     router,
 		logger:     logger,
 		repository: r,
 		githubSvc:  githubSvc,
+		options:    options,
+		startedAt:  time.Now(),
 	}
 
+	router.Use(otelhttp.NewMiddleware("http-server"))
+	router.Use(traceRoute)
+	router.Use(requestID)
+	router.Use(s.accessLog)
+	router.Use(instrument)
 	s.RegisterRoutes()
 
 	return s
 }
 
-// Start starts the HTTP server
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// Start starts the HTTP server and blocks until it fails or is shut down,
+// it returns nil after Shutdown
 func (s *Server) Start() error {
 	s.logger.Info("starting-server", slog.String("url", "http://"+s.addr))
-	return http.ListenAndServe(s.addr, s.router)
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to complete until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.InfoContext(ctx, "shutting-down-server")
+	return s.httpServer.Shutdown(ctx)
 }
@@ -1,63 +1,108 @@
 package httpserver
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/danielboakye/github-repo-stats/pkg/db/postgres"
+	"github.com/danielboakye/github-repo-stats/pkg/db/memory"
+	"github.com/danielboakye/github-repo-stats/pkg/logging"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/response"
 	"github.com/danielboakye/github-repo-stats/pkg/services/githubrepo"
+	"github.com/go-chi/chi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type Base struct {
-	mockDB sqlmock.Sqlmock
-	svc    *Server
+	repo repository.Repository
+	svc  *Server
 }
 
 func setup(t *testing.T) Base {
-	require := require.New(t)
-
-	db, mockDB, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	require.NoError(err)
-
-	postgresRepo := postgres.NewRepository(db)
+	memoryRepo := memory.NewRepository()
 	logger := slog.Default()
-	githubSvc := githubrepo.NewService(postgresRepo, logger, time.Now())
+	githubSvc := newService(memoryRepo, logger)
 
-	apiServer := NewServer(":9000", postgresRepo, githubSvc, logger)
+	apiServer := NewServer(":9000", DefaultTimeouts(), memoryRepo, githubSvc, Options{API: true, Watcher: true}, logger)
 
 	return Base{
-		mockDB: mockDB,
-		svc:    apiServer,
+		repo: memoryRepo,
+		svc:  apiServer,
 	}
 }
 
+// testWebhookSecret signs github webhooks in tests, gitlab webhooks are disabled
+const testWebhookSecret = "webhook-secret"
+
+// newService returns a github service which only pulls commits made from now on
+func newService(repo repository.Repository, logger *slog.Logger) *githubrepo.Service {
+	config := githubrepo.DefaultConfig()
+	config.Since = time.Now()
+	config.WebhookSecret = testWebhookSecret
+	return githubrepo.NewService(repo, logger, config)
+}
+
+// seedCommits tracks repoName and saves count commits authored by author
+func (b Base) seedCommits(t *testing.T, repoName, author string, count int) {
+	ctx := context.Background()
+	githubRepo, err := b.repo.GetRepositoryByName(ctx, repoName)
+	if err != nil {
+		githubRepo.ID, err = b.repo.CreateRepository(ctx, repoName)
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < count; i++ {
+		err := b.repo.SaveCommit(ctx, repository.GithubCommit{
+			RepositoryID: githubRepo.ID,
+			CommitHash:   fmt.Sprintf("%s-%d", author, i),
+			Message:      "commit message",
+			AuthorName:   author,
+			AuthorEmail:  author + "@example.com",
+			Date:         time.Date(2024, 5, 1, i, 0, 0, 0, time.UTC),
+			URL:          "https://github.com/" + repoName,
+		})
+		require.NoError(t, err)
+	}
+}
+
+// decodeEnvelope decodes the response envelope in w and its data into data
+func decodeEnvelope(t *testing.T, w *httptest.ResponseRecorder, data interface{}) response.Envelope {
+	env := response.Envelope{Data: data}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+	require.Equal(t, response.APIVersion, env.APIVersion)
+
+	return env
+}
+
 // go test -timeout 30s -run ^TestGetLeaderboard$ ./pkg/httpserver -v
 func TestGetLeaderboard(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 	base := setup(t)
 
-	base.mockDB.ExpectQuery(`
-				SELECT author_name, COUNT(id) as commit_count
-				FROM commits
-				GROUP BY author_name
-				ORDER BY commit_count DESC
-				LIMIT $1
-			`).
-		WithArgs(2).
-		WillReturnRows(
-			sqlmock.NewRows([]string{"author_name", "commit_count"}).
-				AddRow("user1", 5).
-				AddRow("user2", 3),
-		).
-		RowsWillBeClosed()
+	base.seedCommits(t, "owner/name", "user1", 5)
+	base.seedCommits(t, "owner/name", "user2", 3)
+	base.seedCommits(t, "owner/name", "user3", 1)
 
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodGet, "/v1/leaderboard?limit=2", nil)
@@ -65,13 +110,13 @@ func TestGetLeaderboard(t *testing.T) {
 
 	base.svc.router.ServeHTTP(w, r)
 
-	assert.NoError(base.mockDB.ExpectationsWereMet())
 	assert.Equal(http.StatusOK, w.Code)
 
 	res := []map[string]interface{}{}
-	err := json.Unmarshal(w.Body.Bytes(), &res)
-	require.NoError(err)
+	env := decodeEnvelope(t, w, &res)
 	assert.Len(res, 2)
+	require.NotNil(env.Meta)
+	assert.Equal(2, env.Meta.Limit)
 
 	user1 := res[0]
 	name, exits := user1["author_name"]
@@ -85,3 +130,1010 @@ func TestGetLeaderboard(t *testing.T) {
 	assert.Equal(name, "user2")
 	assert.Equal(user2["commit_count"], float64(3))
 }
+
+// go test -timeout 30s -run ^TestGetCommits$ ./pkg/httpserver -v
+func TestGetCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	base.seedCommits(t, "owner/name", "user1", 3)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/commits?repoName=owner/name&limit=2", nil)
+	r.Header.Set("Content-Type", "application/json")
+
+	base.svc.router.ServeHTTP(w, r)
+
+	assert.Equal(http.StatusOK, w.Code)
+
+	res := []map[string]interface{}{}
+	env := decodeEnvelope(t, w, &res)
+	require.Len(res, 2)
+	assert.Equal("user1-2", res[0]["commit_hash"])
+	assert.Equal("user1-1", res[1]["commit_hash"])
+
+	require.NotNil(env.Meta)
+	require.NotNil(env.Meta.Total)
+	assert.Equal(3, *env.Meta.Total)
+	assert.Equal(2, env.Meta.Limit)
+	require.NotNil(env.Meta.Cursor)
+	assert.NotEmpty(env.Meta.Cursor.Next)
+	assert.Empty(env.Meta.Cursor.Prev)
+	require.NotNil(env.Meta.SyncStatus)
+	assert.Equal(response.SyncStatePending, env.Meta.SyncStatus.State)
+
+	require.NotNil(env.Links)
+	assert.Equal("/v1/commits?repoName=owner/name&limit=2", env.Links.Self)
+	assert.Contains(env.Links.Next, "cursor="+env.Meta.Cursor.Next)
+}
+
+// go test -timeout 30s -run ^TestGetCommitsUntrackedRepo$ ./pkg/httpserver -v
+func TestGetCommitsUntrackedRepo(t *testing.T) {
+	assert := assert.New(t)
+	base := setup(t)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/commits?repoName=owner/new", nil)
+
+	base.svc.router.ServeHTTP(w, r)
+
+	assert.Equal(http.StatusAccepted, w.Code)
+
+	// the tracking state is reported in meta with the same body schema
+	res := []map[string]interface{}{}
+	env := decodeEnvelope(t, w, &res)
+	assert.Empty(res)
+	if assert.NotNil(env.Meta) && assert.NotNil(env.Meta.SyncStatus) {
+		assert.Equal(response.SyncStatePending, env.Meta.SyncStatus.State)
+		assert.Equal("owner/new is now being tracked. Please check back later", env.Meta.SyncStatus.Message)
+	}
+
+	_, err := base.repo.GetRepositoryByName(context.Background(), "owner/new")
+	assert.NoError(err)
+}
+
+// go test -timeout 30s -run ^TestTrackRepository$ ./pkg/httpserver -v
+func TestTrackRepository(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	track := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/repositories", strings.NewReader(`{"repository_name": "Owner/Name"}`))
+		r.Header.Set("Content-Type", "application/json")
+		base.svc.router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := track()
+	require.Equal(http.StatusCreated, w.Code)
+
+	res := map[string]interface{}{}
+	decodeEnvelope(t, w, &res)
+	assert.Equal("owner/name", res["repository_name"])
+
+	w = track()
+	assert.Equal(http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/repositories/owner/name", nil))
+	assert.Equal(http.StatusOK, w.Code)
+}
+
+// go test -timeout 30s -run ^TestTrackRepositoryAfterStop$ ./pkg/httpserver -v
+func TestTrackRepositoryAfterStop(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	memoryRepo := memory.NewRepository()
+	logger := slog.Default()
+	githubSvc := newService(memoryRepo, logger)
+	apiServer := NewServer(":9000", DefaultTimeouts(), memoryRepo, githubSvc, Options{API: true, Watcher: true}, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(githubSvc.Start(ctx))
+	cancel()
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelStop()
+	require.NoError(githubSvc.Stop(stopCtx))
+
+	// the queue is not consumed after stop, tracking more repos than it buffers must not block
+	for i := 0; i < 150; i++ {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/repositories", strings.NewReader(fmt.Sprintf(`{"repository_name": "owner/repo-%d"}`, i)))
+		apiServer.router.ServeHTTP(w, r)
+		require.Equal(http.StatusCreated, w.Code)
+	}
+
+	repos, err := memoryRepo.GetRepositories(context.Background())
+	require.NoError(err)
+	assert.Len(repos, 150)
+}
+
+// go test -timeout 30s -run ^TestGetRepositoryNotFound$ ./pkg/httpserver -v
+func TestGetRepositoryNotFound(t *testing.T) {
+	base := setup(t)
+
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/repositories/owner/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// go test -timeout 30s -run ^TestRepositoryProviders$ ./pkg/httpserver -v
+func TestRepositoryProviders(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	for name, tc := range map[string]struct {
+		body       string
+		repoName   string
+		provider   repository.Provider
+		statusCode int
+	}{
+		"github prefix":   {`{"repository_name": "github:Owner/Name"}`, "owner/name", repository.ProviderGithub, http.StatusCreated},
+		"gitlab subgroup": {`{"repository_name": "gitlab:Group/Subgroup/Project"}`, "gitlab:group/subgroup/project", repository.ProviderGitlab, http.StatusCreated},
+		"gitea":           {`{"repository_name": "gitea:owner/name"}`, "gitea:owner/name", repository.ProviderGitea, http.StatusCreated},
+		"gitea subgroup":  {`{"repository_name": "gitea:group/subgroup/name"}`, "", "", http.StatusBadRequest},
+		"bitbucket":       {`{"repository_name": "bitbucket:Workspace/Slug"}`, "bitbucket:workspace/slug", repository.ProviderBitbucket, http.StatusCreated},
+		"unknown":         {`{"repository_name": "sourcehut:owner/name"}`, "", "", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/repositories", strings.NewReader(tc.body)))
+		require.Equal(tc.statusCode, w.Code, name)
+		if tc.statusCode != http.StatusCreated {
+			continue
+		}
+
+		repo := repository.GithubRepository{}
+		decodeEnvelope(t, w, &repo)
+		assert.Equal(tc.repoName, repo.RepositoryName, name)
+		assert.Equal(tc.provider, repo.Provider, name)
+	}
+
+	// the namespace of repos in subgroups is encoded
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/repositories/gitlab:group%2Fsubgroup/project", nil))
+	require.Equal(http.StatusOK, w.Code)
+	repo := repository.GithubRepository{}
+	decodeEnvelope(t, w, &repo)
+	assert.Equal("gitlab:group/subgroup/project", repo.RepositoryName)
+
+	w = httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/repositories?provider=gitlab", nil))
+	require.Equal(http.StatusOK, w.Code)
+	var repos []repository.GithubRepository
+	env := decodeEnvelope(t, w, &repos)
+	require.Len(repos, 1)
+	assert.Equal("gitlab:group/subgroup/project", repos[0].RepositoryName)
+	assert.Equal(1, *env.Meta.Total)
+
+	w = httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/repositories?provider=sourcehut", nil))
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
+
+// go test -timeout 30s -run ^TestRepositoryFetchMode$ ./pkg/httpserver -v
+func TestRepositoryFetchMode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		base.svc.router.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w
+	}
+
+	w := serve(http.MethodPost, "/v1/repositories", `{"repository_name": "owner/graphql", "fetch_mode": "graphql"}`)
+	require.Equal(http.StatusCreated, w.Code)
+	repo := repository.GithubRepository{}
+	decodeEnvelope(t, w, &repo)
+	assert.Equal(repository.FetchModeGraphQL, repo.FetchMode)
+
+	w = serve(http.MethodPost, "/v1/repositories", `{"repository_name": "owner/rest"}`)
+	require.Equal(http.StatusCreated, w.Code)
+	repo = repository.GithubRepository{}
+	decodeEnvelope(t, w, &repo)
+	assert.Equal(repository.FetchModeREST, repo.FetchMode)
+
+	w = serve(http.MethodPatch, "/v1/repositories/owner/rest", `{"fetch_mode": "graphql"}`)
+	require.Equal(http.StatusOK, w.Code)
+	repo = repository.GithubRepository{}
+	decodeEnvelope(t, w, &repo)
+	assert.Equal(repository.FetchModeGraphQL, repo.FetchMode)
+	stored, err := base.repo.GetRepositoryByName(context.Background(), "owner/rest")
+	require.NoError(err)
+	assert.Equal(repository.FetchModeGraphQL, stored.FetchMode)
+
+	for name, tc := range map[string]struct {
+		method, target, body string
+		statusCode           int
+	}{
+		"unknown mode":      {http.MethodPost, "/v1/repositories", `{"repository_name": "owner/other", "fetch_mode": "soap"}`, http.StatusBadRequest},
+		"graphql on gitlab": {http.MethodPost, "/v1/repositories", `{"repository_name": "gitlab:group/project", "fetch_mode": "graphql"}`, http.StatusBadRequest},
+		"missing mode":      {http.MethodPatch, "/v1/repositories/owner/rest", `{}`, http.StatusBadRequest},
+		"untracked repo":    {http.MethodPatch, "/v1/repositories/owner/missing", `{"fetch_mode": "rest"}`, http.StatusNotFound},
+		"invalid body":      {http.MethodPatch, "/v1/repositories/owner/rest", `{`, http.StatusBadRequest},
+	} {
+		w := serve(tc.method, tc.target, tc.body)
+		assert.Equal(tc.statusCode, w.Code, name)
+	}
+}
+
+// go test -timeout 30s -run ^TestOwners$ ./pkg/httpserver -v
+func TestOwners(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	var listed atomic.Value
+	listed.Store(`[
+		{"name": "API-Gateway", "full_name": "MyOrg/API-Gateway"},
+		{"name": "api-web", "full_name": "MyOrg/api-web"},
+		{"name": "api-legacy", "full_name": "MyOrg/api-legacy"},
+		{"name": "api-fork", "full_name": "MyOrg/api-fork", "fork": true},
+		{"name": "docs", "full_name": "MyOrg/docs"}
+	]`)
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/orgs/myorg/repos", r.URL.Path)
+		w.Write([]byte(listed.Load().(string)))
+	}))
+	defer github.Close()
+
+	memoryRepo := memory.NewRepository()
+	logger := slog.Default()
+	config := githubrepo.DefaultConfig()
+	config.APIURL = github.URL
+	githubSvc := githubrepo.NewService(memoryRepo, logger, config)
+	apiServer := NewServer(":9000", DefaultTimeouts(), memoryRepo, githubSvc, Options{API: true, Watcher: true}, logger)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		apiServer.router.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w
+	}
+
+	w := serve(http.MethodPost, "/v1/owners", `{"owner": "Org:MyOrg", "include": ["API-*"], "exclude": ["*-legacy"], "skip_forks": true}`)
+	require.Equal(http.StatusCreated, w.Code)
+	owner := repository.OwnerSubscription{}
+	decodeEnvelope(t, w, &owner)
+	assert.Equal("org:myorg", owner.Name)
+	assert.Equal(repository.OwnerKindOrg, owner.Kind)
+	assert.Equal([]string{"api-*"}, owner.Include)
+	assert.Nil(owner.LastSyncedAt)
+
+	for name, tc := range map[string]struct {
+		method, target, body string
+		statusCode           int
+	}{
+		"duplicate":     {http.MethodPost, "/v1/owners", `{"owner": "org:myorg"}`, http.StatusConflict},
+		"missing owner": {http.MethodPost, "/v1/owners", `{}`, http.StatusBadRequest},
+		"unknown kind":  {http.MethodPost, "/v1/owners", `{"owner": "team:myorg"}`, http.StatusBadRequest},
+		"invalid glob":  {http.MethodPost, "/v1/owners", `{"owner": "user:alice", "exclude": ["[a"]}`, http.StatusBadRequest},
+		"invalid body":  {http.MethodPost, "/v1/owners", `{`, http.StatusBadRequest},
+		"get":           {http.MethodGet, "/v1/owners/ORG:MyOrg", "", http.StatusOK},
+		"not found":     {http.MethodGet, "/v1/owners/org:missing", "", http.StatusNotFound},
+		"invalid name":  {http.MethodGet, "/v1/owners/myorg/stats", "", http.StatusBadRequest},
+	} {
+		w := serve(tc.method, tc.target, tc.body)
+		assert.Equal(tc.statusCode, w.Code, name)
+	}
+
+	// repos tracked on their own are left out of the subscription
+	_, err := memoryRepo.CreateRepository(ctx, "myorg/api-tools")
+	require.NoError(err)
+	listed.Store(`[
+		{"name": "API-Gateway", "full_name": "MyOrg/API-Gateway"},
+		{"name": "api-web", "full_name": "MyOrg/api-web"},
+		{"name": "api-tools", "full_name": "MyOrg/api-tools"},
+		{"name": "api-legacy", "full_name": "MyOrg/api-legacy"},
+		{"name": "api-fork", "full_name": "MyOrg/api-fork", "fork": true},
+		{"name": "docs", "full_name": "MyOrg/docs"}
+	]`)
+	require.NoError(githubSvc.SyncOwner(ctx, "org:myorg"))
+
+	w = serve(http.MethodGet, "/v1/owners/org:myorg/repositories", "")
+	require.Equal(http.StatusOK, w.Code)
+	var repos []repository.GithubRepository
+	env := decodeEnvelope(t, w, &repos)
+	require.Len(repos, 2)
+	assert.Equal(2, *env.Meta.Total)
+	assert.Equal("myorg/api-gateway", repos[0].RepositoryName)
+	assert.Equal("myorg/api-web", repos[1].RepositoryName)
+	for _, repo := range repos {
+		require.NotNil(repo.OwnerID)
+		assert.Equal(owner.ID, *repo.OwnerID)
+		assert.Nil(repo.DeletedAt)
+	}
+
+	// repos which are no longer listed are marked as deleted unless they were tracked on their own
+	listed.Store(`[
+		{"name": "api-web", "full_name": "MyOrg/api-web"},
+		{"name": "api-tools", "full_name": "MyOrg/api-tools", "fork": true}
+	]`)
+	require.NoError(githubSvc.SyncOwner(ctx, "org:myorg"))
+	gateway, err := memoryRepo.GetRepositoryByName(ctx, "myorg/api-gateway")
+	require.NoError(err)
+	assert.NotNil(gateway.DeletedAt)
+	tools, err := memoryRepo.GetRepositoryByName(ctx, "myorg/api-tools")
+	require.NoError(err)
+	assert.Nil(tools.OwnerID)
+	assert.Nil(tools.DeletedAt)
+
+	web, err := memoryRepo.GetRepositoryByName(ctx, "myorg/api-web")
+	require.NoError(err)
+	require.NoError(memoryRepo.UpdateRepository(ctx, &repository.GithubRepository{ID: web.ID, StarsCount: 3}))
+	for i, repoID := range []string{web.ID, web.ID, gateway.ID} {
+		require.NoError(memoryRepo.SaveCommit(ctx, repository.GithubCommit{
+			RepositoryID: repoID,
+			CommitHash:   fmt.Sprintf("sha-%d", i),
+			AuthorName:   "alice",
+			Date:         time.Date(2024, 5, 1, i, 0, 0, 0, time.UTC),
+		}))
+	}
+
+	w = serve(http.MethodGet, "/v1/owners/org:myorg/stats?limit=1", "")
+	require.Equal(http.StatusOK, w.Code)
+	stats := repository.OwnerStats{}
+	decodeEnvelope(t, w, &stats)
+	assert.Equal(repository.OwnerStats{
+		Repositories:        1,
+		DeletedRepositories: 1,
+		StarsCount:          3,
+		Commits:             2,
+		Authors:             1,
+		TopAuthors:          []repository.CommitStats{{AuthorName: "alice", CommitCount: 2}},
+	}, stats)
+
+	w = serve(http.MethodGet, "/v1/owners", "")
+	require.Equal(http.StatusOK, w.Code)
+	var owners []repository.OwnerSubscription
+	decodeEnvelope(t, w, &owners)
+	require.Len(owners, 1)
+	assert.NotNil(owners[0].LastSyncedAt)
+}
+
+// go test -timeout 30s -run ^TestWebhook$ ./pkg/httpserver -v
+func TestWebhook(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// syncs queued by webhooks fail fast against an api without repositories
+	api := httptest.NewServer(http.NotFoundHandler())
+	defer api.Close()
+	memoryRepo := memory.NewRepository()
+	logger := slog.Default()
+	config := githubrepo.DefaultConfig()
+	config.APIURL = api.URL
+	config.WebhookSecret = testWebhookSecret
+	githubSvc := githubrepo.NewService(memoryRepo, logger, config)
+	apiServer := NewServer(":9000", DefaultTimeouts(), memoryRepo, githubSvc, Options{API: true, Watcher: true}, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(githubSvc.Start(ctx))
+	defer func() {
+		cancel()
+		stopCtx, cancelStop := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelStop()
+		require.NoError(githubSvc.Stop(stopCtx))
+	}()
+
+	_, err := memoryRepo.CreateRepository(ctx, "owner/name")
+	require.NoError(err)
+
+	deliver := func(provider, event, body, secret string) *httptest.ResponseRecorder {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/webhooks/"+provider, strings.NewReader(body))
+		r.Header.Set("X-GitHub-Event", event)
+		r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		w := httptest.NewRecorder()
+		apiServer.router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := deliver("github", "push", `{"repository": {"full_name": "Owner/Name"}}`, testWebhookSecret)
+	require.Equal(http.StatusAccepted, w.Code)
+	result := githubrepo.WebhookResult{}
+	decodeEnvelope(t, w, &result)
+	assert.Equal(githubrepo.WebhookResult{Event: "push", RepositoryName: "owner/name", Queued: true}, result)
+
+	// untracked repos are not tracked by webhooks
+	w = deliver("github", "push", `{"repository": {"full_name": "owner/other"}}`, testWebhookSecret)
+	require.Equal(http.StatusAccepted, w.Code)
+	decodeEnvelope(t, w, &result)
+	assert.False(result.Queued)
+	_, err = memoryRepo.GetRepositoryByName(ctx, "owner/other")
+	assert.ErrorIs(err, repository.ErrNotFound)
+
+	w = deliver("github", "ping", `{"zen": "Keep it simple."}`, testWebhookSecret)
+	require.Equal(http.StatusAccepted, w.Code)
+	result = githubrepo.WebhookResult{}
+	decodeEnvelope(t, w, &result)
+	assert.Equal(githubrepo.WebhookResult{Event: "ping"}, result)
+
+	for name, tc := range map[string]struct {
+		provider, secret string
+		status           int
+		code             response.ErrorCode
+	}{
+		"wrong secret":      {"github", "other", http.StatusUnauthorized, response.CodeInvalidSignature},
+		"webhooks disabled": {"gitlab", testWebhookSecret, http.StatusNotFound, response.CodeNotFound},
+		"unknown provider":  {"sourcehut", testWebhookSecret, http.StatusNotFound, response.CodeNotFound},
+	} {
+		w := deliver(tc.provider, "push", `{"repository": {"full_name": "owner/name"}}`, tc.secret)
+		require.Equal(tc.status, w.Code, name)
+		problem := response.Problem{}
+		require.NoError(json.Unmarshal(w.Body.Bytes(), &problem), name)
+		assert.Equal(tc.code, problem.Code, name)
+	}
+
+	// deliveries which are too large or not json are client errors, not internal ones
+	for name, tc := range map[string]struct {
+		body   string
+		status int
+		code   response.ErrorCode
+	}{
+		"invalid json": {`{"repository":`, http.StatusBadRequest, response.CodeInvalidRequest},
+		"too large":    {strings.Repeat(" ", 25<<20+1), http.StatusRequestEntityTooLarge, response.CodePayloadTooLarge},
+	} {
+		w := deliver("github", "push", tc.body, testWebhookSecret)
+		require.Equal(tc.status, w.Code, name)
+		problem := response.Problem{}
+		require.NoError(json.Unmarshal(w.Body.Bytes(), &problem), name)
+		assert.Equal(tc.code, problem.Code, name)
+	}
+}
+
+// go test -timeout 30s -run ^TestLiveness$ ./pkg/httpserver -v
+func TestLiveness(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	var res LivenessResponse
+	require.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal("alive", res.Status)
+}
+
+// go test -timeout 30s -run ^TestReadiness$ ./pkg/httpserver -v
+func TestReadiness(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	// the request context is cancelled so the github probe fails without reaching the network
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil).WithContext(ctx))
+	require.Equal(http.StatusServiceUnavailable, w.Code)
+
+	var res ReadinessResponse
+	require.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal("unavailable", res.Status)
+	require.Contains(res.Components, "watcher")
+	assert.Equal(statusDown, res.Components["watcher"].Status)
+	assert.Equal("watcher has not started", res.Components["watcher"].Error)
+	require.Contains(res.Components, "github")
+	assert.Equal(statusDown, res.Components["github"].Status)
+	assert.NotContains(res.Components, "database")
+	assert.NotContains(res.Components, "migrations")
+}
+
+// go test -timeout 30s -run ^TestWorkerServer$ ./pkg/httpserver -v
+func TestWorkerServer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	memoryRepo := memory.NewRepository()
+	logger := slog.Default()
+	workerServer := NewServer(":9000", DefaultTimeouts(), memoryRepo, newService(memoryRepo, logger), Options{}, logger)
+
+	w := httptest.NewRecorder()
+	workerServer.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/repositories", nil))
+	assert.Equal(http.StatusNotFound, w.Code, "the api is not served")
+
+	// the watcher runs in another process so readiness does not check it or github
+	w = httptest.NewRecorder()
+	workerServer.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	var res ReadinessResponse
+	require.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal("ready", res.Status)
+	assert.Empty(res.Components)
+
+	w = httptest.NewRecorder()
+	workerServer.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(http.StatusOK, w.Code)
+}
+
+// go test -timeout 30s -run ^TestSearchCommits$ ./pkg/httpserver -v
+func TestSearchCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	base.seedCommits(t, "owner/name", "user1", 2)
+	base.seedCommits(t, "owner/other", "user2", 1)
+
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits/search?q=commit&repoName=owner/name,owner/other&limit=10", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	res := []map[string]interface{}{}
+	decodeEnvelope(t, w, &res)
+	assert.Len(res, 3)
+	assert.Equal("<mark>commit</mark> message", res[0]["snippet"])
+
+	w = httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits/search?q=commit&repoName=owner/missing", nil))
+	assert.Equal(http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits/search?q=%22%22", nil))
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
+
+// go test -timeout 30s -run ^TestGetCommitsFilters$ ./pkg/httpserver -v
+func TestGetCommitsFilters(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	base.seedCommits(t, "owner/name", "user1", 3)
+	base.seedCommits(t, "owner/name", "user2", 2)
+
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits?repoName=owner/name&author=USER2&since=2024-05-01T01:00:00Z&sort=asc", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	res := []map[string]interface{}{}
+	decodeEnvelope(t, w, &res)
+	require.Len(res, 1)
+	assert.Equal("user2-1", res[0]["commit_hash"])
+
+	// an until date without a time includes the commits of that day
+	for query, count := range map[string]int{
+		"until=2024-05-01":           5,
+		"until=2024-05-01T01:00:00Z": 4,
+	} {
+		w = httptest.NewRecorder()
+		base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits?repoName=owner/name&limit=10&"+query, nil))
+		require.Equal(http.StatusOK, w.Code, query)
+		res = []map[string]interface{}{}
+		decodeEnvelope(t, w, &res)
+		assert.Len(res, count, query)
+	}
+
+	for name, query := range map[string]string{
+		"invalid since":           "since=yesterday",
+		"invalid until":           "until=2024-13-01",
+		"since after until":       "since=2024-05-02&until=2024-05-01",
+		"invalid excludeMerges":   "excludeMerges=maybe",
+		"invalid sort":            "sort=random",
+		"path outside repo":       "path=../etc",
+		"author too long":         "author=" + strings.Repeat("a", repository.MaxFilterLength+1),
+		"message too long":        "message=" + strings.Repeat("a", repository.MaxFilterLength+1),
+		"path is repository root": "path=/",
+	} {
+		w := httptest.NewRecorder()
+		base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits?repoName=owner/name&"+query, nil))
+		assert.Equal(http.StatusBadRequest, w.Code, name)
+	}
+}
+
+// go test -timeout 30s -run ^TestGetCommitsCursor$ ./pkg/httpserver -v
+func TestGetCommitsCursor(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	base.seedCommits(t, "owner/name", "user1", 5)
+
+	links := regexp.MustCompile(`<([^>]+)>; rel="(\w+)"`)
+	get := func(target string) ([]string, map[string]string) {
+		w := httptest.NewRecorder()
+		base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(http.StatusOK, w.Code)
+
+		res := []map[string]interface{}{}
+		decodeEnvelope(t, w, &res)
+		var hashes []string
+		for _, c := range res {
+			hashes = append(hashes, c["commit_hash"].(string))
+		}
+		rels := map[string]string{}
+		for _, m := range links.FindAllStringSubmatch(w.Header().Get("Link"), -1) {
+			rels[m[2]] = m[1]
+		}
+		return hashes, rels
+	}
+
+	hashes, rels := get("/v1/commits?repoName=owner/name&limit=2")
+	assert.Equal([]string{"user1-4", "user1-3"}, hashes)
+	assert.NotContains(rels, "prev")
+	require.Contains(rels, "next")
+	assert.Contains(rels["next"], "repoName=owner%2Fname")
+
+	hashes, rels = get(rels["next"])
+	assert.Equal([]string{"user1-2", "user1-1"}, hashes)
+	require.Contains(rels, "prev")
+	require.Contains(rels, "next")
+
+	hashes, rels = get(rels["next"])
+	assert.Equal([]string{"user1-0"}, hashes)
+	assert.NotContains(rels, "next")
+	require.Contains(rels, "prev")
+
+	hashes, rels = get(rels["prev"])
+	assert.Equal([]string{"user1-2", "user1-1"}, hashes)
+	require.Contains(rels, "prev")
+
+	hashes, rels = get(rels["prev"])
+	assert.Equal([]string{"user1-4", "user1-3"}, hashes)
+	assert.NotContains(rels, "prev")
+
+	// offsets link to the following pages by cursor
+	hashes, rels = get("/v1/commits?repoName=owner/name&limit=2&offset=1")
+	assert.Equal([]string{"user1-3", "user1-2"}, hashes)
+	require.Contains(rels, "prev")
+	assert.NotContains(rels["next"], "offset=")
+
+	for name, query := range map[string]string{
+		"invalid cursor":       "cursor=not-a-cursor",
+		"cursor with offset":   "offset=2&" + strings.TrimPrefix(rels["next"], "/v1/commits?"),
+		"empty cursor payload": "cursor=e30",
+	} {
+		w := httptest.NewRecorder()
+		base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits?repoName=owner/name&"+query, nil))
+		assert.Equal(http.StatusBadRequest, w.Code, name)
+	}
+}
+
+// go test -timeout 30s -run ^TestGetCommitsMaxPageSize$ ./pkg/httpserver -v
+func TestGetCommitsMaxPageSize(t *testing.T) {
+	require := require.New(t)
+	base := setup(t)
+
+	base.seedCommits(t, "owner/name", "user1", maxPageSize+5)
+
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits?repoName=owner/name&limit=1000", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	res := []map[string]interface{}{}
+	decodeEnvelope(t, w, &res)
+	require.Len(res, maxPageSize)
+}
+
+// conflictingRepository reports every repository as already tracked but never finds it
+type conflictingRepository struct {
+	repository.Repository
+}
+
+func (conflictingRepository) CreateRepository(ctx context.Context, repoName string) (string, error) {
+	return "", repository.ErrConflict
+}
+
+func (conflictingRepository) GetRepositoryByName(ctx context.Context, name string) (repository.GithubRepository, error) {
+	return repository.GithubRepository{}, repository.ErrNotFound
+}
+
+// go test -timeout 30s -run ^TestGetCommitsConflictingStore$ ./pkg/httpserver -v
+func TestGetCommitsConflictingStore(t *testing.T) {
+	repo := conflictingRepository{Repository: memory.NewRepository()}
+	logger := slog.Default()
+	apiServer := NewServer(":9000", DefaultTimeouts(), repo, newService(repo, logger), Options{API: true, Watcher: true}, logger)
+
+	// the repo is read once more after the conflict instead of looping
+	w := httptest.NewRecorder()
+	apiServer.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits?repoName=owner/name", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// go test -timeout 30s -run ^TestSearchCommitsMaxPageSize$ ./pkg/httpserver -v
+func TestSearchCommitsMaxPageSize(t *testing.T) {
+	require := require.New(t)
+	base := setup(t)
+
+	base.seedCommits(t, "owner/name", "user1", maxPageSize+5)
+
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/commits/search?q=commit&limit=100000000", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	res := []map[string]interface{}{}
+	env := decodeEnvelope(t, w, &res)
+	require.Len(res, maxPageSize)
+	require.Equal(maxPageSize, env.Meta.Limit)
+}
+
+// go test -timeout 30s -run ^TestProblemResponses$ ./pkg/httpserver -v
+func TestProblemResponses(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	base.seedCommits(t, "owner/name", "user1", 1)
+
+	for name, tc := range map[string]struct {
+		method, target, body string
+		status               int
+		code                 response.ErrorCode
+	}{
+		"invalid param":      {http.MethodGet, "/v1/commits?repoName=invalid", "", http.StatusBadRequest, response.CodeInvalidRequest},
+		"invalid body":       {http.MethodPost, "/v1/repositories", "{", http.StatusBadRequest, response.CodeInvalidRequest},
+		"untracked repo":     {http.MethodGet, "/v1/repositories/owner/missing", "", http.StatusNotFound, response.CodeNotFound},
+		"already tracked":    {http.MethodPost, "/v1/repositories", `{"repository_name": "owner/name"}`, http.StatusConflict, response.CodeConflict},
+		"unknown route":      {http.MethodGet, "/v1/unknown", "", http.StatusNotFound, response.CodeRouteNotFound},
+		"unsupported method": {http.MethodDelete, "/v1/commits", "", http.StatusMethodNotAllowed, response.CodeMethodNotAllowed},
+	} {
+		w := httptest.NewRecorder()
+		base.svc.router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+		require.Equal(tc.status, w.Code, name)
+		assert.Equal(response.ProblemContentType, w.Header().Get("Content-Type"), name)
+
+		problem := response.Problem{}
+		require.NoError(json.Unmarshal(w.Body.Bytes(), &problem), name)
+		assert.Equal(tc.status, problem.Status, name)
+		assert.Equal(tc.code, problem.Code, name)
+		assert.Equal(http.StatusText(tc.status), problem.Title, name)
+		assert.Equal("urn:github-repo-stats:problem:"+string(tc.code), problem.Type, name)
+		assert.NotEmpty(problem.Detail, name)
+		assert.NotEmpty(problem.RequestID, name)
+	}
+}
+
+// go test -timeout 30s -run ^TestRequestIDAndAccessLog$ ./pkg/httpserver -v
+func TestRequestIDAndAccessLog(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	var buf bytes.Buffer
+	base.svc.logger = slog.New(logging.NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/repositories/owner/missing", nil)
+	r.Header.Set(requestIDHeader, "client-id-1")
+	base.svc.router.ServeHTTP(w, r)
+	require.Equal(http.StatusNotFound, w.Code)
+	assert.Equal("client-id-1", w.Header().Get(requestIDHeader))
+
+	problem := response.Problem{}
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal("client-id-1", problem.RequestID)
+
+	record := map[string]interface{}{}
+	require.NoError(json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal("http-request", record["msg"])
+	assert.Equal("client-id-1", record[logging.RequestIDKey])
+	assert.Equal("/v1/repositories/{owner}/{name}", record["route"])
+	assert.Equal(float64(http.StatusNotFound), record["status"])
+	assert.Equal(float64(w.Body.Len()), record["bytes"])
+	assert.Contains(record, "latency_ms")
+
+	// ids that could break log lines are replaced
+	for _, id := range []string{"", "has space", strings.Repeat("a", maxRequestIDLength+1)} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v1/repositories", nil)
+		r.Header.Set(requestIDHeader, id)
+		base.svc.router.ServeHTTP(w, r)
+		assert.NotEqual(id, w.Header().Get(requestIDHeader))
+		assert.NotEmpty(w.Header().Get(requestIDHeader))
+	}
+}
+
+// go test -timeout 30s -run ^TestServiceLogsCarryRequest$ ./pkg/httpserver -v
+func TestServiceLogsCarryRequest(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(logging.NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+	memoryRepo := memory.NewRepository()
+	apiServer := NewServer(":9000", DefaultTimeouts(), memoryRepo, newService(memoryRepo, logger), Options{API: true, Watcher: true}, logger)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/commits?repoName=owner/new", nil)
+	r.Header.Set(requestIDHeader, "client-id-2")
+	apiServer.router.ServeHTTP(w, r)
+	require.Equal(http.StatusAccepted, w.Code)
+
+	// the service is not started like in api only deployments, which leave new repos to a worker
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		record := map[string]interface{}{}
+		require.NoError(decoder.Decode(&record))
+		if record["msg"] == "repo-left-for-worker" {
+			assert.Equal(t, "client-id-2", record[logging.RequestIDKey])
+			assert.Equal(t, "owner/new", record[logging.RepositoryKey])
+			return
+		}
+	}
+	t.Fatal("repo-left-for-worker was not logged")
+}
+
+// go test -timeout 30s -run ^TestMetrics$ ./pkg/httpserver -v
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/repositories/owner/missing", nil))
+	require.Equal(http.StatusNotFound, w.Code)
+
+	// unknown paths are labeled by the pattern of the router that rejected them
+	for _, target := range []string{"/v1/unknown/path", "/unknown/path"} {
+		w = httptest.NewRecorder()
+		base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(http.StatusNotFound, w.Code)
+	}
+
+	// expvar is not served, it would publish the command line and the pool stats are part of the metrics
+	w = httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	require.Equal(http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(body, `github_repo_stats_http_requests_total{method="GET",route="/v1/repositories/{owner}/{name}",status="404"}`)
+	assert.Contains(body, `github_repo_stats_http_requests_total{method="GET",route="/v1/*",status="404"}`)
+	assert.Contains(body, `github_repo_stats_http_requests_total{method="GET",route="unmatched",status="404"}`)
+	assert.Contains(body, "github_repo_stats_http_request_duration_seconds_bucket")
+	assert.Contains(body, "github_repo_stats_sync_queue_depth")
+	assert.NotContains(body, "unknown/path")
+}
+
+// go test -timeout 30s -run ^TestTracing$ ./pkg/httpserver -v
+func TestTracing(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+	base := setup(t)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/repositories/owner/missing", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set(requestIDHeader, "client-id-3")
+	base.svc.router.ServeHTTP(w, r)
+	require.Equal(http.StatusNotFound, w.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(spans, 1)
+	span := spans[0]
+	assert.Equal("GET /v1/repositories/{owner}/{name}", span.Name)
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal("00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Contains(span.Attributes, attribute.String("http.route", "/v1/repositories/{owner}/{name}"))
+	assert.Contains(span.Attributes, attribute.String(logging.RequestIDKey, "client-id-3"))
+}
+
+// go test -timeout 30s -run ^TestOpenAPI$ ./pkg/httpserver -v
+func TestOpenAPI(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+
+	w := httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(http.StatusOK, w.Code)
+	assert.Equal("application/json", w.Header().Get("Content-Type"))
+
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &spec))
+	assert.Equal("3.0.3", spec.OpenAPI)
+
+	// routes registered with Handle match every method, the spec only documents GET for them
+	methods := map[string][]string{}
+	err := chi.Walk(base.svc.router, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		methods[route] = append(methods[route], method)
+		return nil
+	})
+	require.NoError(err)
+	require.NotEmpty(methods)
+
+	for route, routeMethods := range methods {
+		operations, ok := spec.Paths[route]
+		if !assert.True(ok, "route %s is missing from the spec", route) {
+			continue
+		}
+		if len(routeMethods) > 2 {
+			assert.Contains(operations, "get", "route %s", route)
+			continue
+		}
+		for _, method := range routeMethods {
+			assert.Contains(operations, strings.ToLower(method), "%s %s is missing from the spec", method, route)
+		}
+	}
+	for path := range spec.Paths {
+		assert.Contains(methods, path, "path %s of the spec is not registered", path)
+	}
+
+	w = httptest.NewRecorder()
+	base.svc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), `url: "/openapi.json"`)
+}
+
+// go test -timeout 30s -run ^TestExportCommits$ ./pkg/httpserver -v
+func TestExportCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	base := setup(t)
+	base.seedCommits(t, "owner/repo", "alice", 3)
+	base.seedCommits(t, "owner/repo", "bob", 2)
+
+	exportCommits := func(target, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		base.svc.router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := exportCommits("/v1/commits/export?repoName=owner/repo&author=bob", "")
+	require.Equal(http.StatusOK, w.Code)
+	assert.Equal("application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(`attachment; filename="owner_repo.ndjson"`, w.Header().Get("Content-Disposition"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(lines, 2)
+	var commit repository.GithubCommit
+	require.NoError(json.Unmarshal([]byte(lines[0]), &commit))
+	assert.Equal("bob-1", commit.CommitHash)
+
+	w = exportCommits("/v1/commits/export?repoName=owner/repo&sort=asc", "application/json;q=0.9, text/csv")
+	require.Equal(http.StatusOK, w.Code)
+	assert.Equal("text/csv", w.Header().Get("Content-Type"))
+	lines = strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(lines, 6)
+	assert.True(strings.HasPrefix(lines[0], "commit_hash,date,"))
+	assert.True(strings.HasPrefix(lines[1], "bob-0,2024-05-01T00:00:00Z,bob,"), lines[1])
+
+	w = exportCommits("/v1/commits/export?repoName=owner/repo&format=parquet", "text/csv")
+	require.Equal(http.StatusOK, w.Code)
+	assert.Equal("application/vnd.apache.parquet", w.Header().Get("Content-Type"))
+	assert.True(bytes.HasPrefix(w.Body.Bytes(), []byte("PAR1")))
+
+	// exporting does not track repositories
+	w = exportCommits("/v1/commits/export?repoName=owner/missing", "")
+	assert.Equal(http.StatusNotFound, w.Code)
+	assert.Equal(response.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Empty(w.Header().Get("Content-Disposition"))
+	_, err := base.repo.GetRepositoryByName(context.Background(), "owner/missing")
+	assert.ErrorIs(err, repository.ErrNotFound)
+
+	w = exportCommits("/v1/commits/export?repoName=owner/repo", "application/xml")
+	assert.Equal(http.StatusNotAcceptable, w.Code)
+	assert.Contains(w.Body.String(), `"code": "not_acceptable"`)
+
+	w = exportCommits("/v1/commits/export?repoName=owner/repo&format=xml", "")
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
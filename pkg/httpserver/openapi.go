@@ -0,0 +1,52 @@
+package httpserver
+
+import (
+	_ "embed"
+	"log/slog"
+	"net/http"
+)
+
+// openAPISpec is the OpenAPI 3 document of every route in RegisterRoutes
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// swaggerUIPage renders /openapi.json with swagger ui loaded from a cdn
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>github-repo-stats api</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// OpenAPI serves the OpenAPI document of the api
+func (s *Server) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(openAPISpec); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-writing-response",
+			slog.String("path", "openAPI"),
+		)
+	}
+}
+
+// Docs serves a swagger ui page of the OpenAPI document
+func (s *Server) Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write([]byte(swaggerUIPage)); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-writing-response",
+			slog.String("path", "docs"),
+		)
+	}
+}
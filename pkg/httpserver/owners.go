@@ -0,0 +1,195 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/response"
+	"github.com/go-chi/chi"
+)
+
+// SubscribeOwnerRequest represents the request body for tracking the repositories of a github organization or user
+type SubscribeOwnerRequest struct {
+	// Owner is of format org:{login} or user:{login}
+	Owner string `json:"owner"`
+	// Include and Exclude are globs of the repository names without their owner, every repository is included when Include is empty
+	Include      []string `json:"include,omitempty"`
+	Exclude      []string `json:"exclude,omitempty"`
+	SkipForks    bool     `json:"skip_forks,omitempty"`
+	SkipArchived bool     `json:"skip_archived,omitempty"`
+}
+
+// NormalizeOwnerName returns the name an owner given by a user is subscribed by, github logins are case insensitive
+func NormalizeOwnerName(ownerName string) (string, error) {
+	name, err := repository.ParseOwnerName(strings.ToLower(strings.TrimSpace(ownerName)))
+	if err != nil {
+		return "", err
+	}
+
+	return name.String(), nil
+}
+
+// normalizeGlobs lowercases patterns like the repository names they match and validates them
+func normalizeGlobs(patterns []string) ([]string, error) {
+	globs := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		globs[i] = strings.ToLower(strings.TrimSpace(pattern))
+	}
+
+	return globs, repository.ValidateGlobs(globs)
+}
+
+// ownerNameParam returns the normalized name of the owner in the path of r
+func ownerNameParam(r *http.Request) (string, error) {
+	ownerName, err := NormalizeOwnerName(chi.URLParam(r, ownerURLParam))
+	if err != nil {
+		return "", response.InvalidRequest(err.Error())
+	}
+
+	return ownerName, nil
+}
+
+// SubscribeOwner is the http handler for SubscribeOwner in github svc
+func (s *Server) SubscribeOwner(w http.ResponseWriter, r *http.Request) error {
+	var req SubscribeOwnerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return response.InvalidRequest("invalid request body")
+	}
+
+	if strings.TrimSpace(req.Owner) == "" {
+		return response.InvalidRequest("owner is missing")
+	}
+	ownerName, err := NormalizeOwnerName(req.Owner)
+	if err != nil {
+		return response.InvalidRequest(err.Error())
+	}
+	name, _ := repository.ParseOwnerName(ownerName)
+
+	owner := repository.OwnerSubscription{
+		Name:         ownerName,
+		Kind:         name.Kind,
+		SkipForks:    req.SkipForks,
+		SkipArchived: req.SkipArchived,
+	}
+	if owner.Include, err = normalizeGlobs(req.Include); err != nil {
+		return response.InvalidRequest("include: " + err.Error())
+	}
+	if owner.Exclude, err = normalizeGlobs(req.Exclude); err != nil {
+		return response.InvalidRequest("exclude: " + err.Error())
+	}
+
+	owner, err = s.githubSvc.SubscribeOwner(r.Context(), owner)
+	if err != nil {
+		return err
+	}
+
+	if err := response.Data(w, http.StatusCreated, owner, nil, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "subscribeOwner"),
+		)
+	}
+
+	return nil
+}
+
+// GetOwners is the http handler for GetOwners in github svc
+func (s *Server) GetOwners(w http.ResponseWriter, r *http.Request) error {
+	owners, err := s.githubSvc.GetOwners(r.Context())
+	if err != nil {
+		return err
+	}
+	if owners == nil {
+		owners = []*repository.OwnerSubscription{}
+	}
+
+	total := len(owners)
+	if err := response.Data(w, http.StatusOK, owners, &response.Meta{Total: &total}, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "getOwners"),
+		)
+	}
+
+	return nil
+}
+
+// GetOwner is the http handler for GetOwner in github svc
+func (s *Server) GetOwner(w http.ResponseWriter, r *http.Request) error {
+	ownerName, err := ownerNameParam(r)
+	if err != nil {
+		return err
+	}
+
+	owner, err := s.githubSvc.GetOwner(r.Context(), ownerName)
+	if err != nil {
+		return err
+	}
+
+	if err := response.Data(w, http.StatusOK, owner, nil, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "getOwner"),
+		)
+	}
+
+	return nil
+}
+
+// GetOwnerRepositories is the http handler for GetOwnerRepositories in github svc
+func (s *Server) GetOwnerRepositories(w http.ResponseWriter, r *http.Request) error {
+	ownerName, err := ownerNameParam(r)
+	if err != nil {
+		return err
+	}
+
+	repos, err := s.githubSvc.GetOwnerRepositories(r.Context(), ownerName)
+	if err != nil {
+		return err
+	}
+	if repos == nil {
+		repos = []*repository.GithubRepository{}
+	}
+
+	total := len(repos)
+	if err := response.Data(w, http.StatusOK, repos, &response.Meta{Total: &total}, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "getOwnerRepositories"),
+		)
+	}
+
+	return nil
+}
+
+// GetOwnerStats is the http handler for GetOwnerStats in github svc, limit is the number of top authors
+func (s *Server) GetOwnerStats(w http.ResponseWriter, r *http.Request) error {
+	ownerName, err := ownerNameParam(r)
+	if err != nil {
+		return err
+	}
+
+	count, err := strconv.Atoi(r.URL.Query().Get(limitQueryParam))
+	if err != nil || count <= 0 {
+		count = 5
+	}
+	if count > maxPageSize {
+		count = maxPageSize
+	}
+
+	stats, err := s.githubSvc.GetOwnerStats(r.Context(), ownerName, count)
+	if err != nil {
+		return err
+	}
+	if stats.TopAuthors == nil {
+		stats.TopAuthors = []repository.CommitStats{}
+	}
+
+	if err := response.Data(w, http.StatusOK, stats, &response.Meta{Limit: count}, nil); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed-encoding-json",
+			slog.String("path", "getOwnerStats"),
+		)
+	}
+
+	return nil
+}
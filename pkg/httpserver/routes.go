@@ -1,13 +1,49 @@
 package httpserver
 
-import "github.com/go-chi/chi"
+import (
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/go-chi/chi"
+)
 
 // RegisterRoutes setups routes for http server
 func (s *Server) RegisterRoutes() {
+	if s.options.API {
+		s.registerAPIRoutes()
+	}
+
+	s.router.Get("/healthz", s.Liveness)
+	s.router.Get("/readyz", s.Readiness)
+	s.router.Handle("/metrics", metrics.Handler())
+
+	s.router.NotFound(s.handle("notFound", s.NotFoundHandler))
+	s.router.MethodNotAllowed(s.handle("methodNotAllowed", s.MethodNotAllowedHandler))
+}
+
+func (s *Server) registerAPIRoutes() {
+	s.router.Get("/openapi.json", s.OpenAPI)
+	s.router.Get("/docs", s.Docs)
+
 	s.router.Route("/v1", func(r chi.Router) {
-		r.Get("/commits", s.GetCommits)
-		r.Get("/leaderboard", s.GetLeaderBoard)
-	})
+		r.Get("/commits", s.handle("getCommits", s.GetCommits))
+		r.Get("/commits/search", s.handle("searchCommits", s.SearchCommits))
+		r.Get("/commits/export", s.handle("exportCommits", s.ExportCommits))
+		r.Get("/leaderboard", s.handle("getLeaderBoard", s.GetLeaderBoard))
+
+		r.Route("/repositories", func(r chi.Router) {
+			r.Get("/", s.handle("getRepositories", s.GetRepositories))
+			r.Post("/", s.handle("trackRepository", s.TrackRepository))
+			r.Get("/{owner}/{name}", s.handle("getRepository", s.GetRepository))
+			r.Patch("/{owner}/{name}", s.handle("updateRepository", s.UpdateRepository))
+		})
 
-	s.router.NotFound(s.NotFoundHandler)
+		r.Route("/owners", func(r chi.Router) {
+			r.Get("/", s.handle("getOwners", s.GetOwners))
+			r.Post("/", s.handle("subscribeOwner", s.SubscribeOwner))
+			r.Get("/{owner}", s.handle("getOwner", s.GetOwner))
+			r.Get("/{owner}/repositories", s.handle("getOwnerRepositories", s.GetOwnerRepositories))
+			r.Get("/{owner}/stats", s.handle("getOwnerStats", s.GetOwnerStats))
+		})
+
+		r.Post("/webhooks/{provider}", s.handle("webhook", s.Webhook))
+	})
 }
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// go test -timeout 30s -run ^TestContextHandler$ ./pkg/logging -v
+func TestContextHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))).With(slog.String("component", "test"))
+
+	ctx := With(context.Background(), slog.String(RequestIDKey, "req-1"))
+	ctx = With(ctx, slog.String(RepositoryKey, "owner/name"))
+	logger.InfoContext(ctx, "tracked")
+
+	record := map[string]interface{}{}
+	require.NoError(json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal("tracked", record["msg"])
+	assert.Equal("test", record["component"])
+	assert.Equal("req-1", record[RequestIDKey])
+	assert.Equal("owner/name", record[RepositoryKey])
+
+	buf.Reset()
+	logger.Info("no-context")
+	record = map[string]interface{}{}
+	require.NoError(json.Unmarshal(buf.Bytes(), &record))
+	assert.NotContains(record, RequestIDKey)
+}
+
+// go test -timeout 30s -run ^TestContextHandlerTraceIDs$ ./pkg/logging -v
+func TestContextHandlerTraceIDs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	logger.InfoContext(With(ctx, slog.String(RequestIDKey, "req-1")), "traced")
+
+	record := map[string]interface{}{}
+	require.NoError(json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", record[TraceIDKey])
+	assert.Equal("00f067aa0ba902b7", record[SpanIDKey])
+	assert.Equal("req-1", record[RequestIDKey])
+}
@@ -0,0 +1,162 @@
+// Package metrics holds the prometheus collectors of the application
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "github_repo_stats"
+
+// Registry is the registry of all collectors, it is used instead of the global registry
+// so the exposed metrics do not depend on what dependencies register
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts served http requests by method, route pattern and status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of http requests served by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes the latency of served http requests by method and route pattern
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of http requests by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// GithubRequests counts github api calls by endpoint and status code, status is "error" when no response was received
+	GithubRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "github_requests_total",
+		Help:      "Number of github api calls by endpoint and status code.",
+	}, []string{"endpoint", "status"})
+
+	// GithubRateLimitRemaining is the remaining github api quota reported by the last response
+	GithubRateLimitRemaining = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "github_rate_limit_remaining",
+		Help:      "Remaining github api requests in the current rate limit window.",
+	})
+
+	// GithubGraphQLRateLimitRemaining is the remaining github graphql api points reported by the last graphql response
+	GithubGraphQLRateLimitRemaining = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "github_graphql_rate_limit_remaining",
+		Help:      "Remaining github graphql api points in the current rate limit window.",
+	})
+
+	// ProviderRequests counts the api calls of the providers other than github by provider, endpoint and status code,
+	// status is "error" when no response was received
+	ProviderRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_requests_total",
+		Help:      "Number of api calls of the providers other than github by provider, endpoint and status code.",
+	}, []string{"provider", "endpoint", "status"})
+
+	// CommitsIngested counts commits saved by repository
+	CommitsIngested = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "commits_ingested_total",
+		Help:      "Number of commits pulled from providers and saved by repository.",
+	}, []string{"repository"})
+
+	// SyncDuration observes how long syncing a repository takes by result
+	SyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_duration_seconds",
+		Help:      "Duration of repository syncs by result.",
+		Buckets:   []float64{0.5, 1, 5, 15, 30, 60, 300, 900, 1800, 3600},
+	}, []string{"result"})
+
+	// LastSyncTimestamp is the unix time of the last successful sync by repository
+	LastSyncTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_sync_timestamp_seconds",
+		Help:      "Unix time of the last successful sync by repository.",
+	}, []string{"repository"})
+
+	// QueueDepth is the number of repositories waiting for their first sync
+	QueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sync_queue_depth",
+		Help:      "Number of newly tracked repositories waiting for their first sync.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		GithubRequests,
+		GithubRateLimitRemaining,
+		GithubGraphQLRateLimitRemaining,
+		ProviderRequests,
+		CommitsIngested,
+		SyncDuration,
+		LastSyncTimestamp,
+		QueueDepth,
+	)
+}
+
+// Handler returns the http handler exposing the collectors in Registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDB exposes the connection pool stats of db labeled with name
+func RegisterDB(name string, db *sql.DB) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveGithubResponse records a github api call to endpoint, resp is nil when the call failed.
+// the remaining quota is recorded by the rate limit resource of the response, graphql points are limited apart from rest requests
+func ObserveGithubResponse(endpoint string, resp *http.Response) {
+	if resp == nil {
+		GithubRequests.WithLabelValues(endpoint, "error").Inc()
+		return
+	}
+
+	GithubRequests.WithLabelValues(endpoint, strconv.Itoa(resp.StatusCode)).Inc()
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	if resp.Header.Get("X-RateLimit-Resource") == "graphql" {
+		GithubGraphQLRateLimitRemaining.Set(float64(remaining))
+		return
+	}
+	GithubRateLimitRemaining.Set(float64(remaining))
+}
+
+// ObserveProviderResponse records an api call to endpoint of a provider other than github, resp is nil when the call failed
+func ObserveProviderResponse(provider, endpoint string, resp *http.Response) {
+	if resp == nil {
+		ProviderRequests.WithLabelValues(provider, endpoint, "error").Inc()
+		return
+	}
+
+	ProviderRequests.WithLabelValues(provider, endpoint, strconv.Itoa(resp.StatusCode)).Inc()
+}
+
+// ObserveSync records a sync of repoName that started at start, err is the result of the sync
+func ObserveSync(repoName string, start time.Time, err error) {
+	if err != nil {
+		SyncDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
+		return
+	}
+
+	SyncDuration.WithLabelValues("success").Observe(time.Since(start).Seconds())
+	LastSyncTimestamp.WithLabelValues(repoName).SetToCurrentTime()
+}
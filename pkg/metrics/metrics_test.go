@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -timeout 30s -run ^TestObserveGithubResponse$ ./pkg/metrics -v
+func TestObserveGithubResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "4999")
+	ObserveGithubResponse("commits", resp)
+	ObserveGithubResponse("commits", nil)
+
+	assert.Equal(float64(1), testutil.ToFloat64(GithubRequests.WithLabelValues("commits", "200")))
+	assert.Equal(float64(1), testutil.ToFloat64(GithubRequests.WithLabelValues("commits", "error")))
+	assert.Equal(float64(4999), testutil.ToFloat64(GithubRateLimitRemaining))
+
+	// responses without the header keep the last known quota
+	ObserveGithubResponse("repos", &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}})
+	assert.Equal(float64(4999), testutil.ToFloat64(GithubRateLimitRemaining))
+
+	// graphql points are recorded apart from the rest quota
+	graphql := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	graphql.Header.Set("X-RateLimit-Remaining", "4800")
+	graphql.Header.Set("X-RateLimit-Resource", "graphql")
+	ObserveGithubResponse("graphql", graphql)
+	assert.Equal(float64(4800), testutil.ToFloat64(GithubGraphQLRateLimitRemaining))
+	assert.Equal(float64(4999), testutil.ToFloat64(GithubRateLimitRemaining))
+}
+
+// go test -timeout 30s -run ^TestObserveProviderResponse$ ./pkg/metrics -v
+func TestObserveProviderResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	ObserveProviderResponse("gitlab", "commits", &http.Response{StatusCode: http.StatusTooManyRequests})
+	ObserveProviderResponse("gitea", "commits", nil)
+
+	assert.Equal(float64(1), testutil.ToFloat64(ProviderRequests.WithLabelValues("gitlab", "commits", "429")))
+	assert.Equal(float64(1), testutil.ToFloat64(ProviderRequests.WithLabelValues("gitea", "commits", "error")))
+}
+
+// go test -timeout 30s -run ^TestObserveSync$ ./pkg/metrics -v
+func TestObserveSync(t *testing.T) {
+	assert := assert.New(t)
+
+	ObserveSync("owner/failed", time.Now(), errors.New("failed"))
+	assert.Equal(0, testutil.CollectAndCount(LastSyncTimestamp))
+
+	ObserveSync("owner/name", time.Now(), nil)
+	assert.Equal(1, testutil.CollectAndCount(LastSyncTimestamp))
+	assert.InDelta(float64(time.Now().Unix()), testutil.ToFloat64(LastSyncTimestamp.WithLabelValues("owner/name")), 5)
+	assert.Equal(2, testutil.CollectAndCount(SyncDuration))
+}
@@ -0,0 +1,258 @@
+// Package bitbucket implements the bitbucket provider with the bitbucket cloud rest api,
+// repositories are addressed by their workspace and slug
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// commitsPerPage is the largest page size of the commits api
+const commitsPerPage = 100
+
+// Config represents the settings of the bitbucket provider
+type Config struct {
+	// APIURL is the base url of the bitbucket rest api, e.g. https://api.bitbucket.org/2.0
+	APIURL string
+	// Token is a workspace, project or repository access token, requests are anonymous when it is empty
+	Token string
+}
+
+// repositoryResponse represents the repository http response from bitbucket api we are interested in
+type repositoryResponse struct {
+	Description string `json:"description"`
+	Language    string `json:"language"`
+	Links       struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+// pageResponse represents the paging fields of a paginated http response from bitbucket api
+type pageResponse struct {
+	// Size is the total number of values, it is not returned by every endpoint
+	Size int `json:"size"`
+	// Next is the url of the next page, it is empty on the last page
+	Next string `json:"next"`
+}
+
+// commitResponse represents a commit http response from bitbucket api
+type commitResponse struct {
+	Hash    string    `json:"hash"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+	Author  struct {
+		// Raw is the author of format Name <email>
+		Raw string `json:"raw"`
+		// User is missing when the commit email is not linked to a bitbucket account
+		User *struct {
+			Nickname string `json:"nickname"`
+		} `json:"user"`
+	} `json:"author"`
+	Parents []struct {
+		Hash string `json:"hash"`
+	} `json:"parents"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+// commitsResponse represents a page of the commits http response from bitbucket api
+type commitsResponse struct {
+	pageResponse
+	Values []*commitResponse `json:"values"`
+}
+
+// webhookPayload represents the fields of webhook payloads we are interested in
+type webhookPayload struct {
+	Repository *struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// Provider represents the bitbucket provider
+type Provider struct {
+	httpClient *http.Client
+	config     Config
+}
+
+// NewProvider initiates a new bitbucket provider sending api requests with httpClient
+func NewProvider(httpClient *http.Client, config Config) *Provider {
+	return &Provider{
+		httpClient: httpClient,
+		config:     config,
+	}
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() repository.Provider {
+	return repository.ProviderBitbucket
+}
+
+// get decodes the json response of the api at path into v, endpoint labels the request metrics
+func (p *Provider) get(ctx context.Context, endpoint, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.APIURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("error fetching from bitbucket: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.Token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	metrics.ObserveProviderResponse(string(repository.ProviderBitbucket), endpoint, resp)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return provider.ErrRateLimitReached
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: %s", endpoint, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// count returns the total number of values listed by the paginated api at path
+func (p *Provider) count(ctx context.Context, endpoint, path string) (int, error) {
+	var page pageResponse
+	if err := p.get(ctx, endpoint, path+"?pagelen=1", &page); err != nil {
+		return 0, err
+	}
+
+	return page.Size, nil
+}
+
+// FetchRepository implements provider.Provider. bitbucket has no stars and its issue tracker is optional,
+// so only the forks and watchers are counted
+func (p *Provider) FetchRepository(ctx context.Context, path string) (provider.Repository, error) {
+	var repo repositoryResponse
+	if err := p.get(ctx, "repositories", "/repositories/"+path, &repo); err != nil {
+		return provider.Repository{}, err
+	}
+
+	forks, err := p.count(ctx, "forks", "/repositories/"+path+"/forks")
+	if err != nil {
+		return provider.Repository{}, err
+	}
+	watchers, err := p.count(ctx, "watchers", "/repositories/"+path+"/watchers")
+	if err != nil {
+		return provider.Repository{}, err
+	}
+
+	return provider.Repository{
+		Description:   repo.Description,
+		URL:           repo.Links.HTML.Href,
+		Language:      repo.Language,
+		ForksCount:    forks,
+		WatchersCount: watchers,
+	}, nil
+}
+
+// ListCommits implements provider.Provider. the commits api can not filter by date, pages are listed newest first
+// until a commit older than since is found. the cursor is the opaque page token of the next page url
+func (p *Provider) ListCommits(ctx context.Context, path string, since *time.Time, cursor string) (provider.CommitPage, error) {
+	query := url.Values{}
+	query.Set("pagelen", fmt.Sprint(commitsPerPage))
+	if cursor != "" {
+		query.Set("page", cursor)
+	}
+
+	var commits commitsResponse
+	if err := p.get(ctx, "commits", "/repositories/"+path+"/commits?"+query.Encode(), &commits); err != nil {
+		return provider.CommitPage{}, err
+	}
+
+	var result provider.CommitPage
+	for _, commit := range commits.Values {
+		if since != nil && commit.Date.Before(*since) {
+			return result, nil
+		}
+		result.Commits = append(result.Commits, commit.toCommit())
+	}
+
+	if commits.Next != "" {
+		next, err := url.Parse(commits.Next)
+		if err != nil {
+			return result, fmt.Errorf("invalid next page url %q: %w", commits.Next, err)
+		}
+		result.Next = next.Query().Get("page")
+	}
+
+	return result, nil
+}
+
+// toCommit converts a commit response to a commit, bitbucket lists commits without their committer and line counts
+func (c *commitResponse) toCommit() repository.GithubCommit {
+	name, email := parseAuthor(c.Author.Raw)
+	commit := repository.GithubCommit{
+		CommitHash:  c.Hash,
+		Message:     c.Message,
+		AuthorName:  name,
+		AuthorEmail: email,
+		Date:        c.Date,
+		URL:         c.Links.HTML.Href,
+		Parents:     make([]string, 0, len(c.Parents)),
+	}
+	if c.Author.User != nil {
+		commit.AuthorLogin = c.Author.User.Nickname
+	}
+	for _, parent := range c.Parents {
+		commit.Parents = append(commit.Parents, parent.Hash)
+	}
+
+	return commit
+}
+
+// parseAuthor splits a raw author of format Name <email>, raw is the name when it has no email
+func parseAuthor(raw string) (name, email string) {
+	name, rest, ok := strings.Cut(raw, "<")
+	if !ok {
+		return strings.TrimSpace(raw), ""
+	}
+
+	email, _, _ = strings.Cut(rest, ">")
+	return strings.TrimSpace(name), strings.TrimSpace(email)
+}
+
+// ParseWebhook implements provider.Provider, deliveries are signed in the X-Hub-Signature header
+func (p *Provider) ParseWebhook(r *http.Request, secret string) (provider.WebhookEvent, error) {
+	body, err := provider.ReadWebhook(r)
+	if err != nil {
+		return provider.WebhookEvent{}, err
+	}
+	signature, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature"), "sha256=")
+	if !ok || !provider.ValidSignature(secret, body, signature) {
+		return provider.WebhookEvent{}, provider.ErrInvalidSignature
+	}
+
+	event := provider.WebhookEvent{Event: r.Header.Get("X-Event-Key")}
+	event.Push = event.Event == "repo:push"
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return event, fmt.Errorf("%w: %w", provider.ErrInvalidWebhook, err)
+	}
+	if payload.Repository != nil {
+		event.Path = strings.ToLower(payload.Repository.FullName)
+	}
+
+	return event, nil
+}
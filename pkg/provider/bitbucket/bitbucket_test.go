@@ -0,0 +1,146 @@
+package bitbucket
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProvider returns a provider for a fake bitbucket api served by handler
+func newTestProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewProvider(server.Client(), Config{APIURL: server.URL + "/2.0", Token: "bitbucket-token"})
+}
+
+// go test -timeout 30s -run ^TestFetchRepository$ ./pkg/provider/bitbucket -v
+func TestFetchRepository(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("Bearer bitbucket-token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/2.0/repositories/workspace/slug":
+			w.Write([]byte(`{"description": "a repo", "language": "go", "links": {"html": {"href": "https://bitbucket.org/workspace/slug"}}}`))
+		case "/2.0/repositories/workspace/slug/forks":
+			assert.Equal("1", r.URL.Query().Get("pagelen"))
+			w.Write([]byte(`{"size": 2, "values": [{}]}`))
+		case "/2.0/repositories/workspace/slug/watchers":
+			w.Write([]byte(`{"size": 3, "values": [{}]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+
+	repo, err := p.FetchRepository(context.Background(), "workspace/slug")
+	require.NoError(err)
+	assert.Equal(provider.Repository{
+		Description:   "a repo",
+		URL:           "https://bitbucket.org/workspace/slug",
+		Language:      "go",
+		ForksCount:    2,
+		WatchersCount: 3,
+	}, repo)
+}
+
+// go test -timeout 30s -run ^TestListCommits$ ./pkg/provider/bitbucket -v
+func TestListCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/2.0/repositories/workspace/slug/commits", r.URL.Path)
+		assert.Equal("100", r.URL.Query().Get("pagelen"))
+		if r.URL.Query().Get("page") == "" {
+			w.Write([]byte(`{
+				"values": [
+					{
+						"hash": "abc", "date": "2024-05-02T10:00:00+00:00", "message": "Add feature",
+						"author": {"raw": "Alice <alice@example.com>", "user": {"nickname": "alice"}},
+						"parents": [{"hash": "def"}],
+						"links": {"html": {"href": "https://bitbucket.org/workspace/slug/commits/abc"}}
+					}
+				],
+				"next": "https://api.bitbucket.org/2.0/repositories/workspace/slug/commits?pagelen=100&page=token2"
+			}`))
+			return
+		}
+
+		assert.Equal("token2", r.URL.Query().Get("page"))
+		w.Write([]byte(`{
+			"values": [
+				{
+					"hash": "def", "date": "2024-05-01T10:00:00+00:00", "message": "Merge",
+					"author": {"raw": "carol"},
+					"parents": [{"hash": "1"}, {"hash": "2"}]
+				},
+				{"hash": "old", "date": "2023-01-01T10:00:00+00:00", "message": "Old", "author": {"raw": "Dave <dave@example.com>"}}
+			],
+			"next": "https://api.bitbucket.org/2.0/repositories/workspace/slug/commits?pagelen=100&page=token3"
+		}`))
+	})
+
+	page, err := p.ListCommits(context.Background(), "workspace/slug", nil, "")
+	require.NoError(err)
+	assert.Equal("token2", page.Next)
+	require.Len(page.Commits, 1)
+
+	commit := page.Commits[0]
+	assert.Equal("abc", commit.CommitHash)
+	assert.Equal("Alice", commit.AuthorName)
+	assert.Equal("alice@example.com", commit.AuthorEmail)
+	assert.Equal("alice", commit.AuthorLogin)
+	assert.True(time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC).Equal(commit.Date))
+	assert.Equal([]string{"def"}, commit.Parents)
+	assert.Equal("https://bitbucket.org/workspace/slug/commits/abc", commit.URL)
+	assert.Nil(commit.Stats)
+
+	// the listing stops at the first commit older than since
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	page, err = p.ListCommits(context.Background(), "workspace/slug", &since, page.Next)
+	require.NoError(err)
+	assert.Empty(page.Next)
+	require.Len(page.Commits, 1)
+
+	merge := page.Commits[0]
+	assert.Equal("carol", merge.AuthorName)
+	assert.Empty(merge.AuthorEmail)
+	assert.Empty(merge.AuthorLogin)
+	assert.True(merge.IsMerge())
+}
+
+// go test -timeout 30s -run ^TestParseWebhook$ ./pkg/provider/bitbucket -v
+func TestParseWebhook(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	p := NewProvider(http.DefaultClient, Config{})
+	body := `{"push": {"changes": []}, "repository": {"full_name": "Workspace/Slug"}}`
+
+	newRequest := func(secret string) *http.Request {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		r := httptest.NewRequest(http.MethodPost, "/v1/webhooks/bitbucket", strings.NewReader(body))
+		r.Header.Set("X-Event-Key", "repo:push")
+		r.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		return r
+	}
+
+	event, err := p.ParseWebhook(newRequest("secret"), "secret")
+	require.NoError(err)
+	assert.Equal(provider.WebhookEvent{Event: "repo:push", Path: "workspace/slug", Push: true}, event)
+
+	_, err = p.ParseWebhook(newRequest("other"), "secret")
+	assert.ErrorIs(err, provider.ErrInvalidSignature)
+}
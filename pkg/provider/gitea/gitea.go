@@ -0,0 +1,242 @@
+// Package gitea implements the gitea provider with the gitea rest api, which forgejo serves as well
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// commitsPerPage is the page size of the commits api, gitea caps it at 50 by default
+const commitsPerPage = 50
+
+// Config represents the settings of the gitea provider
+type Config struct {
+	// APIURL is the base url of the gitea rest api, e.g. https://gitea.com/api/v1
+	APIURL string
+	// Token is an access token, requests are anonymous when it is empty
+	Token string
+}
+
+// repositoryResponse represents the repository http response from gitea api we are interested in
+type repositoryResponse struct {
+	Description     string `json:"description"`
+	HTMLURL         string `json:"html_url"`
+	Language        string `json:"language"`
+	ForksCount      int    `json:"forks_count"`
+	StarsCount      int    `json:"stars_count"`
+	OpenIssuesCount int    `json:"open_issues_count"`
+	WatchersCount   int    `json:"watchers_count"`
+}
+
+// commitUser represents the author or committer of a commit
+type commitUser struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// commitResponse represents a commit http response from gitea api
+type commitResponse struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message   string      `json:"message"`
+		Author    commitUser  `json:"author"`
+		Committer *commitUser `json:"committer"`
+	} `json:"commit"`
+	// Author is null when the commit email is not linked to a gitea account
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+	Files []struct {
+		Filename string `json:"filename"`
+	} `json:"files"`
+	Stats *struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+}
+
+// webhookPayload represents the fields of webhook payloads we are interested in
+type webhookPayload struct {
+	Repository *struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// Provider represents the gitea provider
+type Provider struct {
+	httpClient *http.Client
+	config     Config
+}
+
+// NewProvider initiates a new gitea provider sending api requests with httpClient
+func NewProvider(httpClient *http.Client, config Config) *Provider {
+	return &Provider{
+		httpClient: httpClient,
+		config:     config,
+	}
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() repository.Provider {
+	return repository.ProviderGitea
+}
+
+// get decodes the json response of the api at path into v and returns the response headers,
+// endpoint labels the request metrics
+func (p *Provider) get(ctx context.Context, endpoint, path string, v interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.APIURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching from gitea: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.config.Token != "" {
+		req.Header.Set("Authorization", "token "+p.config.Token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	metrics.ObserveProviderResponse(string(repository.ProviderGitea), endpoint, resp)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, provider.ErrRateLimitReached
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", endpoint, resp.Status)
+	}
+
+	return resp.Header, json.NewDecoder(resp.Body).Decode(v)
+}
+
+// FetchRepository implements provider.Provider
+func (p *Provider) FetchRepository(ctx context.Context, path string) (provider.Repository, error) {
+	var repo repositoryResponse
+	if _, err := p.get(ctx, "repos", "/repos/"+path, &repo); err != nil {
+		return provider.Repository{}, err
+	}
+
+	return provider.Repository{
+		Description:     repo.Description,
+		URL:             repo.HTMLURL,
+		Language:        repo.Language,
+		ForksCount:      repo.ForksCount,
+		StarsCount:      repo.StarsCount,
+		OpenIssuesCount: repo.OpenIssuesCount,
+		WatchersCount:   repo.WatchersCount,
+	}, nil
+}
+
+// ListCommits implements provider.Provider, the changed files and line counts of commits are listed along with them
+func (p *Provider) ListCommits(ctx context.Context, path string, since *time.Time, cursor string) (provider.CommitPage, error) {
+	page, err := provider.PageNumber(cursor)
+	if err != nil {
+		return provider.CommitPage{}, err
+	}
+
+	query := url.Values{}
+	query.Set("page", fmt.Sprint(page))
+	query.Set("limit", fmt.Sprint(commitsPerPage))
+	query.Set("stat", "true")
+	query.Set("files", "true")
+	query.Set("verification", "false")
+	if since != nil {
+		query.Set("since", provider.Since(*since))
+	}
+
+	var commits []*commitResponse
+	header, err := p.get(ctx, "commits", "/repos/"+path+"/commits?"+query.Encode(), &commits)
+	if err != nil {
+		return provider.CommitPage{}, err
+	}
+
+	more := len(commits) == commitsPerPage
+	if hasMore, err := strconv.ParseBool(header.Get("X-HasMore")); err == nil {
+		more = hasMore
+	}
+	result := provider.CommitPage{Next: provider.NextPage(page, more)}
+	for _, commit := range commits {
+		result.Commits = append(result.Commits, commit.toCommit())
+	}
+
+	return result, nil
+}
+
+// toCommit converts a commit response to a commit
+func (c *commitResponse) toCommit() repository.GithubCommit {
+	commit := repository.GithubCommit{
+		CommitHash:  c.SHA,
+		Message:     c.Commit.Message,
+		AuthorName:  c.Commit.Author.Name,
+		AuthorEmail: c.Commit.Author.Email,
+		Date:        c.Commit.Author.Date,
+		URL:         c.HTMLURL,
+		Parents:     make([]string, 0, len(c.Parents)),
+	}
+	if c.Author != nil {
+		commit.AuthorLogin = c.Author.Login
+	}
+	for _, parent := range c.Parents {
+		commit.Parents = append(commit.Parents, parent.SHA)
+	}
+	if committer := c.Commit.Committer; committer != nil {
+		commit.CommitterName = committer.Name
+		commit.CommitterEmail = committer.Email
+		commit.CommitterDate = &committer.Date
+	}
+
+	// merges are diffed against their first parent by gitea, their stats are left unknown as for git imports
+	if c.Stats != nil && !commit.IsMerge() {
+		commit.Stats = &repository.CommitDelta{
+			Additions:    c.Stats.Additions,
+			Deletions:    c.Stats.Deletions,
+			FilesChanged: len(c.Files),
+		}
+		for _, file := range c.Files {
+			commit.Files = append(commit.Files, file.Filename)
+		}
+	}
+
+	return commit
+}
+
+// ParseWebhook implements provider.Provider, deliveries are signed in the X-Gitea-Signature header
+func (p *Provider) ParseWebhook(r *http.Request, secret string) (provider.WebhookEvent, error) {
+	body, err := provider.ReadWebhook(r)
+	if err != nil {
+		return provider.WebhookEvent{}, err
+	}
+	if !provider.ValidSignature(secret, body, r.Header.Get("X-Gitea-Signature")) {
+		return provider.WebhookEvent{}, provider.ErrInvalidSignature
+	}
+
+	event := provider.WebhookEvent{Event: r.Header.Get("X-Gitea-Event")}
+	event.Push = event.Event == "push"
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return event, fmt.Errorf("%w: %w", provider.ErrInvalidWebhook, err)
+	}
+	if payload.Repository != nil {
+		event.Path = strings.ToLower(payload.Repository.FullName)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,129 @@
+package gitea
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProvider returns a provider for a fake gitea api served by handler
+func newTestProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewProvider(server.Client(), Config{APIURL: server.URL + "/api/v1", Token: "gitea-token"})
+}
+
+// go test -timeout 30s -run ^TestFetchRepository$ ./pkg/provider/gitea -v
+func TestFetchRepository(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/api/v1/repos/owner/name", r.URL.Path)
+		assert.Equal("token gitea-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"description": "a repo", "html_url": "https://gitea.com/owner/name", "language": "Go", "forks_count": 2, "stars_count": 5, "open_issues_count": 1, "watchers_count": 3}`))
+	})
+
+	repo, err := p.FetchRepository(context.Background(), "owner/name")
+	require.NoError(err)
+	assert.Equal(provider.Repository{
+		Description:     "a repo",
+		URL:             "https://gitea.com/owner/name",
+		Language:        "Go",
+		ForksCount:      2,
+		StarsCount:      5,
+		OpenIssuesCount: 1,
+		WatchersCount:   3,
+	}, repo)
+}
+
+// go test -timeout 30s -run ^TestListCommits$ ./pkg/provider/gitea -v
+func TestListCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/api/v1/repos/owner/name/commits", r.URL.Path)
+		assert.Equal("true", r.URL.Query().Get("stat"))
+		assert.Empty(r.URL.Query().Get("since"))
+		w.Header().Set("X-HasMore", "false")
+		w.Write([]byte(`[
+			{
+				"sha": "abc", "html_url": "https://gitea.com/owner/name/commit/abc",
+				"commit": {
+					"message": "Add feature",
+					"author": {"name": "Alice", "email": "alice@example.com", "date": "2024-05-02T10:00:00Z"},
+					"committer": {"name": "Bob", "email": "bob@example.com", "date": "2024-05-02T11:00:00Z"}
+				},
+				"author": {"login": "alice"},
+				"parents": [{"sha": "def"}],
+				"files": [{"filename": "main.go"}, {"filename": "README.md"}],
+				"stats": {"total": 7, "additions": 5, "deletions": 2}
+			},
+			{
+				"sha": "def", "html_url": "https://gitea.com/owner/name/commit/def",
+				"commit": {"message": "Merge", "author": {"name": "Carol", "email": "carol@example.com", "date": "2024-05-01T10:00:00Z"}},
+				"author": null,
+				"parents": [{"sha": "1"}, {"sha": "2"}],
+				"files": [{"filename": "main.go"}],
+				"stats": {"total": 1, "additions": 1, "deletions": 0}
+			}
+		]`))
+	})
+
+	page, err := p.ListCommits(context.Background(), "owner/name", nil, "")
+	require.NoError(err)
+	assert.Empty(page.Next)
+	require.Len(page.Commits, 2)
+
+	commit := page.Commits[0]
+	assert.Equal("abc", commit.CommitHash)
+	assert.Equal("alice", commit.AuthorLogin)
+	assert.True(time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC).Equal(commit.Date))
+	assert.Equal("Bob", commit.CommitterName)
+	assert.Equal([]string{"def"}, commit.Parents)
+	assert.Equal([]string{"main.go", "README.md"}, commit.Files)
+	assert.Equal(&repository.CommitDelta{Additions: 5, Deletions: 2, FilesChanged: 2}, commit.Stats)
+
+	merge := page.Commits[1]
+	assert.Empty(merge.AuthorLogin)
+	assert.Empty(merge.CommitterName)
+	assert.Nil(merge.Stats, "merges have no stats")
+	assert.Empty(merge.Files)
+}
+
+// go test -timeout 30s -run ^TestParseWebhook$ ./pkg/provider/gitea -v
+func TestParseWebhook(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	p := NewProvider(http.DefaultClient, Config{})
+	body := `{"ref": "refs/heads/main", "repository": {"full_name": "Owner/Name"}}`
+
+	newRequest := func(secret string) *http.Request {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		r := httptest.NewRequest(http.MethodPost, "/v1/webhooks/gitea", strings.NewReader(body))
+		r.Header.Set("X-Gitea-Event", "push")
+		r.Header.Set("X-Gitea-Signature", hex.EncodeToString(mac.Sum(nil)))
+		return r
+	}
+
+	event, err := p.ParseWebhook(newRequest("secret"), "secret")
+	require.NoError(err)
+	assert.Equal(provider.WebhookEvent{Event: "push", Path: "owner/name", Push: true}, event)
+
+	_, err = p.ParseWebhook(newRequest("other"), "secret")
+	assert.ErrorIs(err, provider.ErrInvalidSignature)
+}
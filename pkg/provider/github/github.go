@@ -0,0 +1,330 @@
+// Package github implements the github provider with the github rest api, or the graphql api for repos fetched with it
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+const (
+	// commitsPerPage is the largest page size of the commits api
+	commitsPerPage = 100
+	// reposPerPage is the largest page size of the repository listings of organizations and users
+	reposPerPage = 100
+)
+
+// Config represents the settings of the github provider
+type Config struct {
+	// APIURL is the base url of the github rest api
+	APIURL string
+	// GraphQLURL is the url of the github graphql api
+	GraphQLURL string
+	// Token authenticates github api requests, requests are anonymous when it is empty
+	Token string
+}
+
+// RepositoryResponse represents repository http response from github api we are interested in
+type RepositoryResponse struct {
+	Description      string `json:"description"`
+	URL              string `json:"html_url"`
+	Language         string `json:"language"`
+	ForksCount       int    `json:"forks_count"`
+	StarsCount       int    `json:"stargazers_count"`
+	OpenIssuesCount  int    `json:"open_issues"`
+	SubscribersCount int    `json:"subscribers_count"`
+}
+
+// OwnerRepositoryResponse represents a repository in the repository listings of organizations and users
+type OwnerRepositoryResponse struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Fork     bool   `json:"fork"`
+	Archived bool   `json:"archived"`
+}
+
+// CommitAuthor represents author in CommitDetails
+type CommitAuthor struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// CommitDetails represents commit details in CommitResponse
+type CommitDetails struct {
+	Message   string        `json:"message"`
+	Author    CommitAuthor  `json:"author"`
+	Committer *CommitAuthor `json:"committer"`
+}
+
+// User represents the github account linked to a commit author
+type User struct {
+	Login string `json:"login"`
+}
+
+// CommitParent represents a parent in CommitResponse
+type CommitParent struct {
+	SHA string `json:"sha"`
+}
+
+// CommitResponse represents commit http response
+type CommitResponse struct {
+	SHA     string         `json:"sha"`
+	Commit  CommitDetails  `json:"commit"`
+	URL     string         `json:"html_url"`
+	Author  *User          `json:"author"` // author is null when the commit email is not linked to a github account
+	Parents []CommitParent `json:"parents"`
+}
+
+// RateLimit represents the remaining core api quota
+type RateLimit struct {
+	Remaining int
+	Reset     time.Time
+}
+
+// rateLimitResponse represents the rate limit http response from github api
+type rateLimitResponse struct {
+	Resources struct {
+		Core struct {
+			Remaining int   `json:"remaining"`
+			Reset     int64 `json:"reset"`
+		} `json:"core"`
+	} `json:"resources"`
+}
+
+// webhookPayload represents the fields of webhook payloads we are interested in
+type webhookPayload struct {
+	Repository *struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// Provider represents the github provider
+type Provider struct {
+	httpClient *http.Client
+	config     Config
+}
+
+// NewProvider initiates a new github provider sending api requests with httpClient
+func NewProvider(httpClient *http.Client, config Config) *Provider {
+	return &Provider{
+		httpClient: httpClient,
+		config:     config,
+	}
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() repository.Provider {
+	return repository.ProviderGithub
+}
+
+// newRequest creates a github api request for path, authenticated when a token is configured
+func (p *Provider) newRequest(ctx context.Context, path string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.APIURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "go-github-fetcher")
+	if p.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.Token)
+	}
+
+	return req, nil
+}
+
+// get decodes the json response of the api at path into v, endpoint labels the request metrics
+func (p *Provider) get(ctx context.Context, endpoint, path string, v interface{}) error {
+	req, err := p.newRequest(ctx, path)
+	if err != nil {
+		return fmt.Errorf("error fetching from github: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	metrics.ObserveGithubResponse(endpoint, resp)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return provider.ErrRateLimitReached
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: %s", endpoint, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// FetchRepository implements provider.Provider
+func (p *Provider) FetchRepository(ctx context.Context, path string) (provider.Repository, error) {
+	var repo RepositoryResponse
+	if err := p.get(ctx, "repos", "/repos/"+path, &repo); err != nil {
+		return provider.Repository{}, err
+	}
+
+	return provider.Repository{
+		Description:     repo.Description,
+		URL:             repo.URL,
+		Language:        repo.Language,
+		ForksCount:      repo.ForksCount,
+		StarsCount:      repo.StarsCount,
+		OpenIssuesCount: repo.OpenIssuesCount,
+		WatchersCount:   repo.SubscribersCount,
+	}, nil
+}
+
+// ListCommits implements provider.Provider
+func (p *Provider) ListCommits(ctx context.Context, path string, since *time.Time, cursor string) (provider.CommitPage, error) {
+	page, err := provider.PageNumber(cursor)
+	if err != nil {
+		return provider.CommitPage{}, err
+	}
+
+	query := url.Values{}
+	query.Set("page", fmt.Sprint(page))
+	query.Set("per_page", fmt.Sprint(commitsPerPage))
+	if since != nil {
+		query.Set("since", provider.Since(*since))
+	}
+
+	var commits []*CommitResponse
+	if err := p.get(ctx, "commits", "/repos/"+path+"/commits?"+query.Encode(), &commits); err != nil {
+		return provider.CommitPage{}, err
+	}
+
+	result := provider.CommitPage{Next: provider.NextPage(page, len(commits) == commitsPerPage)}
+	for _, commit := range commits {
+		result.Commits = append(result.Commits, commit.toCommit())
+	}
+
+	return result, nil
+}
+
+// ListOwnerRepositories implements provider.OwnerLister, the repositories of users are the ones they own
+func (p *Provider) ListOwnerRepositories(ctx context.Context, kind repository.OwnerKind, login, cursor string) (provider.OwnerRepositoryPage, error) {
+	page, err := provider.PageNumber(cursor)
+	if err != nil {
+		return provider.OwnerRepositoryPage{}, err
+	}
+
+	endpoint, repoType := "/orgs/", "all"
+	if kind == repository.OwnerKindUser {
+		endpoint, repoType = "/users/", "owner"
+	}
+	query := url.Values{}
+	query.Set("type", repoType)
+	query.Set("page", fmt.Sprint(page))
+	query.Set("per_page", fmt.Sprint(reposPerPage))
+
+	var repos []*OwnerRepositoryResponse
+	if err := p.get(ctx, "owner_repos", endpoint+url.PathEscape(login)+"/repos?"+query.Encode(), &repos); err != nil {
+		return provider.OwnerRepositoryPage{}, err
+	}
+
+	result := provider.OwnerRepositoryPage{Next: provider.NextPage(page, len(repos) == reposPerPage)}
+	for _, repo := range repos {
+		result.Repositories = append(result.Repositories, provider.OwnerRepository{
+			Path:     strings.ToLower(repo.FullName),
+			Name:     strings.ToLower(repo.Name),
+			Fork:     repo.Fork,
+			Archived: repo.Archived,
+		})
+	}
+
+	return result, nil
+}
+
+// toCommit converts a commit response to a commit
+func (c *CommitResponse) toCommit() repository.GithubCommit {
+	var authorLogin string
+	if c.Author != nil {
+		authorLogin = c.Author.Login
+	}
+	parents := make([]string, 0, len(c.Parents))
+	for _, parent := range c.Parents {
+		parents = append(parents, parent.SHA)
+	}
+
+	commit := repository.GithubCommit{
+		CommitHash:  c.SHA,
+		Message:     c.Commit.Message,
+		AuthorName:  c.Commit.Author.Name,
+		AuthorEmail: c.Commit.Author.Email,
+		AuthorLogin: authorLogin,
+		Date:        c.Commit.Author.Date,
+		URL:         c.URL,
+		Parents:     parents,
+	}
+	if committer := c.Commit.Committer; committer != nil {
+		commit.CommitterName = committer.Name
+		commit.CommitterEmail = committer.Email
+		commit.CommitterDate = &committer.Date
+	}
+
+	return commit
+}
+
+// ParseWebhook implements provider.Provider, deliveries are signed in the X-Hub-Signature-256 header
+func (p *Provider) ParseWebhook(r *http.Request, secret string) (provider.WebhookEvent, error) {
+	body, err := provider.ReadWebhook(r)
+	if err != nil {
+		return provider.WebhookEvent{}, err
+	}
+	signature, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+	if !ok || !provider.ValidSignature(secret, body, signature) {
+		return provider.WebhookEvent{}, provider.ErrInvalidSignature
+	}
+
+	event := provider.WebhookEvent{Event: r.Header.Get("X-GitHub-Event")}
+	event.Push = event.Event == "push"
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return event, fmt.Errorf("%w: %w", provider.ErrInvalidWebhook, err)
+	}
+	if payload.Repository != nil {
+		event.Path = strings.ToLower(payload.Repository.FullName)
+	}
+
+	return event, nil
+}
+
+// RateLimit fetches the rate limit of the token, the endpoint does not count against the quota
+func (p *Provider) RateLimit(ctx context.Context) (RateLimit, error) {
+	var rateLimit rateLimitResponse
+	req, err := p.newRequest(ctx, "/rate_limit")
+	if err != nil {
+		return RateLimit{}, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return RateLimit{}, fmt.Errorf("failed to fetch rate limit: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&rateLimit); err != nil {
+		return RateLimit{}, err
+	}
+
+	return RateLimit{
+		Remaining: rateLimit.Resources.Core.Remaining,
+		Reset:     time.Unix(rateLimit.Resources.Core.Reset, 0).UTC(),
+	}, nil
+}
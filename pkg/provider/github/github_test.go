@@ -0,0 +1,137 @@
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sign returns the hex encoded HMAC-SHA256 of body with secret
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// go test -timeout 30s -run ^TestListCommits$ ./pkg/provider/github -v
+func TestListCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/repos/owner/name/commits", r.URL.Path)
+		assert.Equal("Bearer ghp_token", r.Header.Get("Authorization"))
+		count := commitsPerPage
+		if r.URL.Query().Get("page") == "2" {
+			count = 1
+		}
+
+		var commits []string
+		for i := 0; i < count; i++ {
+			commits = append(commits, fmt.Sprintf(`{
+				"sha": "sha-%d", "html_url": "https://github.com/owner/name/commit/sha-%d",
+				"commit": {"message": "commit", "author": {"name": "Alice", "email": "alice@example.com", "date": "2024-05-01T10:00:00Z"}},
+				"author": {"login": "alice"}, "parents": [{"sha": "parent"}]
+			}`, i, i))
+		}
+		w.Write([]byte("[" + strings.Join(commits, ",") + "]"))
+	}))
+	defer server.Close()
+	p := NewProvider(server.Client(), Config{APIURL: server.URL, Token: "ghp_token"})
+
+	page, err := p.ListCommits(context.Background(), "owner/name", nil, "")
+	require.NoError(err)
+	assert.Equal("2", page.Next, "a full page may be followed by another")
+	require.Len(page.Commits, commitsPerPage)
+	commit := page.Commits[0]
+	assert.Equal("sha-0", commit.CommitHash)
+	assert.Equal("alice", commit.AuthorLogin)
+	assert.True(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Equal(commit.Date))
+	assert.Equal([]string{"parent"}, commit.Parents)
+	assert.Nil(commit.CommitterDate)
+
+	page, err = p.ListCommits(context.Background(), "owner/name", nil, page.Next)
+	require.NoError(err)
+	assert.Empty(page.Next)
+}
+
+// go test -timeout 30s -run ^TestListOwnerRepositories$ ./pkg/provider/github -v
+func TestListOwnerRepositories(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/alice/repos" {
+			assert.Equal("owner", r.URL.Query().Get("type"))
+			w.Write([]byte(`[]`))
+			return
+		}
+		assert.Equal("/orgs/myorg/repos", r.URL.Path)
+		assert.Equal("all", r.URL.Query().Get("type"))
+		count := reposPerPage
+		if r.URL.Query().Get("page") == "2" {
+			count = 1
+		}
+
+		var repos []string
+		for i := 0; i < count; i++ {
+			repos = append(repos, fmt.Sprintf(`{"name": "Repo-%d", "full_name": "MyOrg/Repo-%d", "fork": %t, "archived": %t}`, i, i, i == 1, i == 2))
+		}
+		w.Write([]byte("[" + strings.Join(repos, ",") + "]"))
+	}))
+	defer server.Close()
+	p := NewProvider(server.Client(), Config{APIURL: server.URL})
+
+	page, err := p.ListOwnerRepositories(context.Background(), repository.OwnerKindOrg, "myorg", "")
+	require.NoError(err)
+	assert.Equal("2", page.Next)
+	require.Len(page.Repositories, reposPerPage)
+	assert.Equal(provider.OwnerRepository{Path: "myorg/repo-0", Name: "repo-0"}, page.Repositories[0])
+	assert.True(page.Repositories[1].Fork)
+	assert.True(page.Repositories[2].Archived)
+
+	page, err = p.ListOwnerRepositories(context.Background(), repository.OwnerKindOrg, "myorg", page.Next)
+	require.NoError(err)
+	assert.Empty(page.Next)
+	assert.Len(page.Repositories, 1)
+
+	page, err = p.ListOwnerRepositories(context.Background(), repository.OwnerKindUser, "alice", "")
+	require.NoError(err)
+	assert.Equal(provider.OwnerRepositoryPage{}, page)
+}
+
+// go test -timeout 30s -run ^TestParseWebhook$ ./pkg/provider/github -v
+func TestParseWebhook(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	p := NewProvider(http.DefaultClient, Config{})
+	body := `{"ref": "refs/heads/main", "repository": {"full_name": "Owner/Name"}}`
+
+	newRequest := func(signature string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/v1/webhooks/github", strings.NewReader(body))
+		r.Header.Set("X-GitHub-Event", "push")
+		r.Header.Set("X-Hub-Signature-256", signature)
+		return r
+	}
+
+	event, err := p.ParseWebhook(newRequest("sha256="+sign("It's a Secret to Everybody", body)), "It's a Secret to Everybody")
+	require.NoError(err)
+	assert.Equal(provider.WebhookEvent{Event: "push", Path: "owner/name", Push: true}, event)
+
+	for _, signature := range []string{"", sign("It's a Secret to Everybody", body), "sha256=" + sign("other", body), "sha256=zz"} {
+		_, err = p.ParseWebhook(newRequest(signature), "It's a Secret to Everybody")
+		assert.ErrorIs(err, provider.ErrInvalidSignature, signature)
+	}
+}
@@ -0,0 +1,374 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+const (
+	// graphqlCommitsPerPage is the largest page size of graphql connections
+	graphqlCommitsPerPage = 100
+	// graphqlPullRequestsPerCommit bounds the pull requests loaded per commit, a commit is rarely part of more
+	graphqlPullRequestsPerCommit = 10
+)
+
+// repositoryQuery fetches the metadata of a repository. open issues include open pull requests like the rest api
+const repositoryQuery = `query($owner: String!, $name: String!) {
+  rateLimit { cost remaining resetAt }
+  repository(owner: $owner, name: $name) {
+    description
+    url
+    primaryLanguage { name }
+    forkCount
+    stargazerCount
+    issues(states: OPEN) { totalCount }
+    pullRequests(states: OPEN) { totalCount }
+    watchers { totalCount }
+  }
+}`
+
+// historyQuery fetches a page of the commits of the default branch of a repository with their stats and pull requests
+const historyQuery = `query($owner: String!, $name: String!, $first: Int!, $cursor: String, $since: GitTimestamp, $pullRequests: Int!) {
+  rateLimit { cost remaining resetAt }
+  repository(owner: $owner, name: $name) {
+    defaultBranchRef {
+      target {
+        ... on Commit {
+          history(first: $first, after: $cursor, since: $since) {
+            pageInfo { hasNextPage endCursor }
+            nodes {
+              oid
+              message
+              url
+              additions
+              deletions
+              changedFilesIfAvailable
+              author { name email date user { login } }
+              committer { name email date }
+              parents(first: 100) { nodes { oid } }
+              associatedPullRequests(first: $pullRequests) { nodes { number } }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// graphqlRequest represents the body of a graphql api request
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// graphqlError represents an error of a graphql response, type is e.g. NOT_FOUND or RATE_LIMITED
+type graphqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphqlResponse represents a graphql http response, data is decoded by the caller
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphqlError  `json:"errors"`
+}
+
+// graphqlRateLimit represents the point based rate limit reported by every query
+type graphqlRateLimit struct {
+	Cost      int       `json:"cost"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// totalCount represents the size of a graphql connection
+type totalCount struct {
+	TotalCount int `json:"totalCount"`
+}
+
+// repositoryData represents the data of repositoryQuery
+type repositoryData struct {
+	Repository *struct {
+		Description     string `json:"description"`
+		URL             string `json:"url"`
+		PrimaryLanguage *struct {
+			Name string `json:"name"`
+		} `json:"primaryLanguage"`
+		ForkCount      int        `json:"forkCount"`
+		StargazerCount int        `json:"stargazerCount"`
+		Issues         totalCount `json:"issues"`
+		PullRequests   totalCount `json:"pullRequests"`
+		Watchers       totalCount `json:"watchers"`
+	} `json:"repository"`
+}
+
+// graphqlActor represents the author or committer of a graphql commit
+type graphqlActor struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+	// User is null when the email is not linked to a github account
+	User *User `json:"user"`
+}
+
+// graphqlCommit represents a commit of historyQuery
+type graphqlCommit struct {
+	OID       string `json:"oid"`
+	Message   string `json:"message"`
+	URL       string `json:"url"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	// ChangedFiles is null when github does not compute it, e.g. for very large commits, the stats are left unknown then
+	ChangedFiles *int          `json:"changedFilesIfAvailable"`
+	Author       *graphqlActor `json:"author"`
+	Committer    *graphqlActor `json:"committer"`
+	Parents      struct {
+		Nodes []struct {
+			OID string `json:"oid"`
+		} `json:"nodes"`
+	} `json:"parents"`
+	AssociatedPullRequests struct {
+		Nodes []struct {
+			Number int `json:"number"`
+		} `json:"nodes"`
+	} `json:"associatedPullRequests"`
+}
+
+// historyData represents the data of historyQuery, the default branch is null for empty repositories
+type historyData struct {
+	Repository *struct {
+		DefaultBranchRef *struct {
+			Target struct {
+				History struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []graphqlCommit `json:"nodes"`
+				} `json:"history"`
+			} `json:"target"`
+		} `json:"defaultBranchRef"`
+	} `json:"repository"`
+}
+
+// GraphQLProvider represents the github provider fetching with the github graphql api. commits are listed
+// with their line counts and pull requests in pages of 100 instead of a rest request per commit.
+// webhooks are parsed like the rest provider
+type GraphQLProvider struct {
+	*Provider
+
+	// rateLimit is the rate limit reported by the last query, it is shared by every repo synced with the token
+	rateLimitMu sync.Mutex
+	rateLimit   *graphqlRateLimit
+}
+
+// NewGraphQLProvider initiates a new github graphql provider sending api requests with httpClient,
+// the graphql api does not accept anonymous requests
+func NewGraphQLProvider(httpClient *http.Client, config Config) *GraphQLProvider {
+	return &GraphQLProvider{Provider: NewProvider(httpClient, config)}
+}
+
+// reserve returns provider.ErrRateLimitReached when the points left before the rate limit resets
+// are fewer than the cost of the last query, so queries are not sent only to be rejected
+func (p *GraphQLProvider) reserve() error {
+	p.rateLimitMu.Lock()
+	defer p.rateLimitMu.Unlock()
+
+	if p.rateLimit == nil || time.Now().After(p.rateLimit.ResetAt) {
+		return nil
+	}
+	if p.rateLimit.Remaining < max(p.rateLimit.Cost, 1) {
+		return fmt.Errorf("%d graphql points left until %s: %w", p.rateLimit.Remaining, p.rateLimit.ResetAt.Format(time.RFC3339), provider.ErrRateLimitReached)
+	}
+
+	return nil
+}
+
+// observeRateLimit records the rate limit reported by a query
+func (p *GraphQLProvider) observeRateLimit(rateLimit *graphqlRateLimit) {
+	if rateLimit == nil {
+		return
+	}
+
+	p.rateLimitMu.Lock()
+	defer p.rateLimitMu.Unlock()
+	p.rateLimit = rateLimit
+}
+
+// query sends a graphql query for the repository at path and decodes its data into v
+func (p *GraphQLProvider) query(ctx context.Context, query, path string, variables map[string]any, v any) error {
+	owner, name, ok := strings.Cut(path, "/")
+	if !ok {
+		return fmt.Errorf("invalid github repository path %q", path)
+	}
+	if err := p.reserve(); err != nil {
+		return err
+	}
+
+	variables["owner"], variables["name"] = owner, name
+	body, err := json.Marshal(graphqlRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.GraphQLURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error fetching from github: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-github-fetcher")
+	if p.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.Token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	metrics.ObserveGithubResponse("graphql", resp)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// secondary rate limits are reported with 403 or 429
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		return provider.ErrRateLimitReached
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to query graphql: %s", resp.Status)
+	}
+
+	var result graphqlResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	// the rate limit is reported even when the query failed
+	var rateLimit struct {
+		RateLimit *graphqlRateLimit `json:"rateLimit"`
+	}
+	if len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, &rateLimit); err == nil {
+			p.observeRateLimit(rateLimit.RateLimit)
+		}
+	}
+
+	for _, e := range result.Errors {
+		if e.Type == "RATE_LIMITED" {
+			return provider.ErrRateLimitReached
+		}
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("failed to query graphql: %s", result.Errors[0].Message)
+	}
+
+	return json.Unmarshal(result.Data, v)
+}
+
+// FetchRepository implements provider.Provider
+func (p *GraphQLProvider) FetchRepository(ctx context.Context, path string) (provider.Repository, error) {
+	var data repositoryData
+	if err := p.query(ctx, repositoryQuery, path, map[string]any{}, &data); err != nil {
+		return provider.Repository{}, err
+	}
+	repo := data.Repository
+	if repo == nil {
+		return provider.Repository{}, fmt.Errorf("repository %s not found", path)
+	}
+
+	var language string
+	if repo.PrimaryLanguage != nil {
+		language = repo.PrimaryLanguage.Name
+	}
+	return provider.Repository{
+		Description:     repo.Description,
+		URL:             repo.URL,
+		Language:        language,
+		ForksCount:      repo.ForkCount,
+		StarsCount:      repo.StargazerCount,
+		OpenIssuesCount: repo.Issues.TotalCount + repo.PullRequests.TotalCount,
+		WatchersCount:   repo.Watchers.TotalCount,
+	}, nil
+}
+
+// ListCommits implements provider.Provider, commits of the default branch are listed. cursors are the end cursors of
+// the history connection
+func (p *GraphQLProvider) ListCommits(ctx context.Context, path string, since *time.Time, cursor string) (provider.CommitPage, error) {
+	variables := map[string]any{
+		"first":        graphqlCommitsPerPage,
+		"pullRequests": graphqlPullRequestsPerCommit,
+	}
+	if cursor != "" {
+		variables["cursor"] = cursor
+	}
+	if since != nil {
+		variables["since"] = provider.Since(*since)
+	}
+
+	var data historyData
+	if err := p.query(ctx, historyQuery, path, variables, &data); err != nil {
+		return provider.CommitPage{}, err
+	}
+	if data.Repository == nil {
+		return provider.CommitPage{}, fmt.Errorf("repository %s not found", path)
+	}
+	if data.Repository.DefaultBranchRef == nil {
+		// empty repositories have no default branch
+		return provider.CommitPage{}, nil
+	}
+
+	history := data.Repository.DefaultBranchRef.Target.History
+	var result provider.CommitPage
+	if history.PageInfo.HasNextPage {
+		result.Next = history.PageInfo.EndCursor
+	}
+	for _, commit := range history.Nodes {
+		result.Commits = append(result.Commits, commit.toCommit())
+	}
+
+	return result, nil
+}
+
+// toCommit converts a graphql commit to a commit
+func (c *graphqlCommit) toCommit() repository.GithubCommit {
+	commit := repository.GithubCommit{
+		CommitHash: c.OID,
+		Message:    c.Message,
+		URL:        c.URL,
+	}
+	if author := c.Author; author != nil {
+		commit.AuthorName = author.Name
+		commit.AuthorEmail = author.Email
+		commit.Date = author.Date
+		if author.User != nil {
+			commit.AuthorLogin = author.User.Login
+		}
+	}
+	if committer := c.Committer; committer != nil {
+		commit.CommitterName = committer.Name
+		commit.CommitterEmail = committer.Email
+		commit.CommitterDate = &committer.Date
+	}
+	for _, parent := range c.Parents.Nodes {
+		commit.Parents = append(commit.Parents, parent.OID)
+	}
+	for _, pullRequest := range c.AssociatedPullRequests.Nodes {
+		commit.PullRequests = append(commit.PullRequests, pullRequest.Number)
+	}
+
+	// merges are diffed against their first parent by github, their stats are left unknown as for git imports
+	if c.ChangedFiles != nil && !commit.IsMerge() {
+		commit.Stats = &repository.CommitDelta{
+			Additions:    c.Additions,
+			Deletions:    c.Deletions,
+			FilesChanged: *c.ChangedFiles,
+		}
+	}
+
+	return commit
+}
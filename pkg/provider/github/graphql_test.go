@@ -0,0 +1,183 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGraphQLProvider returns a graphql provider for a fake graphql api served by handler
+func newTestGraphQLProvider(t *testing.T, handler func(w http.ResponseWriter, req graphqlRequest)) *GraphQLProvider {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/graphql", r.URL.Path)
+		assert.Equal(t, "Bearer ghp_token", r.Header.Get("Authorization"))
+
+		var req graphqlRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		handler(w, req)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewGraphQLProvider(server.Client(), Config{GraphQLURL: server.URL + "/graphql", Token: "ghp_token"})
+}
+
+// go test -timeout 30s -run ^TestGraphQLFetchRepository$ ./pkg/provider/github -v
+func TestGraphQLFetchRepository(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := newTestGraphQLProvider(t, func(w http.ResponseWriter, req graphqlRequest) {
+		assert.Equal("owner", req.Variables["owner"])
+		assert.Equal("name", req.Variables["name"])
+		w.Write([]byte(`{"data": {
+			"rateLimit": {"cost": 1, "remaining": 4999, "resetAt": "2030-01-01T00:00:00Z"},
+			"repository": {
+				"description": "a repo", "url": "https://github.com/owner/name", "primaryLanguage": {"name": "Go"},
+				"forkCount": 2, "stargazerCount": 5, "issues": {"totalCount": 1}, "pullRequests": {"totalCount": 2}, "watchers": {"totalCount": 3}
+			}
+		}}`))
+	})
+
+	repo, err := p.FetchRepository(context.Background(), "owner/name")
+	require.NoError(err)
+	assert.Equal(provider.Repository{
+		Description:     "a repo",
+		URL:             "https://github.com/owner/name",
+		Language:        "Go",
+		ForksCount:      2,
+		StarsCount:      5,
+		OpenIssuesCount: 3,
+		WatchersCount:   3,
+	}, repo)
+}
+
+// go test -timeout 30s -run ^TestGraphQLListCommits$ ./pkg/provider/github -v
+func TestGraphQLListCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	p := newTestGraphQLProvider(t, func(w http.ResponseWriter, req graphqlRequest) {
+		assert.Equal("2024-05-01T00:00:00Z", req.Variables["since"])
+		if req.Variables["cursor"] == "abc 99" {
+			w.Write([]byte(`{"data": {
+				"rateLimit": {"cost": 1, "remaining": 4998, "resetAt": "2030-01-01T00:00:00Z"},
+				"repository": {"defaultBranchRef": {"target": {"history": {"pageInfo": {"hasNextPage": false, "endCursor": "def 0"}, "nodes": []}}}}
+			}}`))
+			return
+		}
+
+		assert.Nil(req.Variables["cursor"])
+		w.Write([]byte(`{"data": {
+			"rateLimit": {"cost": 1, "remaining": 4999, "resetAt": "2030-01-01T00:00:00Z"},
+			"repository": {"defaultBranchRef": {"target": {"history": {
+				"pageInfo": {"hasNextPage": true, "endCursor": "abc 99"},
+				"nodes": [
+					{
+						"oid": "abc", "message": "Add feature (#7)", "url": "https://github.com/owner/name/commit/abc",
+						"additions": 5, "deletions": 2, "changedFilesIfAvailable": 2,
+						"author": {"name": "Alice", "email": "alice@example.com", "date": "2024-05-02T10:00:00Z", "user": {"login": "alice"}},
+						"committer": {"name": "GitHub", "email": "noreply@github.com", "date": "2024-05-02T11:00:00Z", "user": null},
+						"parents": {"nodes": [{"oid": "def"}]},
+						"associatedPullRequests": {"nodes": [{"number": 7}]}
+					},
+					{
+						"oid": "def", "message": "Merge", "url": "https://github.com/owner/name/commit/def",
+						"additions": 9, "deletions": 0, "changedFilesIfAvailable": 1,
+						"author": {"name": "Carol", "email": "carol@example.com", "date": "2024-05-01T10:00:00Z", "user": null},
+						"committer": null,
+						"parents": {"nodes": [{"oid": "1"}, {"oid": "2"}]},
+						"associatedPullRequests": {"nodes": []}
+					}
+				]
+			}}}}
+		}}`))
+	})
+
+	page, err := p.ListCommits(context.Background(), "owner/name", &since, "")
+	require.NoError(err)
+	assert.Equal("abc 99", page.Next)
+	require.Len(page.Commits, 2)
+
+	commit := page.Commits[0]
+	assert.Equal("abc", commit.CommitHash)
+	assert.Equal("alice", commit.AuthorLogin)
+	assert.True(time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC).Equal(commit.Date))
+	assert.Equal("GitHub", commit.CommitterName)
+	assert.Equal([]string{"def"}, commit.Parents)
+	assert.Equal(&repository.CommitDelta{Additions: 5, Deletions: 2, FilesChanged: 2}, commit.Stats)
+	assert.Equal([]int{7}, commit.PullRequests)
+
+	merge := page.Commits[1]
+	assert.Empty(merge.AuthorLogin)
+	assert.Nil(merge.CommitterDate)
+	assert.Nil(merge.Stats, "merges have no stats")
+	assert.Empty(merge.PullRequests)
+
+	page, err = p.ListCommits(context.Background(), "owner/name", &since, page.Next)
+	require.NoError(err)
+	assert.Empty(page.Next)
+	assert.Empty(page.Commits)
+}
+
+// go test -timeout 30s -run ^TestGraphQLEmptyRepository$ ./pkg/provider/github -v
+func TestGraphQLEmptyRepository(t *testing.T) {
+	p := newTestGraphQLProvider(t, func(w http.ResponseWriter, req graphqlRequest) {
+		w.Write([]byte(`{"data": {"rateLimit": {"cost": 1, "remaining": 4999, "resetAt": "2030-01-01T00:00:00Z"}, "repository": {"defaultBranchRef": null}}}`))
+	})
+
+	page, err := p.ListCommits(context.Background(), "owner/name", nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, provider.CommitPage{}, page)
+}
+
+// go test -timeout 30s -run ^TestGraphQLRateLimit$ ./pkg/provider/github -v
+func TestGraphQLRateLimit(t *testing.T) {
+	assert := assert.New(t)
+	var requests atomic.Int32
+	resetAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	p := newTestGraphQLProvider(t, func(w http.ResponseWriter, req graphqlRequest) {
+		switch requests.Add(1) {
+		case 1:
+			w.Write([]byte(`{"data": {"rateLimit": {"cost": 2, "remaining": 1, "resetAt": "` + resetAt + `"}, "repository": {"defaultBranchRef": null}}}`))
+		default:
+			w.Write([]byte(`{"data": null, "errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`))
+		}
+	})
+
+	_, err := p.ListCommits(context.Background(), "owner/name", nil, "")
+	assert.NoError(err)
+
+	// fewer points are left than the last query cost, the next query waits for the reset without being sent
+	_, err = p.ListCommits(context.Background(), "owner/name", nil, "")
+	assert.True(errors.Is(err, provider.ErrRateLimitReached))
+	assert.Equal(int32(1), requests.Load())
+
+	// queries rejected by github are reported as rate limited too
+	p.rateLimit = nil
+	_, err = p.FetchRepository(context.Background(), "owner/name")
+	assert.True(errors.Is(err, provider.ErrRateLimitReached))
+	assert.Equal(int32(2), requests.Load())
+}
+
+// go test -timeout 30s -run ^TestGraphQLErrors$ ./pkg/provider/github -v
+func TestGraphQLErrors(t *testing.T) {
+	p := newTestGraphQLProvider(t, func(w http.ResponseWriter, req graphqlRequest) {
+		w.Write([]byte(`{"data": {"repository": null}, "errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a Repository with the name 'owner/missing'."}]}`))
+	})
+
+	_, err := p.FetchRepository(context.Background(), "owner/missing")
+	assert.EqualError(t, err, "failed to query graphql: Could not resolve to a Repository with the name 'owner/missing'.")
+}
@@ -0,0 +1,202 @@
+// Package gitlab implements the gitlab provider with the gitlab rest api,
+// projects are addressed by their full path including subgroups
+package gitlab
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// commitsPerPage is the largest page size of the commits api
+const commitsPerPage = 100
+
+// Config represents the settings of the gitlab provider
+type Config struct {
+	// APIURL is the base url of the gitlab rest api, e.g. https://gitlab.com/api/v4
+	APIURL string
+	// Token is a personal, group or project access token, requests are anonymous when it is empty
+	Token string
+}
+
+// projectResponse represents the project http response from gitlab api we are interested in
+type projectResponse struct {
+	Description     string `json:"description"`
+	WebURL          string `json:"web_url"`
+	ForksCount      int    `json:"forks_count"`
+	StarCount       int    `json:"star_count"`
+	OpenIssuesCount int    `json:"open_issues_count"`
+}
+
+// commitResponse represents a commit http response from gitlab api
+type commitResponse struct {
+	ID             string    `json:"id"`
+	ParentIDs      []string  `json:"parent_ids"`
+	Message        string    `json:"message"`
+	AuthorName     string    `json:"author_name"`
+	AuthorEmail    string    `json:"author_email"`
+	AuthoredDate   time.Time `json:"authored_date"`
+	CommitterName  string    `json:"committer_name"`
+	CommitterEmail string    `json:"committer_email"`
+	CommittedDate  time.Time `json:"committed_date"`
+	WebURL         string    `json:"web_url"`
+}
+
+// webhookPayload represents the fields of webhook payloads we are interested in
+type webhookPayload struct {
+	ObjectKind string `json:"object_kind"`
+	Project    *struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+}
+
+// Provider represents the gitlab provider
+type Provider struct {
+	httpClient *http.Client
+	config     Config
+}
+
+// NewProvider initiates a new gitlab provider sending api requests with httpClient
+func NewProvider(httpClient *http.Client, config Config) *Provider {
+	return &Provider{
+		httpClient: httpClient,
+		config:     config,
+	}
+}
+
+// Name implements provider.Provider
+func (p *Provider) Name() repository.Provider {
+	return repository.ProviderGitlab
+}
+
+// projectPath returns the api path of the project at path, its slashes are encoded as gitlab expects
+func projectPath(path string) string {
+	return "/projects/" + url.PathEscape(path)
+}
+
+// get decodes the json response of the api at path into v and returns the response headers,
+// endpoint labels the request metrics
+func (p *Provider) get(ctx context.Context, endpoint, path string, v interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.APIURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching from gitlab: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.config.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", p.config.Token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	metrics.ObserveProviderResponse(string(repository.ProviderGitlab), endpoint, resp)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, provider.ErrRateLimitReached
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", endpoint, resp.Status)
+	}
+
+	return resp.Header, json.NewDecoder(resp.Body).Decode(v)
+}
+
+// FetchRepository implements provider.Provider, gitlab does not report the language of projects
+func (p *Provider) FetchRepository(ctx context.Context, path string) (provider.Repository, error) {
+	var project projectResponse
+	if _, err := p.get(ctx, "projects", projectPath(path), &project); err != nil {
+		return provider.Repository{}, err
+	}
+
+	return provider.Repository{
+		Description:     project.Description,
+		URL:             project.WebURL,
+		ForksCount:      project.ForksCount,
+		StarsCount:      project.StarCount,
+		OpenIssuesCount: project.OpenIssuesCount,
+	}, nil
+}
+
+// ListCommits implements provider.Provider, commits of the default branch are listed
+func (p *Provider) ListCommits(ctx context.Context, path string, since *time.Time, cursor string) (provider.CommitPage, error) {
+	page, err := provider.PageNumber(cursor)
+	if err != nil {
+		return provider.CommitPage{}, err
+	}
+
+	query := url.Values{}
+	query.Set("page", fmt.Sprint(page))
+	query.Set("per_page", fmt.Sprint(commitsPerPage))
+	if since != nil {
+		query.Set("since", provider.Since(*since))
+	}
+
+	var commits []*commitResponse
+	header, err := p.get(ctx, "commits", projectPath(path)+"/repository/commits?"+query.Encode(), &commits)
+	if err != nil {
+		return provider.CommitPage{}, err
+	}
+
+	// X-Next-Page is empty on the last page, it is left out for large projects
+	more := len(commits) == commitsPerPage
+	if _, ok := header["X-Next-Page"]; ok {
+		more = header.Get("X-Next-Page") != ""
+	}
+	result := provider.CommitPage{Next: provider.NextPage(page, more)}
+	for _, commit := range commits {
+		committedDate := commit.CommittedDate
+		result.Commits = append(result.Commits, repository.GithubCommit{
+			CommitHash:     commit.ID,
+			Message:        commit.Message,
+			AuthorName:     commit.AuthorName,
+			AuthorEmail:    commit.AuthorEmail,
+			Date:           commit.AuthoredDate,
+			URL:            commit.WebURL,
+			Parents:        append([]string{}, commit.ParentIDs...),
+			CommitterName:  commit.CommitterName,
+			CommitterEmail: commit.CommitterEmail,
+			CommitterDate:  &committedDate,
+		})
+	}
+
+	return result, nil
+}
+
+// ParseWebhook implements provider.Provider, gitlab sends the secret token in the X-Gitlab-Token header
+func (p *Provider) ParseWebhook(r *http.Request, secret string) (provider.WebhookEvent, error) {
+	token := r.Header.Get("X-Gitlab-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return provider.WebhookEvent{}, provider.ErrInvalidSignature
+	}
+	body, err := provider.ReadWebhook(r)
+	if err != nil {
+		return provider.WebhookEvent{}, err
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return provider.WebhookEvent{}, fmt.Errorf("%w: %w", provider.ErrInvalidWebhook, err)
+	}
+
+	event := provider.WebhookEvent{
+		Event: r.Header.Get("X-Gitlab-Event"),
+		Push:  payload.ObjectKind == "push",
+	}
+	if payload.Project != nil {
+		event.Path = strings.ToLower(payload.Project.PathWithNamespace)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,122 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProvider returns a provider for a fake gitlab api served by handler
+func newTestProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewProvider(server.Client(), Config{APIURL: server.URL + "/api/v4", Token: "glpat-token"})
+}
+
+// go test -timeout 30s -run ^TestFetchRepository$ ./pkg/provider/gitlab -v
+func TestFetchRepository(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/api/v4/projects/group%2Fsubgroup%2Fproject", r.URL.EscapedPath())
+		assert.Equal("glpat-token", r.Header.Get("PRIVATE-TOKEN"))
+		w.Write([]byte(`{"description": "a project", "web_url": "https://gitlab.com/group/subgroup/project", "forks_count": 2, "star_count": 5, "open_issues_count": 1}`))
+	})
+
+	repo, err := p.FetchRepository(context.Background(), "group/subgroup/project")
+	require.NoError(err)
+	assert.Equal(provider.Repository{
+		Description:     "a project",
+		URL:             "https://gitlab.com/group/subgroup/project",
+		ForksCount:      2,
+		StarsCount:      5,
+		OpenIssuesCount: 1,
+	}, repo)
+}
+
+// go test -timeout 30s -run ^TestListCommits$ ./pkg/provider/gitlab -v
+func TestListCommits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/api/v4/projects/group%2Fproject/repository/commits", r.URL.EscapedPath())
+		assert.Equal("2024-05-01T00:00:00Z", r.URL.Query().Get("since"))
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+		} else {
+			w.Header().Set("X-Next-Page", "")
+		}
+		w.Write([]byte(`[{
+			"id": "abc", "parent_ids": ["def", "123"], "message": "Merge branch 'feature'\n",
+			"author_name": "Alice", "author_email": "alice@example.com", "authored_date": "2024-05-02T10:00:00+02:00",
+			"committer_name": "Bob", "committer_email": "bob@example.com", "committed_date": "2024-05-02T11:00:00+02:00",
+			"web_url": "https://gitlab.com/group/project/-/commit/abc"
+		}]`))
+	})
+
+	page, err := p.ListCommits(context.Background(), "group/project", &since, "")
+	require.NoError(err)
+	assert.Equal("2", page.Next)
+	require.Len(page.Commits, 1)
+	commit := page.Commits[0]
+	assert.Equal("abc", commit.CommitHash)
+	assert.Equal([]string{"def", "123"}, commit.Parents)
+	assert.Equal("Alice", commit.AuthorName)
+	assert.True(time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC).Equal(commit.Date))
+	assert.Equal("Bob", commit.CommitterName)
+	require.NotNil(commit.CommitterDate)
+	assert.True(time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC).Equal(*commit.CommitterDate))
+	assert.Equal("https://gitlab.com/group/project/-/commit/abc", commit.URL)
+	assert.Nil(commit.Stats)
+
+	page, err = p.ListCommits(context.Background(), "group/project", &since, page.Next)
+	require.NoError(err)
+	assert.Empty(page.Next, "the last page has no next page")
+}
+
+// go test -timeout 30s -run ^TestRateLimit$ ./pkg/provider/gitlab -v
+func TestRateLimit(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := p.ListCommits(context.Background(), "group/project", nil, "")
+	assert.True(t, errors.Is(err, provider.ErrRateLimitReached))
+}
+
+// go test -timeout 30s -run ^TestParseWebhook$ ./pkg/provider/gitlab -v
+func TestParseWebhook(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	p := NewProvider(http.DefaultClient, Config{})
+
+	newRequest := func(token, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/v1/webhooks/gitlab", strings.NewReader(body))
+		r.Header.Set("X-Gitlab-Event", "Push Hook")
+		r.Header.Set("X-Gitlab-Token", token)
+		return r
+	}
+
+	event, err := p.ParseWebhook(newRequest("secret", `{"object_kind": "push", "project": {"path_with_namespace": "Group/Subgroup/Project"}}`), "secret")
+	require.NoError(err)
+	assert.Equal(provider.WebhookEvent{Event: "Push Hook", Path: "group/subgroup/project", Push: true}, event)
+
+	event, err = p.ParseWebhook(newRequest("secret", `{"object_kind": "tag_push", "project": {"path_with_namespace": "group/project"}}`), "secret")
+	require.NoError(err)
+	assert.False(event.Push)
+
+	_, err = p.ParseWebhook(newRequest("other", `{"object_kind": "push"}`), "secret")
+	assert.ErrorIs(err, provider.ErrInvalidSignature)
+}
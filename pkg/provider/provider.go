@@ -0,0 +1,150 @@
+// Package provider defines the git hosting services repositories are synced from,
+// the implementations live in the sub packages named after each provider
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// maxWebhookBytes bounds webhook payloads, github caps them at 25 MB
+const maxWebhookBytes = 25 << 20
+
+var (
+	// ErrRateLimitReached represents rate limit reached error
+	ErrRateLimitReached = errors.New("rate limit error")
+
+	// ErrInvalidSignature is returned for webhook deliveries which are not signed with the configured secret
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrWebhookTooLarge is returned for webhook deliveries larger than maxWebhookBytes
+	ErrWebhookTooLarge = errors.New("webhook is too large")
+
+	// ErrInvalidWebhook is returned for webhook deliveries whose payload can not be parsed
+	ErrInvalidWebhook = errors.New("invalid webhook payload")
+)
+
+// Repository represents the metadata of a repository on its provider
+type Repository struct {
+	Description     string
+	URL             string
+	Language        string
+	ForksCount      int
+	StarsCount      int
+	OpenIssuesCount int
+	WatchersCount   int
+}
+
+// CommitPage represents a page of the commits of a repository, newest first
+type CommitPage struct {
+	// Commits have no ID or RepositoryID, they are set when the commits are saved
+	Commits []repository.GithubCommit
+	// Next is the cursor of the next page, it is empty on the last page
+	Next string
+}
+
+// OwnerRepository represents a repository listed for an organization or user
+type OwnerRepository struct {
+	// Path is the path of the repository on its provider, e.g. owner/name
+	Path     string
+	Name     string
+	Fork     bool
+	Archived bool
+}
+
+// OwnerRepositoryPage represents a page of the repositories of an organization or user
+type OwnerRepositoryPage struct {
+	Repositories []OwnerRepository
+	// Next is the cursor of the next page, it is empty on the last page
+	Next string
+}
+
+// WebhookEvent represents a webhook delivery
+type WebhookEvent struct {
+	// Event is the event name of the provider, e.g. push or Push Hook
+	Event string
+	// Path is the path of the repository of the event on its provider, empty for events without a repository
+	Path string
+	// Push reports whether commits were pushed to the repository
+	Push bool
+}
+
+// Provider fetches repositories and their commits from a git hosting service
+type Provider interface {
+	// Name returns the provider of the repositories it serves
+	Name() repository.Provider
+	// FetchRepository returns the metadata of the repository at path
+	FetchRepository(ctx context.Context, path string) (Repository, error)
+	// ListCommits returns the page at cursor of the commits of the repository at path made since since,
+	// every commit when since is nil. the first page is at the empty cursor, the next ones at the Next cursor of the page before
+	ListCommits(ctx context.Context, path string, since *time.Time, cursor string) (CommitPage, error)
+	// ParseWebhook verifies that a webhook delivery is signed with secret and returns its event
+	ParseWebhook(r *http.Request, secret string) (WebhookEvent, error)
+}
+
+// OwnerLister is implemented by the providers which list the repositories of an organization or user
+type OwnerLister interface {
+	// ListOwnerRepositories returns the page at cursor of the repositories owned by the organization or user login
+	ListOwnerRepositories(ctx context.Context, kind repository.OwnerKind, login, cursor string) (OwnerRepositoryPage, error)
+}
+
+// ReadWebhook returns the body of a webhook delivery
+func ReadWebhook(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook: %w", err)
+	}
+	if len(body) > maxWebhookBytes {
+		return nil, fmt.Errorf("%w, it is larger than %d bytes", ErrWebhookTooLarge, maxWebhookBytes)
+	}
+
+	return body, nil
+}
+
+// ValidSignature reports whether signature is the hex encoded HMAC-SHA256 of body with secret
+func ValidSignature(secret string, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// PageNumber returns the page number of a cursor of a provider paged by number, 1 for the empty cursor
+func PageNumber(cursor string) (int, error) {
+	if cursor == "" {
+		return 1, nil
+	}
+
+	page, err := strconv.Atoi(cursor)
+	if err != nil || page < 1 {
+		return 0, fmt.Errorf("invalid page cursor %q", cursor)
+	}
+	return page, nil
+}
+
+// NextPage returns the cursor of the page after page when more is true, the empty cursor otherwise
+func NextPage(page int, more bool) string {
+	if !more {
+		return ""
+	}
+	return strconv.Itoa(page + 1)
+}
+
+// Since formats the oldest commit time of a commit listing for provider apis
+func Since(since time.Time) string {
+	return since.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -timeout 30s -run ^TestValidSignature$ ./pkg/provider -v
+func TestValidSignature(t *testing.T) {
+	assert := assert.New(t)
+
+	// example of the github webhook documentation
+	secret, body := "It's a Secret to Everybody", []byte("Hello, World!")
+	signature := "757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	assert.True(ValidSignature(secret, body, signature))
+	assert.False(ValidSignature("other", body, signature))
+	assert.False(ValidSignature(secret, []byte("Hello, World?"), signature))
+	assert.False(ValidSignature(secret, body, "not hex"))
+	assert.False(ValidSignature(secret, body, ""))
+}
+
+// go test -timeout 30s -run ^TestPageNumber$ ./pkg/provider -v
+func TestPageNumber(t *testing.T) {
+	assert := assert.New(t)
+
+	page, err := PageNumber("")
+	assert.NoError(err)
+	assert.Equal(1, page)
+
+	page, err = PageNumber(NextPage(page, true))
+	assert.NoError(err)
+	assert.Equal(2, page)
+	assert.Empty(NextPage(page, false))
+
+	for _, cursor := range []string{"0", "-1", "Y3Vyc29y"} {
+		_, err = PageNumber(cursor)
+		assert.Error(err, cursor)
+	}
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrNotFound represents no record found in the datastore
+	ErrNotFound = errors.New("record not found")
+
+	// ErrConflict represents a record that already exists in the datastore
+	ErrConflict = errors.New("record already exists")
+)
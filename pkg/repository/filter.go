@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// MaxFilterLength limits the length of the free text filters of a CommitFilter
+const MaxFilterLength = 255
+
+// DateFormat represents dates without a time, they are interpreted as midnight UTC except by ParseUntil
+const DateFormat = "2006-01-02"
+
+// ErrInvalidDate is returned by ParseDate and ParseUntil for values that are not ISO 8601 dates or date times
+var ErrInvalidDate = errors.New("must be an ISO 8601 date, e.g. 2024-01-31 or 2024-01-31T15:04:05Z")
+
+// SortOrder represents the order commits are listed in by date
+type SortOrder string
+
+const (
+	// SortNewest lists the most recent commits first
+	SortNewest SortOrder = "desc"
+	// SortOldest lists the oldest commits first
+	SortOldest SortOrder = "asc"
+)
+
+// ParseDate parses an ISO 8601 date or date time of a commit filter in UTC
+func ParseDate(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, DateFormat} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, ErrInvalidDate
+}
+
+// ParseUntil parses the until date or date time of a commit filter in UTC, a date without a time matches the whole day.
+// the day ends a microsecond before midnight as postgres rounds timestamps to microseconds
+func ParseUntil(s string) (time.Time, error) {
+	if t, err := time.Parse(DateFormat, s); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Microsecond), nil
+	}
+
+	return ParseDate(s)
+}
+
+// CommitFilter represents the filters applied when listing commits, the zero value matches every commit
+type CommitFilter struct {
+	// Author matches the author name, email or github login ignoring case
+	Author string
+	// Since matches commits on or after the time
+	Since *time.Time
+	// Until matches commits on or before the time
+	Until *time.Time
+	// Path matches commits that changed the file or a file in the directory,
+	// only commits saved with their changed files can match
+	Path string
+	// ExcludeMerges skips commits with more than one parent
+	ExcludeMerges bool
+	// Message matches commit messages containing the text ignoring case
+	Message string
+	// Sort orders the commits by date, newest first by default
+	Sort SortOrder
+}
+
+// Normalize validates the filter and puts it in the form stores expect: the free text filters are trimmed,
+// the path is relative to the repository root and the sort order defaults to newest first
+func (f *CommitFilter) Normalize() error {
+	f.Author = strings.TrimSpace(f.Author)
+	if len(f.Author) > MaxFilterLength {
+		return fmt.Errorf("author must not be longer than %d characters", MaxFilterLength)
+	}
+
+	f.Message = strings.TrimSpace(f.Message)
+	if len(f.Message) > MaxFilterLength {
+		return fmt.Errorf("message must not be longer than %d characters", MaxFilterLength)
+	}
+
+	if f.Since != nil && f.Until != nil && f.Since.After(*f.Until) {
+		return errors.New("since must not be after until")
+	}
+
+	if p := strings.TrimSpace(f.Path); p != "" {
+		p = path.Clean(strings.TrimPrefix(p, "/"))
+		if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+			return errors.New("path must be a file or directory in the repository")
+		}
+		f.Path = p
+	}
+
+	switch sort := SortOrder(strings.ToLower(string(f.Sort))); sort {
+	case "":
+		f.Sort = SortNewest
+	case SortNewest, SortOldest:
+		f.Sort = sort
+	default:
+		return fmt.Errorf("sort must be one of: %s, %s", SortNewest, SortOldest)
+	}
+
+	return nil
+}
+
+// Ascending reports whether commits are sorted oldest first
+func (f CommitFilter) Ascending() bool {
+	return f.Sort == SortOldest
+}
+
+// Matches reports whether commit matches every filter, it is used by stores that filter in memory
+func (f CommitFilter) Matches(commit GithubCommit) bool {
+	if f.Author != "" &&
+		!strings.EqualFold(commit.AuthorName, f.Author) &&
+		!strings.EqualFold(commit.AuthorEmail, f.Author) &&
+		!strings.EqualFold(commit.AuthorLogin, f.Author) {
+		return false
+	}
+	if f.Since != nil && commit.Date.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && commit.Date.After(*f.Until) {
+		return false
+	}
+	if f.ExcludeMerges && commit.IsMerge() {
+		return false
+	}
+	if f.Message != "" && !strings.Contains(strings.ToLower(commit.Message), strings.ToLower(f.Message)) {
+		return false
+	}
+	if f.Path != "" {
+		for _, file := range commit.Files {
+			if MatchesPath(file, f.Path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// MatchesPath reports whether file is filter or is in the directory filter
+func MatchesPath(file, filter string) bool {
+	filter = strings.TrimSuffix(path.Clean(filter), "/")
+	return file == filter || strings.HasPrefix(file, filter+"/")
+}
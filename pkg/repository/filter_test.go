@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -timeout 30s -run ^TestCommitFilterNormalize$ ./pkg/repository -v
+func TestCommitFilterNormalize(t *testing.T) {
+	assert := assert.New(t)
+
+	may1 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	may2 := may1.AddDate(0, 0, 1)
+	testCases := []struct {
+		name   string
+		filter CommitFilter
+		want   CommitFilter
+		err    string
+	}{
+		{name: "zero value", want: CommitFilter{Sort: SortNewest}},
+		{
+			name:   "trimmed",
+			filter: CommitFilter{Author: " alice ", Message: " fix ", Sort: "ASC"},
+			want:   CommitFilter{Author: "alice", Message: "fix", Sort: SortOldest},
+		},
+		{name: "absolute path", filter: CommitFilter{Path: "/pkg/"}, want: CommitFilter{Path: "pkg", Sort: SortNewest}},
+		{name: "relative path", filter: CommitFilter{Path: "./pkg/db/../api"}, want: CommitFilter{Path: "pkg/api", Sort: SortNewest}},
+		{name: "repository root", filter: CommitFilter{Path: "/"}, err: "path must be a file or directory in the repository"},
+		{name: "path outside repo", filter: CommitFilter{Path: "../etc"}, err: "path must be a file or directory in the repository"},
+		{name: "since after until", filter: CommitFilter{Since: &may2, Until: &may1}, err: "since must not be after until"},
+		{name: "invalid sort", filter: CommitFilter{Sort: "random"}, err: "sort must be one of: desc, asc"},
+	}
+	for _, tc := range testCases {
+		err := tc.filter.Normalize()
+		if tc.err != "" {
+			assert.EqualError(err, tc.err, tc.name)
+			continue
+		}
+		assert.NoError(err, tc.name)
+		assert.Equal(tc.want, tc.filter, tc.name)
+	}
+}
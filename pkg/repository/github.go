@@ -1,11 +1,41 @@
 package repository
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// FetchMode represents the api the commits and metadata of a github repository are fetched with
+type FetchMode string
+
+const (
+	// FetchModeREST fetches with the rest api of the provider of a repository, it is the default of every repository
+	FetchModeREST FetchMode = "rest"
+	// FetchModeGraphQL fetches commits with their stats and pull requests in batched queries of the github graphql api
+	FetchModeGraphQL FetchMode = "graphql"
+)
+
+// ParseFetchMode returns the fetch mode named s for a repository of provider p
+func ParseFetchMode(s string, p Provider) (FetchMode, error) {
+	switch mode := FetchMode(s); mode {
+	case FetchModeREST:
+		return mode, nil
+	case FetchModeGraphQL:
+		if p != ProviderGithub {
+			return "", fmt.Errorf("fetch mode %s is only supported for github repositories", mode)
+		}
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown fetch mode %q, must be one of: rest, graphql", s)
+	}
+}
 
 // GithubRepository represents github repository
 type GithubRepository struct {
 	ID                   string     `json:"id"`
-	RepositoryName       string     `json:"repository_name"` // repository_name is of format {owner}/{repo}
+	RepositoryName       string     `json:"repository_name"` // repository_name is of format {owner}/{repo}, prefixed with {provider}: outside github
+	Provider             Provider   `json:"provider"`
+	FetchMode            FetchMode  `json:"fetch_mode"`
 	Description          *string    `json:"description"`
 	URL                  *string    `json:"url"`
 	Language             *string    `json:"language"`
@@ -16,18 +46,40 @@ type GithubRepository struct {
 	CommitLastPulledTime *time.Time `json:"commit_last_pulled_time"`
 	CreatedAt            time.Time  `json:"created_at"`
 	UpdatedAt            *time.Time `json:"updated_at"`
+	OwnerID              *string    `json:"owner_id"`   // owner_id is the id of the owner subscription the repository is tracked by
+	DeletedAt            *time.Time `json:"deleted_at"` // deleted_at is set once the repository is no longer listed for its owner subscription
 }
 
 // GithubCommit represents git commit
 type GithubCommit struct {
-	ID           string    `json:"id,omitempty"`
-	RepositoryID string    `json:"repository_id,omitempty"`
-	CommitHash   string    `json:"commit_hash"`
-	Message      string    `json:"message"`
-	AuthorName   string    `json:"author_name"`
-	AuthorEmail  string    `json:"author_email"`
-	Date         time.Time `json:"date"`
-	URL          string    `json:"url"`
+	ID             string       `json:"id,omitempty"`
+	RepositoryID   string       `json:"repository_id,omitempty"`
+	CommitHash     string       `json:"commit_hash"`
+	Message        string       `json:"message"`
+	AuthorName     string       `json:"author_name"`
+	AuthorEmail    string       `json:"author_email"`
+	AuthorLogin    string       `json:"author_login,omitempty"` // author_login is the github username when the author email is linked to an account
+	Date           time.Time    `json:"date"`
+	CommitterName  string       `json:"committer_name,omitempty"`
+	CommitterEmail string       `json:"committer_email,omitempty"`
+	CommitterDate  *time.Time   `json:"committer_date,omitempty"`
+	URL            string       `json:"url"`
+	Parents        []string     `json:"parents,omitempty"`
+	Files          []string     `json:"files,omitempty"`         // files is only saved by sources that load changed files and not returned when listing commits
+	Stats          *CommitDelta `json:"stats,omitempty"`         // stats is only saved by sources that load diffs
+	PullRequests   []int        `json:"pull_requests,omitempty"` // pull_requests are the numbers of the pull requests of the commit, only saved by the github graphql api
+}
+
+// CommitDelta represents the lines and files changed by a commit
+type CommitDelta struct {
+	Additions    int `json:"additions"`
+	Deletions    int `json:"deletions"`
+	FilesChanged int `json:"files_changed"`
+}
+
+// IsMerge reports whether the commit has more than one parent
+func (c GithubCommit) IsMerge() bool {
+	return len(c.Parents) > 1
 }
 
 // CommitStats represents leaderboard stat
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Provider represents the git hosting service a repository is synced from
+type Provider string
+
+const (
+	// ProviderGithub is the default provider of repository names without a prefix
+	ProviderGithub Provider = "github"
+	// ProviderGitlab repositories may be nested in subgroups, e.g. gitlab:group/subgroup/project
+	ProviderGitlab Provider = "gitlab"
+	// ProviderGitea repositories are named gitea:owner/name
+	ProviderGitea Provider = "gitea"
+	// ProviderBitbucket repositories are named bitbucket:workspace/slug
+	ProviderBitbucket Provider = "bitbucket"
+)
+
+// Providers lists every supported provider
+var Providers = []Provider{ProviderGithub, ProviderGitlab, ProviderGitea, ProviderBitbucket}
+
+// ParseProvider returns the provider named s
+func ParseProvider(s string) (Provider, error) {
+	for _, p := range Providers {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+
+	names := make([]string, len(Providers))
+	for i, p := range Providers {
+		names[i] = string(p)
+	}
+	return "", fmt.Errorf("unknown provider %q, must be one of: %s", s, strings.Join(names, ", "))
+}
+
+// RepoName represents a repository identifier, {provider}:{path} or just {owner}/{name} for github repositories
+type RepoName struct {
+	Provider Provider
+	// Path is the path of the repository on its provider, e.g. owner/name or group/subgroup/project on gitlab
+	Path string
+}
+
+// ParseRepoName parses a repository identifier, names without a provider prefix are github repositories.
+// for tracking in the context of dynamic owner repos requiring full repo name with owner prefix is necessary
+// as repo names are only unique per owner not in the whole provider
+func ParseRepoName(s string) (RepoName, error) {
+	name := RepoName{Provider: ProviderGithub, Path: s}
+	if prefix, path, ok := strings.Cut(s, ":"); ok {
+		provider, err := ParseProvider(prefix)
+		if err != nil {
+			return name, err
+		}
+		name = RepoName{Provider: provider, Path: path}
+	}
+
+	parts := strings.Split(name.Path, "/")
+	switch name.Provider {
+	case ProviderGitlab:
+		if len(parts) < 2 {
+			return name, fmt.Errorf("gitlab repository names must be in the format 'gitlab:group/project' or 'gitlab:group/subgroup/project'")
+		}
+	case ProviderGitea:
+		if len(parts) != 2 {
+			return name, fmt.Errorf("gitea repository names must be in the format 'gitea:owner/name'")
+		}
+	case ProviderBitbucket:
+		if len(parts) != 2 {
+			return name, fmt.Errorf("bitbucket repository names must be in the format 'bitbucket:workspace/slug'")
+		}
+	default:
+		if len(parts) != 2 {
+			return name, fmt.Errorf("repository name must be in the format 'owner/name'")
+		}
+	}
+
+	for _, part := range parts {
+		if part == "" {
+			return name, fmt.Errorf("both owner and repository name must be non-empty")
+		}
+	}
+
+	return name, nil
+}
+
+// String returns the identifier repositories are tracked by, github repositories have no provider prefix
+func (n RepoName) String() string {
+	if n.Provider == ProviderGithub || n.Provider == "" {
+		return n.Path
+	}
+
+	return string(n.Provider) + ":" + n.Path
+}
+
+// ProviderOf returns the provider of a tracked repository name, github when it has no known provider prefix
+func ProviderOf(repoName string) Provider {
+	if prefix, _, ok := strings.Cut(repoName, ":"); ok {
+		if provider, err := ParseProvider(prefix); err == nil {
+			return provider
+		}
+	}
+
+	return ProviderGithub
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -timeout 30s -run ^TestParseRepoName$ ./pkg/repository -v
+func TestParseRepoName(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		name     string
+		provider Provider
+		path     string
+		canon    string
+		err      string
+	}{
+		{name: "owner/name", provider: ProviderGithub, path: "owner/name", canon: "owner/name"},
+		{name: "github:owner/name", provider: ProviderGithub, path: "owner/name", canon: "owner/name"},
+		{name: "gitlab:group/project", provider: ProviderGitlab, path: "group/project", canon: "gitlab:group/project"},
+		{name: "gitlab:group/subgroup/project", provider: ProviderGitlab, path: "group/subgroup/project", canon: "gitlab:group/subgroup/project"},
+		{name: "gitea:owner/name", provider: ProviderGitea, path: "owner/name", canon: "gitea:owner/name"},
+		{name: "bitbucket:workspace/slug", provider: ProviderBitbucket, path: "workspace/slug", canon: "bitbucket:workspace/slug"},
+		{name: "owner", err: "repository name must be in the format 'owner/name'"},
+		{name: "owner/group/name", err: "repository name must be in the format 'owner/name'"},
+		{name: "owner/", err: "both owner and repository name must be non-empty"},
+		{name: "gitlab:project", err: "gitlab repository names must be in the format 'gitlab:group/project' or 'gitlab:group/subgroup/project'"},
+		{name: "gitlab:group//project", err: "both owner and repository name must be non-empty"},
+		{name: "gitea:group/subgroup/name", err: "gitea repository names must be in the format 'gitea:owner/name'"},
+		{name: "bitbucket:workspace/project/slug", err: "bitbucket repository names must be in the format 'bitbucket:workspace/slug'"},
+		{name: "sourcehut:owner/name", err: `unknown provider "sourcehut", must be one of: github, gitlab, gitea, bitbucket`},
+	}
+	for _, tc := range testCases {
+		name, err := ParseRepoName(tc.name)
+		if tc.err != "" {
+			assert.EqualError(err, tc.err, tc.name)
+			continue
+		}
+		assert.NoError(err, tc.name)
+		assert.Equal(tc.provider, name.Provider, tc.name)
+		assert.Equal(tc.path, name.Path, tc.name)
+		assert.Equal(tc.canon, name.String(), tc.name)
+		assert.Equal(tc.provider, ProviderOf(name.String()), tc.name)
+	}
+}
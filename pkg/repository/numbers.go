@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+)
+
+// JoinNumbers formats numbers as a space separated column, e.g. the pull requests of a commit
+func JoinNumbers(numbers []int) string {
+	fields := make([]string, len(numbers))
+	for i, n := range numbers {
+		fields[i] = strconv.Itoa(n)
+	}
+	return strings.Join(fields, " ")
+}
+
+// SplitNumbers parses a column written by JoinNumbers, fields which are not numbers are skipped
+func SplitNumbers(s string) []int {
+	var numbers []int
+	for _, field := range strings.Fields(s) {
+		if n, err := strconv.Atoi(field); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
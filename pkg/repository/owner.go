@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// OwnerKind represents whether an owner subscription lists the repositories of a github organization or user
+type OwnerKind string
+
+const (
+	// OwnerKindOrg subscriptions are named org:{login}
+	OwnerKindOrg OwnerKind = "org"
+	// OwnerKindUser subscriptions are named user:{login}, only the repositories owned by the user are listed
+	OwnerKindUser OwnerKind = "user"
+)
+
+// OwnerName represents an owner subscription identifier of format {kind}:{login}, e.g. org:myorg or user:alice
+type OwnerName struct {
+	Kind  OwnerKind
+	Login string
+}
+
+// ParseOwnerName parses an owner subscription identifier
+func ParseOwnerName(s string) (OwnerName, error) {
+	kind, login, ok := strings.Cut(s, ":")
+	if !ok {
+		return OwnerName{}, fmt.Errorf("owner name must be in the format 'org:login' or 'user:login'")
+	}
+	name := OwnerName{Kind: OwnerKind(kind), Login: login}
+	if name.Kind != OwnerKindOrg && name.Kind != OwnerKindUser {
+		return name, fmt.Errorf("unknown owner kind %q, must be one of: org, user", kind)
+	}
+	if login == "" || strings.Contains(login, "/") {
+		return name, fmt.Errorf("owner login must be non-empty and must not contain '/'")
+	}
+
+	return name, nil
+}
+
+// String returns the identifier owner subscriptions are stored by
+func (n OwnerName) String() string {
+	return string(n.Kind) + ":" + n.Login
+}
+
+// OwnerSubscription represents a github organization or user whose repositories are tracked,
+// repositories matching the subscription are tracked and the ones no longer listed are marked as deleted
+type OwnerSubscription struct {
+	ID   string    `json:"id"`
+	Name string    `json:"name"` // name is of format {kind}:{login}
+	Kind OwnerKind `json:"kind"`
+	// Include and Exclude are globs of the names of the repositories without their owner, e.g. api-*.
+	// every repository is included when Include is empty
+	Include      []string   `json:"include"`
+	Exclude      []string   `json:"exclude"`
+	SkipForks    bool       `json:"skip_forks"`
+	SkipArchived bool       `json:"skip_archived"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ValidateGlobs returns an error for the first malformed glob of patterns
+func ValidateGlobs(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("glob patterns must be non-empty")
+		}
+		if strings.ContainsAny(pattern, " \t\n") {
+			return fmt.Errorf("glob pattern %q must not contain whitespace", pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether the repository called name of the owner is tracked by the subscription
+func (o OwnerSubscription) Matches(name string, fork, archived bool) bool {
+	if (o.SkipForks && fork) || (o.SkipArchived && archived) {
+		return false
+	}
+	if len(o.Include) > 0 && !matchAny(o.Include, name) {
+		return false
+	}
+
+	return !matchAny(o.Exclude, name)
+}
+
+// matchAny reports whether name matches one of the globs of patterns, malformed globs match nothing
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnerStats represents the stats of the repositories of an owner subscription, deleted repositories are only counted
+type OwnerStats struct {
+	Repositories        int           `json:"repositories"`
+	DeletedRepositories int           `json:"deleted_repositories"`
+	StarsCount          int           `json:"stars_count"`
+	ForksCount          int           `json:"forks_count"`
+	OpenIssuesCount     int           `json:"open_issues_count"`
+	WatchersCount       int           `json:"watchers_count"`
+	Commits             int           `json:"commits"`
+	Authors             int           `json:"authors"`
+	TopAuthors          []CommitStats `json:"top_authors"`
+}
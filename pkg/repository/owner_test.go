@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -timeout 30s -run ^TestParseOwnerName$ ./pkg/repository -v
+func TestParseOwnerName(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		name  string
+		kind  OwnerKind
+		login string
+		err   string
+	}{
+		{name: "org:myorg", kind: OwnerKindOrg, login: "myorg"},
+		{name: "user:alice", kind: OwnerKindUser, login: "alice"},
+		{name: "myorg", err: "owner name must be in the format 'org:login' or 'user:login'"},
+		{name: "team:myorg", err: `unknown owner kind "team", must be one of: org, user`},
+		{name: "org:", err: "owner login must be non-empty and must not contain '/'"},
+		{name: "org:myorg/repo", err: "owner login must be non-empty and must not contain '/'"},
+	}
+	for _, tc := range testCases {
+		name, err := ParseOwnerName(tc.name)
+		if tc.err != "" {
+			assert.EqualError(err, tc.err, tc.name)
+			continue
+		}
+		assert.NoError(err, tc.name)
+		assert.Equal(tc.kind, name.Kind, tc.name)
+		assert.Equal(tc.login, name.Login, tc.name)
+		assert.Equal(tc.name, name.String(), tc.name)
+	}
+}
+
+// go test -timeout 30s -run ^TestOwnerSubscriptionMatches$ ./pkg/repository -v
+func TestOwnerSubscriptionMatches(t *testing.T) {
+	assert := assert.New(t)
+
+	all := OwnerSubscription{}
+	assert.True(all.Matches("api", false, false))
+	assert.True(all.Matches("fork", true, true))
+
+	sub := OwnerSubscription{
+		Include:      []string{"api-*", "web"},
+		Exclude:      []string{"*-legacy"},
+		SkipForks:    true,
+		SkipArchived: true,
+	}
+	assert.True(sub.Matches("api-gateway", false, false))
+	assert.True(sub.Matches("web", false, false))
+	assert.False(sub.Matches("docs", false, false), "not included")
+	assert.False(sub.Matches("api-legacy", false, false), "excluded")
+	assert.False(sub.Matches("api-fork", true, false), "fork")
+	assert.False(sub.Matches("api-old", false, true), "archived")
+
+	assert.NoError(ValidateGlobs([]string{"api-*", "[a-c]?"}))
+	assert.Error(ValidateGlobs([]string{"[api"}))
+	assert.Error(ValidateGlobs([]string{""}))
+	assert.Error(ValidateGlobs([]string{"api *"}))
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Page represents the page of commits to list.
+// Cursor takes precedence over Offset when it is set
+type Page struct {
+	Limit  int
+	Offset int
+	Cursor *Cursor
+}
+
+// Cursor represents a position in a list of commits ordered by (commit_date, commit_hash)
+type Cursor struct {
+	Date time.Time
+	Hash string
+	// Before selects the commits before the position instead of after it
+	Before bool
+}
+
+// cursorToken represents the encoded form of Cursor
+type cursorToken struct {
+	Date   time.Time `json:"d"`
+	Hash   string    `json:"h"`
+	Before bool      `json:"b,omitempty"`
+}
+
+// NewCursor returns a cursor positioned at commit
+func NewCursor(commit *GithubCommit, before bool) *Cursor {
+	return &Cursor{Date: commit.Date, Hash: commit.CommitHash, Before: before}
+}
+
+// Encode returns the opaque token of the cursor
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(cursorToken{Date: c.Date.UTC(), Hash: c.Hash, Before: c.Before})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a token returned by Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var t cursorToken
+	if err := json.Unmarshal(b, &t); err != nil || t.Hash == "" || t.Date.IsZero() {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &Cursor{Date: t.Date, Hash: t.Hash, Before: t.Before}, nil
+}
+
+// Descending reports whether commits are read newest first. pages before a cursor are read
+// in the reverse of the listing order, stores reverse them back before returning them
+func (p Page) Descending(filter CommitFilter) bool {
+	descending := !filter.Ascending()
+	if p.Cursor != nil && p.Cursor.Before {
+		return !descending
+	}
+
+	return descending
+}
+
+// Reversed reports whether commits read for the page must be reversed to be in listing order
+func (p Page) Reversed() bool {
+	return p.Cursor != nil && p.Cursor.Before
+}
+
+// CommitPage represents a page of commits with the cursors of its neighbouring pages
+type CommitPage struct {
+	Commits []*GithubCommit
+	// Next is nil on the last page
+	Next *Cursor
+	// Prev is nil on the first page
+	Prev *Cursor
+	// Total is the number of commits matching the filter across all pages
+	Total int
+	// LastSyncedAt is when commits of the repository were last pulled, nil until the first sync
+	LastSyncedAt *time.Time
+}
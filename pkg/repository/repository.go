@@ -12,7 +12,24 @@ type Repository interface {
 	CreateRepository(ctx context.Context, repoName string) (string, error)
 	UpdateRepository(ctx context.Context, repo *GithubRepository) error
 	UpdateCommitLastSyncTime(ctx context.Context, repoID string, syncTime time.Time) error
+	UpdateFetchMode(ctx context.Context, repoID string, mode FetchMode) error
 	SaveCommit(ctx context.Context, commit GithubCommit) error
-	GetCommitsByRepository(ctx context.Context, repoID string, limit, offset int) ([]*GithubCommit, error)
+	GetCommitsByRepository(ctx context.Context, repoID string, filter CommitFilter, page Page) ([]*GithubCommit, error)
+	CountCommitsByRepository(ctx context.Context, repoID string, filter CommitFilter) (int, error)
+	// StreamCommitsByRepository calls fn with every commit matching filter in the order of filter without loading them all at once,
+	// it stops at the first error of fn and returns it
+	StreamCommitsByRepository(ctx context.Context, repoID string, filter CommitFilter, fn func(*GithubCommit) error) error
 	GetLeaderBoard(ctx context.Context, limit int) ([]CommitStats, error)
+	SearchCommits(ctx context.Context, search CommitSearch) ([]*CommitSearchResult, error)
+	CreateOwner(ctx context.Context, owner OwnerSubscription) (string, error)
+	GetOwners(ctx context.Context) ([]*OwnerSubscription, error)
+	GetOwnerByName(ctx context.Context, name string) (OwnerSubscription, error)
+	UpdateOwnerLastSyncTime(ctx context.Context, ownerID string, syncTime time.Time) error
+	// GetRepositoriesByOwner returns the repositories of an owner subscription including the deleted ones
+	GetRepositoriesByOwner(ctx context.Context, ownerID string) ([]*GithubRepository, error)
+	UpdateRepositoryOwner(ctx context.Context, repoID, ownerID string) error
+	// UpdateRepositoryDeletedAt marks a repository as deleted, it is restored when deletedAt is nil
+	UpdateRepositoryDeletedAt(ctx context.Context, repoID string, deletedAt *time.Time) error
+	// GetOwnerStats aggregates the repositories of an owner subscription which are not deleted along with their limit top authors
+	GetOwnerStats(ctx context.Context, ownerID string, limit int) (OwnerStats, error)
 }
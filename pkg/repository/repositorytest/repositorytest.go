@@ -0,0 +1,740 @@
+// Package repositorytest provides a conformance test suite that every
+// repository.Repository implementation must pass
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns a new empty repository.Repository for a single test
+type Factory func(t *testing.T) repository.Repository
+
+// Run runs the conformance suite against the implementation returned by newRepo
+func Run(t *testing.T, newRepo Factory) {
+	tests := map[string]func(t *testing.T, repo repository.Repository){
+		"RepositoryNotFound":     testRepositoryNotFound,
+		"CreateRepository":       testCreateRepository,
+		"DuplicateRepository":    testDuplicateRepository,
+		"GetRepositories":        testGetRepositories,
+		"UpdateRepository":       testUpdateRepository,
+		"UpdateCommitLastSync":   testUpdateCommitLastSyncTime,
+		"UpdateFetchMode":        testUpdateFetchMode,
+		"SaveCommitDuplicate":    testSaveCommitDuplicate,
+		"SaveCommitUnknownRepo":  testSaveCommitUnknownRepository,
+		"GetCommitsByRepository": testGetCommitsByRepository,
+		"FilterCommits":          testFilterCommits,
+		"PageCommitsByCursor":    testPageCommitsByCursor,
+		"StreamCommits":          testStreamCommits,
+		"GetLeaderBoard":         testGetLeaderBoard,
+		"SearchCommits":          testSearchCommits,
+		"Owners":                 testOwners,
+		"RepositoriesByOwner":    testRepositoriesByOwner,
+		"OwnerStats":             testOwnerStats,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+// baseTime is truncated to microseconds as that is the precision of postgres timestamps
+var baseTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func createRepository(t *testing.T, repo repository.Repository, name string) string {
+	repoID, err := repo.CreateRepository(context.Background(), name)
+	require.NoError(t, err)
+	require.NotEmpty(t, repoID)
+
+	return repoID
+}
+
+func saveCommitMessage(t *testing.T, repo repository.Repository, repoID, hash, message string, date time.Time) {
+	err := repo.SaveCommit(context.Background(), repository.GithubCommit{
+		RepositoryID: repoID,
+		CommitHash:   hash,
+		Message:      message,
+		AuthorName:   "user1",
+		AuthorEmail:  "user1@example.com",
+		Date:         date,
+		URL:          "https://github.com/owner/name/commit/" + hash,
+	})
+	require.NoError(t, err)
+}
+
+func saveCommit(t *testing.T, repo repository.Repository, repoID, hash, author string, date time.Time) {
+	err := repo.SaveCommit(context.Background(), repository.GithubCommit{
+		RepositoryID: repoID,
+		CommitHash:   hash,
+		Message:      "message " + hash,
+		AuthorName:   author,
+		AuthorEmail:  author + "@example.com",
+		Date:         date,
+		URL:          "https://github.com/owner/name/commit/" + hash,
+	})
+	require.NoError(t, err)
+}
+
+func testRepositoryNotFound(t *testing.T, repo repository.Repository) {
+	_, err := repo.GetRepositoryByName(context.Background(), "owner/missing")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testCreateRepository(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	repoID := createRepository(t, repo, "owner/name")
+
+	got, err := repo.GetRepositoryByName(context.Background(), "owner/name")
+	require.NoError(err)
+	assert.Equal(repoID, got.ID)
+	assert.Equal("owner/name", got.RepositoryName)
+	assert.Equal(repository.ProviderGithub, got.Provider)
+	assert.Equal(repository.FetchModeREST, got.FetchMode)
+	assert.Nil(got.CommitLastPulledTime)
+	assert.Nil(got.UpdatedAt)
+	assert.False(got.CreatedAt.IsZero())
+
+	// the provider is taken from the prefix of the name
+	createRepository(t, repo, "gitlab:group/subgroup/project")
+	got, err = repo.GetRepositoryByName(context.Background(), "gitlab:group/subgroup/project")
+	require.NoError(err)
+	assert.Equal(repository.ProviderGitlab, got.Provider)
+}
+
+func testDuplicateRepository(t *testing.T, repo repository.Repository) {
+	createRepository(t, repo, "owner/name")
+
+	_, err := repo.CreateRepository(context.Background(), "owner/name")
+	assert.ErrorIs(t, err, repository.ErrConflict)
+}
+
+func testGetRepositories(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+
+	repos, err := repo.GetRepositories(context.Background())
+	require.NoError(err)
+	require.Empty(repos)
+
+	createRepository(t, repo, "owner/one")
+	createRepository(t, repo, "owner/two")
+
+	repos, err = repo.GetRepositories(context.Background())
+	require.NoError(err)
+	require.Len(repos, 2)
+
+	names := []string{repos[0].RepositoryName, repos[1].RepositoryName}
+	assert.ElementsMatch(t, []string{"owner/one", "owner/two"}, names)
+	assert.Equal(t, repository.ProviderGithub, repos[0].Provider)
+}
+
+func testUpdateRepository(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	repoID := createRepository(t, repo, "owner/name")
+	description, url, language := "a repo", "https://github.com/owner/name", "Go"
+
+	err := repo.UpdateRepository(context.Background(), &repository.GithubRepository{
+		ID:              repoID,
+		RepositoryName:  "owner/name",
+		Description:     &description,
+		URL:             &url,
+		Language:        &language,
+		ForksCount:      1,
+		StarsCount:      2,
+		OpenIssuesCount: 3,
+		WatchersCount:   4,
+	})
+	require.NoError(err)
+
+	got, err := repo.GetRepositoryByName(context.Background(), "owner/name")
+	require.NoError(err)
+	require.NotNil(got.Description)
+	assert.Equal(description, *got.Description)
+	require.NotNil(got.URL)
+	assert.Equal(url, *got.URL)
+	require.NotNil(got.Language)
+	assert.Equal(language, *got.Language)
+	assert.Equal(1, got.ForksCount)
+	assert.Equal(2, got.StarsCount)
+	assert.Equal(3, got.OpenIssuesCount)
+	assert.Equal(4, got.WatchersCount)
+	assert.NotNil(got.UpdatedAt)
+}
+
+func testUpdateCommitLastSyncTime(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+
+	repoID := createRepository(t, repo, "owner/name")
+	require.NoError(repo.UpdateCommitLastSyncTime(context.Background(), repoID, baseTime))
+
+	got, err := repo.GetRepositoryByName(context.Background(), "owner/name")
+	require.NoError(err)
+	require.NotNil(got.CommitLastPulledTime)
+	assert.True(t, baseTime.Equal(*got.CommitLastPulledTime), "expected %v, got %v", baseTime, *got.CommitLastPulledTime)
+}
+
+func testUpdateFetchMode(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+
+	repoID := createRepository(t, repo, "owner/name")
+	require.NoError(repo.UpdateFetchMode(context.Background(), repoID, repository.FetchModeGraphQL))
+
+	got, err := repo.GetRepositoryByName(context.Background(), "owner/name")
+	require.NoError(err)
+	assert.Equal(t, repository.FetchModeGraphQL, got.FetchMode)
+
+	repos, err := repo.GetRepositories(context.Background())
+	require.NoError(err)
+	require.Len(repos, 1)
+	assert.Equal(t, repository.FetchModeGraphQL, repos[0].FetchMode)
+}
+
+func testSaveCommitDuplicate(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+
+	repoID := createRepository(t, repo, "owner/name")
+	otherRepoID := createRepository(t, repo, "owner/other")
+
+	saveCommit(t, repo, repoID, "abc", "user1", baseTime)
+	// the same hash is ignored within a repository but allowed across repositories
+	saveCommit(t, repo, repoID, "abc", "user1", baseTime)
+	saveCommit(t, repo, otherRepoID, "abc", "user1", baseTime)
+
+	commits, err := repo.GetCommitsByRepository(context.Background(), repoID, repository.CommitFilter{}, repository.Page{Limit: 10})
+	require.NoError(err)
+	require.Len(commits, 1)
+
+	commits, err = repo.GetCommitsByRepository(context.Background(), otherRepoID, repository.CommitFilter{}, repository.Page{Limit: 10})
+	require.NoError(err)
+	require.Len(commits, 1)
+}
+
+func testSaveCommitUnknownRepository(t *testing.T, repo repository.Repository) {
+	err := repo.SaveCommit(context.Background(), repository.GithubCommit{
+		RepositoryID: "00000000-0000-0000-0000-000000000000",
+		CommitHash:   "abc",
+		Date:         baseTime,
+		URL:          "https://github.com/owner/name/commit/abc",
+	})
+	assert.Error(t, err)
+}
+
+func testGetCommitsByRepository(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	repoID := createRepository(t, repo, "owner/name")
+	otherRepoID := createRepository(t, repo, "owner/other")
+	for i := 0; i < 5; i++ {
+		saveCommit(t, repo, repoID, fmt.Sprintf("hash%d", i), "user1", baseTime.Add(time.Duration(i)*time.Hour))
+	}
+	saveCommit(t, repo, otherRepoID, "other", "user1", baseTime.Add(time.Hour*24))
+
+	commits, err := repo.GetCommitsByRepository(context.Background(), repoID, repository.CommitFilter{}, repository.Page{Limit: 2})
+	require.NoError(err)
+	require.Len(commits, 2)
+	assert.Equal("hash4", commits[0].CommitHash)
+	assert.Equal("hash3", commits[1].CommitHash)
+
+	first := commits[0]
+	assert.Equal("message hash4", first.Message)
+	assert.Equal("user1", first.AuthorName)
+	assert.Equal("user1@example.com", first.AuthorEmail)
+	assert.Equal("https://github.com/owner/name/commit/hash4", first.URL)
+	assert.True(baseTime.Add(4*time.Hour).Equal(first.Date), "unexpected commit date %v", first.Date)
+
+	commits, err = repo.GetCommitsByRepository(context.Background(), repoID, repository.CommitFilter{}, repository.Page{Limit: 10, Offset: 3})
+	require.NoError(err)
+	require.Len(commits, 2)
+	assert.Equal("hash1", commits[0].CommitHash)
+	assert.Equal("hash0", commits[1].CommitHash)
+
+	commits, err = repo.GetCommitsByRepository(context.Background(), repoID, repository.CommitFilter{}, repository.Page{Limit: 10, Offset: 10})
+	require.NoError(err)
+	assert.Empty(commits)
+
+	count, err := repo.CountCommitsByRepository(context.Background(), repoID, repository.CommitFilter{})
+	require.NoError(err)
+	assert.Equal(5, count)
+
+	count, err = repo.CountCommitsByRepository(context.Background(), otherRepoID, repository.CommitFilter{})
+	require.NoError(err)
+	assert.Equal(1, count)
+}
+
+func testPageCommitsByCursor(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	repoID := createRepository(t, repo, "owner/name")
+	// hash1 and hash2 share a date so the hash decides their order
+	saveCommit(t, repo, repoID, "hash0", "user1", baseTime)
+	saveCommit(t, repo, repoID, "hash1", "user1", baseTime.Add(time.Hour))
+	saveCommit(t, repo, repoID, "hash2", "user1", baseTime.Add(time.Hour))
+	saveCommit(t, repo, repoID, "hash3", "user1", baseTime.Add(2*time.Hour))
+
+	list := func(filter repository.CommitFilter, cursor *repository.Cursor) []string {
+		commits, err := repo.GetCommitsByRepository(context.Background(), repoID, filter, repository.Page{Limit: 2, Cursor: cursor})
+		require.NoError(err)
+
+		var hashes []string
+		for _, c := range commits {
+			hashes = append(hashes, c.CommitHash)
+		}
+		return hashes
+	}
+	at := func(hash string, d time.Duration, before bool) *repository.Cursor {
+		return &repository.Cursor{Date: baseTime.Add(d), Hash: hash, Before: before}
+	}
+
+	newest := repository.CommitFilter{}
+	assert.Equal([]string{"hash3", "hash1"}, list(newest, nil))
+	assert.Equal([]string{"hash2", "hash0"}, list(newest, at("hash1", time.Hour, false)))
+	assert.Empty(list(newest, at("hash0", 0, false)))
+	assert.Equal([]string{"hash1", "hash2"}, list(newest, at("hash0", 0, true)))
+	assert.Equal([]string{"hash3", "hash1"}, list(newest, at("hash2", time.Hour, true)))
+
+	oldest := repository.CommitFilter{Sort: repository.SortOldest}
+	assert.Equal([]string{"hash0", "hash2"}, list(oldest, nil))
+	assert.Equal([]string{"hash1", "hash3"}, list(oldest, at("hash2", time.Hour, false)))
+	assert.Equal([]string{"hash0", "hash2"}, list(oldest, at("hash1", time.Hour, true)))
+
+	// the cursor takes precedence over the offset
+	commits, err := repo.GetCommitsByRepository(context.Background(), repoID, newest, repository.Page{Limit: 2, Offset: 1, Cursor: at("hash3", 2*time.Hour, false)})
+	require.NoError(err)
+	require.Len(commits, 2)
+	assert.Equal("hash1", commits[0].CommitHash)
+}
+
+func testStreamCommits(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	// more commits than the batches of implementations that stream in pages, pairs share a date so the hash decides their order
+	repoID := createRepository(t, repo, "owner/name")
+	otherRepoID := createRepository(t, repo, "owner/other")
+	const count = 2101
+	for i := 0; i < count; i++ {
+		author := "user1"
+		if i%3 == 0 {
+			author = "user2"
+		}
+		saveCommit(t, repo, repoID, fmt.Sprintf("hash%04d", i), author, baseTime.Add(time.Duration(i/2)*time.Minute))
+	}
+	saveCommit(t, repo, otherRepoID, "other", "user1", baseTime)
+
+	for _, filter := range []repository.CommitFilter{
+		{},
+		{Sort: repository.SortOldest},
+		{Author: "user2", Sort: repository.SortOldest},
+	} {
+		want, err := repo.GetCommitsByRepository(ctx, repoID, filter, repository.Page{Limit: count})
+		require.NoError(err)
+
+		var got []*repository.GithubCommit
+		err = repo.StreamCommitsByRepository(ctx, repoID, filter, func(commit *repository.GithubCommit) error {
+			got = append(got, commit)
+			return nil
+		})
+		require.NoError(err)
+		assert.Equal(want, got, "filter %+v", filter)
+	}
+
+	errStop := errors.New("stop")
+	streamed := 0
+	err := repo.StreamCommitsByRepository(ctx, repoID, repository.CommitFilter{}, func(commit *repository.GithubCommit) error {
+		streamed++
+		if streamed == 3 {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(err, errStop)
+	assert.Equal(3, streamed)
+
+	err = repo.StreamCommitsByRepository(ctx, "00000000-0000-0000-0000-000000000000", repository.CommitFilter{}, func(commit *repository.GithubCommit) error {
+		return errStop
+	})
+	assert.NoError(err, "unknown repositories have no commits")
+}
+
+func testFilterCommits(t *testing.T, repo repository.Repository) {
+	repoID := createRepository(t, repo, "owner/name")
+	committedAt := baseTime.Add(90 * time.Minute)
+	commits := []repository.GithubCommit{
+		{
+			CommitHash: "a", Message: "Initial commit", AuthorName: "Alice", AuthorEmail: "alice@example.com", AuthorLogin: "alice-gh",
+			Date: baseTime, Files: []string{"README.md", "src/main.go"},
+		},
+		{
+			CommitHash: "b", Message: "Fix 100% CPU usage", AuthorName: "Bob", AuthorEmail: "bob@example.com",
+			Date: baseTime.Add(time.Hour), Parents: []string{"a"}, Files: []string{"src/lib/cpu.go"},
+			CommitterName: "Carol", CommitterEmail: "carol@example.com", CommitterDate: &committedAt,
+			Stats: &repository.CommitDelta{Additions: 12, Deletions: 3, FilesChanged: 1}, PullRequests: []int{7, 12},
+		},
+		{
+			CommitHash: "c", Message: "Merge branch fix_cpu", AuthorName: "Alice", AuthorEmail: "alice@example.com", AuthorLogin: "alice-gh",
+			Date: baseTime.Add(2 * time.Hour), Parents: []string{"a", "b"},
+		},
+		{
+			CommitHash: "d", Message: "Update docs", AuthorName: "Carol", AuthorEmail: "carol@example.com",
+			Date: baseTime.Add(3 * time.Hour), Parents: []string{"c"}, Files: []string{"srcdocs/index.md"},
+		},
+	}
+	for _, commit := range commits {
+		commit.RepositoryID = repoID
+		commit.URL = "https://github.com/owner/name/commit/" + commit.CommitHash
+		require.NoError(t, repo.SaveCommit(context.Background(), commit))
+	}
+
+	list := func(t *testing.T, filter repository.CommitFilter) []string {
+		commits, err := repo.GetCommitsByRepository(context.Background(), repoID, filter, repository.Page{Limit: 10})
+		require.NoError(t, err)
+
+		// every filter must be applied the same way when counting
+		count, err := repo.CountCommitsByRepository(context.Background(), repoID, filter)
+		require.NoError(t, err)
+		assert.Equal(t, len(commits), count)
+
+		var hashes []string
+		for _, c := range commits {
+			hashes = append(hashes, c.CommitHash)
+		}
+		return hashes
+	}
+	at := func(d time.Duration) *time.Time {
+		t := baseTime.Add(d)
+		return &t
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		commits, err := repo.GetCommitsByRepository(context.Background(), repoID, repository.CommitFilter{}, repository.Page{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, commits, 4)
+		assert.Equal(t, []string{"a", "b"}, commits[1].Parents)
+		assert.True(t, commits[1].IsMerge())
+		assert.Equal(t, "alice-gh", commits[1].AuthorLogin)
+		assert.Empty(t, commits[0].AuthorLogin)
+
+		// committer and stats are optional
+		assert.Equal(t, "Carol", commits[2].CommitterName)
+		assert.Equal(t, "carol@example.com", commits[2].CommitterEmail)
+		require.NotNil(t, commits[2].CommitterDate)
+		assert.True(t, committedAt.Equal(*commits[2].CommitterDate))
+		assert.Equal(t, &repository.CommitDelta{Additions: 12, Deletions: 3, FilesChanged: 1}, commits[2].Stats)
+		assert.Equal(t, []int{7, 12}, commits[2].PullRequests)
+		assert.Empty(t, commits[0].CommitterName)
+		assert.Nil(t, commits[0].CommitterDate)
+		assert.Nil(t, commits[0].Stats)
+		assert.Empty(t, commits[0].PullRequests)
+	})
+
+	t.Run("author", func(t *testing.T) {
+		assert.Equal(t, []string{"c", "a"}, list(t, repository.CommitFilter{Author: "alice"}))
+		assert.Equal(t, []string{"b"}, list(t, repository.CommitFilter{Author: "BOB@example.com"}))
+		assert.Equal(t, []string{"c", "a"}, list(t, repository.CommitFilter{Author: "alice-gh"}))
+		assert.Empty(t, list(t, repository.CommitFilter{Author: "ali"}))
+	})
+
+	t.Run("date range", func(t *testing.T) {
+		assert.Equal(t, []string{"d", "c"}, list(t, repository.CommitFilter{Since: at(2 * time.Hour)}))
+		assert.Equal(t, []string{"b", "a"}, list(t, repository.CommitFilter{Until: at(time.Hour)}))
+		assert.Equal(t, []string{"c", "b"}, list(t, repository.CommitFilter{Since: at(30 * time.Minute), Until: at(150 * time.Minute)}))
+
+		// an until date without a time matches the whole day
+		until, err := repository.ParseUntil(baseTime.Format(repository.DateFormat))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"d", "c", "b", "a"}, list(t, repository.CommitFilter{Until: &until}))
+		until, err = repository.ParseUntil(baseTime.AddDate(0, 0, -1).Format(repository.DateFormat))
+		require.NoError(t, err)
+		assert.Empty(t, list(t, repository.CommitFilter{Until: &until}))
+	})
+
+	t.Run("exclude merges", func(t *testing.T) {
+		assert.Equal(t, []string{"d", "b", "a"}, list(t, repository.CommitFilter{ExcludeMerges: true}))
+	})
+
+	t.Run("message", func(t *testing.T) {
+		assert.Equal(t, []string{"c", "b"}, list(t, repository.CommitFilter{Message: "CPU"}))
+		// LIKE wildcards are matched literally
+		assert.Equal(t, []string{"b"}, list(t, repository.CommitFilter{Message: "100%"}))
+		assert.Equal(t, []string{"c"}, list(t, repository.CommitFilter{Message: "fix_"}))
+	})
+
+	t.Run("path", func(t *testing.T) {
+		assert.Equal(t, []string{"b", "a"}, list(t, repository.CommitFilter{Path: "src"}))
+		assert.Equal(t, []string{"b"}, list(t, repository.CommitFilter{Path: "src/lib/"}))
+		assert.Equal(t, []string{"a"}, list(t, repository.CommitFilter{Path: "README.md"}))
+		assert.Empty(t, list(t, repository.CommitFilter{Path: "readme.md"}))
+	})
+
+	t.Run("sort", func(t *testing.T) {
+		assert.Equal(t, []string{"a", "b", "c", "d"}, list(t, repository.CommitFilter{Sort: repository.SortOldest}))
+		assert.Equal(t, []string{"a", "c"}, list(t, repository.CommitFilter{Sort: repository.SortOldest, Author: "alice"}))
+	})
+}
+
+func testGetLeaderBoard(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+
+	leaderboard, err := repo.GetLeaderBoard(context.Background(), 5)
+	require.NoError(err)
+	require.Empty(leaderboard)
+
+	repoID := createRepository(t, repo, "owner/name")
+	otherRepoID := createRepository(t, repo, "owner/other")
+	for i := 0; i < 3; i++ {
+		saveCommit(t, repo, repoID, fmt.Sprintf("user1-%d", i), "user1", baseTime)
+	}
+	for i := 0; i < 2; i++ {
+		saveCommit(t, repo, otherRepoID, fmt.Sprintf("user2-%d", i), "user2", baseTime)
+	}
+	saveCommit(t, repo, repoID, "user3-0", "user3", baseTime)
+
+	leaderboard, err = repo.GetLeaderBoard(context.Background(), 2)
+	require.NoError(err)
+	assert.Equal(t, []repository.CommitStats{
+		{AuthorName: "user1", CommitCount: 3},
+		{AuthorName: "user2", CommitCount: 2},
+	}, leaderboard)
+}
+
+func testSearchCommits(t *testing.T, repo repository.Repository) {
+	repoID := createRepository(t, repo, "owner/name")
+	otherRepoID := createRepository(t, repo, "owner/other")
+	saveCommitMessage(t, repo, repoID, "a", "Fix buffer overflow (CVE-2024-1234)", baseTime)
+	saveCommitMessage(t, repo, repoID, "b", "PROJ-42 add parser for config files", baseTime.Add(time.Hour))
+	saveCommitMessage(t, repo, repoID, "c", "parser cleanup", baseTime.Add(2*time.Hour))
+	saveCommitMessage(t, repo, otherRepoID, "d", "Bump parser version to fix CVE-2023-9999", baseTime.Add(3*time.Hour))
+	saveCommitMessage(t, repo, otherRepoID, "e", "overflow check for parser overflow overflow", baseTime.Add(4*time.Hour))
+
+	search := func(t *testing.T, q string, repoIDs ...string) []*repository.CommitSearchResult {
+		query, err := repository.ParseSearchQuery(q)
+		require.NoError(t, err)
+
+		results, err := repo.SearchCommits(context.Background(), repository.CommitSearch{
+			Query:         query,
+			RepositoryIDs: repoIDs,
+			Limit:         10,
+		})
+		require.NoError(t, err)
+		return results
+	}
+	hashes := func(results []*repository.CommitSearchResult) []string {
+		var hashes []string
+		for _, r := range results {
+			hashes = append(hashes, r.CommitHash)
+		}
+		return hashes
+	}
+
+	t.Run("word", func(t *testing.T) {
+		results := search(t, "PARSER")
+		assert.ElementsMatch(t, []string{"b", "c", "d", "e"}, hashes(results))
+	})
+
+	t.Run("all terms must match", func(t *testing.T) {
+		results := search(t, "parser config")
+		assert.Equal(t, []string{"b"}, hashes(results))
+	})
+
+	t.Run("phrase", func(t *testing.T) {
+		assert.Equal(t, []string{"b"}, hashes(search(t, `"add parser"`)))
+		assert.Empty(t, search(t, `"parser add"`))
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		results := search(t, "CVE-2024*")
+		require.Len(t, results, 1)
+		assert.Equal(t, "a", results[0].CommitHash)
+		assert.Equal(t, "owner/name", results[0].RepositoryName)
+		assert.Equal(t, "Fix buffer overflow (CVE-2024-1234)", results[0].Message)
+		assert.Contains(t, results[0].Snippet, "<mark>")
+
+		assert.ElementsMatch(t, []string{"a", "d"}, hashes(search(t, "cve*")))
+	})
+
+	t.Run("jira key", func(t *testing.T) {
+		assert.Equal(t, []string{"b"}, hashes(search(t, "proj-42")))
+	})
+
+	t.Run("repositories", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"d", "e"}, hashes(search(t, "parser", otherRepoID)))
+		assert.ElementsMatch(t, []string{"b", "c", "d", "e"}, hashes(search(t, "parser", repoID, otherRepoID)))
+	})
+
+	t.Run("ranked", func(t *testing.T) {
+		results := search(t, "overflow")
+		assert.Equal(t, []string{"e", "a"}, hashes(results))
+		assert.Greater(t, results[0].Rank, results[1].Rank)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		assert.Empty(t, search(t, "nothing"))
+	})
+}
+
+func createOwner(t *testing.T, repo repository.Repository, name string) string {
+	ownerID, err := repo.CreateOwner(context.Background(), repository.OwnerSubscription{Name: name, Kind: repository.OwnerKindOrg})
+	require.NoError(t, err)
+	require.NotEmpty(t, ownerID)
+
+	return ownerID
+}
+
+func testOwners(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	_, err := repo.GetOwnerByName(context.Background(), "org:missing")
+	assert.ErrorIs(err, repository.ErrNotFound)
+
+	ownerID, err := repo.CreateOwner(context.Background(), repository.OwnerSubscription{
+		Name:         "org:myorg",
+		Kind:         repository.OwnerKindOrg,
+		Include:      []string{"api-*", "web"},
+		Exclude:      []string{"*-legacy"},
+		SkipForks:    true,
+		SkipArchived: true,
+	})
+	require.NoError(err)
+
+	got, err := repo.GetOwnerByName(context.Background(), "org:myorg")
+	require.NoError(err)
+	assert.Equal(ownerID, got.ID)
+	assert.Equal(repository.OwnerKindOrg, got.Kind)
+	assert.Equal([]string{"api-*", "web"}, got.Include)
+	assert.Equal([]string{"*-legacy"}, got.Exclude)
+	assert.True(got.SkipForks)
+	assert.True(got.SkipArchived)
+	assert.Nil(got.LastSyncedAt)
+	assert.False(got.CreatedAt.IsZero())
+
+	_, err = repo.CreateOwner(context.Background(), repository.OwnerSubscription{Name: "org:myorg", Kind: repository.OwnerKindOrg})
+	assert.ErrorIs(err, repository.ErrConflict)
+
+	require.NoError(repo.UpdateOwnerLastSyncTime(context.Background(), ownerID, baseTime))
+	createOwner(t, repo, "user:alice")
+
+	owners, err := repo.GetOwners(context.Background())
+	require.NoError(err)
+	require.Len(owners, 2)
+	names := []string{owners[0].Name, owners[1].Name}
+	assert.ElementsMatch([]string{"org:myorg", "user:alice"}, names)
+	for _, owner := range owners {
+		if owner.Name == "org:myorg" {
+			require.NotNil(owner.LastSyncedAt)
+			assert.True(baseTime.Equal(*owner.LastSyncedAt), "expected %v, got %v", baseTime, *owner.LastSyncedAt)
+		} else {
+			assert.Empty(owner.Include)
+		}
+	}
+}
+
+func testRepositoriesByOwner(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	ownerID := createOwner(t, repo, "org:owner")
+	repoID := createRepository(t, repo, "owner/one")
+	createRepository(t, repo, "owner/two")
+
+	repos, err := repo.GetRepositoriesByOwner(context.Background(), ownerID)
+	require.NoError(err)
+	require.Empty(repos)
+
+	require.NoError(repo.UpdateRepositoryOwner(context.Background(), repoID, ownerID))
+	require.NoError(repo.UpdateRepositoryDeletedAt(context.Background(), repoID, &baseTime))
+
+	repos, err = repo.GetRepositoriesByOwner(context.Background(), ownerID)
+	require.NoError(err)
+	require.Len(repos, 1)
+	assert.Equal("owner/one", repos[0].RepositoryName)
+	require.NotNil(repos[0].OwnerID)
+	assert.Equal(ownerID, *repos[0].OwnerID)
+	require.NotNil(repos[0].DeletedAt)
+	assert.True(baseTime.Equal(*repos[0].DeletedAt), "expected %v, got %v", baseTime, *repos[0].DeletedAt)
+
+	got, err := repo.GetRepositoryByName(context.Background(), "owner/two")
+	require.NoError(err)
+	assert.Nil(got.OwnerID)
+	assert.Nil(got.DeletedAt)
+
+	// restored repositories are no longer deleted
+	require.NoError(repo.UpdateRepositoryDeletedAt(context.Background(), repoID, nil))
+	all, err := repo.GetRepositories(context.Background())
+	require.NoError(err)
+	require.Len(all, 2)
+	for _, r := range all {
+		assert.Nil(r.DeletedAt, r.RepositoryName)
+		if r.ID == repoID {
+			require.NotNil(r.OwnerID)
+			assert.Equal(ownerID, *r.OwnerID)
+		}
+	}
+}
+
+func testOwnerStats(t *testing.T, repo repository.Repository) {
+	require := require.New(t)
+
+	ownerID := createOwner(t, repo, "org:owner")
+	stats, err := repo.GetOwnerStats(context.Background(), ownerID, 5)
+	require.NoError(err)
+	assert.Equal(t, repository.OwnerStats{}, stats)
+
+	oneID := createRepository(t, repo, "owner/one")
+	twoID := createRepository(t, repo, "owner/two")
+	deletedID := createRepository(t, repo, "owner/deleted")
+	otherID := createRepository(t, repo, "other/name")
+	for i, id := range []string{oneID, twoID, deletedID} {
+		require.NoError(repo.UpdateRepositoryOwner(context.Background(), id, ownerID))
+		require.NoError(repo.UpdateRepository(context.Background(), &repository.GithubRepository{
+			ID:              id,
+			ForksCount:      i + 1,
+			StarsCount:      10 * (i + 1),
+			OpenIssuesCount: 1,
+			WatchersCount:   2,
+		}))
+	}
+	require.NoError(repo.UpdateRepositoryDeletedAt(context.Background(), deletedID, &baseTime))
+
+	for i := 0; i < 2; i++ {
+		saveCommit(t, repo, oneID, fmt.Sprintf("user1-%d", i), "user1", baseTime)
+	}
+	saveCommit(t, repo, twoID, "user1-2", "user1", baseTime)
+	saveCommit(t, repo, twoID, "user2-0", "user2", baseTime)
+	saveCommit(t, repo, twoID, "user3-0", "user3", baseTime)
+	// commits of deleted repositories and of repositories of other owners are not counted
+	saveCommit(t, repo, deletedID, "user4-0", "user4", baseTime)
+	saveCommit(t, repo, otherID, "user5-0", "user5", baseTime)
+
+	stats, err = repo.GetOwnerStats(context.Background(), ownerID, 2)
+	require.NoError(err)
+	assert.Equal(t, repository.OwnerStats{
+		Repositories:        2,
+		DeletedRepositories: 1,
+		StarsCount:          30,
+		ForksCount:          3,
+		OpenIssuesCount:     2,
+		WatchersCount:       4,
+		Commits:             5,
+		Authors:             3,
+		TopAuthors: []repository.CommitStats{
+			{AuthorName: "user1", CommitCount: 3},
+			{AuthorName: "user2", CommitCount: 1},
+		},
+	}, stats)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxSearchTerms limits the number of terms in a single search query
+const maxSearchTerms = 10
+
+// SearchTerm represents a word or phrase in a commit search query.
+// a term with more than one word only matches the words next to each other in order
+type SearchTerm struct {
+	Words []string
+	// Prefix matches the last word as a prefix, e.g. "CVE-2024*"
+	Prefix bool
+}
+
+// SearchQuery represents a parsed commit search query, every term must match
+type SearchQuery struct {
+	Terms []SearchTerm
+}
+
+// CommitSearch represents the parameters of a commit search
+type CommitSearch struct {
+	Query SearchQuery
+	// RepositoryIDs limits the search to the given repositories, all repositories are searched when empty
+	RepositoryIDs []string
+	Limit         int
+	Offset        int
+}
+
+// CommitSearchResult represents a commit matching a search, ordered by rank
+type CommitSearchResult struct {
+	GithubCommit
+	RepositoryName string  `json:"repository_name"`
+	Rank           float64 `json:"rank"`
+	// Snippet is an excerpt of the commit message with matches wrapped in <mark></mark>
+	Snippet string `json:"snippet"`
+}
+
+// ParseSearchQuery parses q into a SearchQuery.
+// words in double quotes are matched as a phrase and a trailing * matches a prefix.
+// punctuation splits words so CVE-2024-1234 is matched as the phrase "cve 2024 1234"
+func ParseSearchQuery(q string) (SearchQuery, error) {
+	var query SearchQuery
+
+	for _, token := range splitSearchTokens(q) {
+		prefix := strings.HasSuffix(token, "*")
+		words := SearchWords(token)
+		if len(words) == 0 {
+			continue
+		}
+		query.Terms = append(query.Terms, SearchTerm{Words: words, Prefix: prefix})
+	}
+
+	if len(query.Terms) == 0 {
+		return query, fmt.Errorf("search query must contain at least one word")
+	}
+	if len(query.Terms) > maxSearchTerms {
+		return query, fmt.Errorf("search query must not contain more than %d terms", maxSearchTerms)
+	}
+
+	return query, nil
+}
+
+// splitSearchTokens splits q on whitespace outside of double quotes
+func splitSearchTokens(q string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		quoted  bool
+	)
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			// a closing quote ends the phrase, only a directly following * is kept for prefix matching
+			if quoted {
+				quoted = false
+				continue
+			}
+			flush()
+			quoted = true
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// SearchWords splits s into lower case words of letters and digits
+func SearchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
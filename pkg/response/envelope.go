@@ -0,0 +1,70 @@
+package response
+
+import (
+	"net/http"
+	"time"
+)
+
+// APIVersion is the version of the response envelope
+const APIVersion = "v1"
+
+// Envelope is the JSON response format of every successful v1 response.
+// data is always present, meta and links are only set when they apply to the resource
+type Envelope struct {
+	APIVersion string      `json:"api_version"`
+	Data       interface{} `json:"data"`
+	Meta       *Meta       `json:"meta,omitempty"`
+	Links      *Links      `json:"links,omitempty"`
+}
+
+// Meta describes the data of an Envelope
+type Meta struct {
+	// Total is the number of items across all pages, nil when it is not known
+	Total      *int        `json:"total,omitempty"`
+	Limit      int         `json:"limit,omitempty"`
+	Offset     int         `json:"offset,omitempty"`
+	Cursor     *CursorMeta `json:"cursor,omitempty"`
+	SyncStatus *SyncStatus `json:"sync_status,omitempty"`
+}
+
+// CursorMeta holds the opaque cursors of the neighbouring pages
+type CursorMeta struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// SyncState represents how far the collection of a repository has progressed
+type SyncState string
+
+const (
+	// SyncStatePending is set until the first sync of a tracked repository completes
+	SyncStatePending SyncState = "pending"
+	// SyncStateSynced is set once commits of a repository have been pulled at least once
+	SyncStateSynced SyncState = "synced"
+	// SyncStateNotTracked is set when no repositories are tracked
+	SyncStateNotTracked SyncState = "not_tracked"
+)
+
+// SyncStatus describes the state of the data collection backing a response
+type SyncStatus struct {
+	State        SyncState  `json:"state"`
+	Message      string     `json:"message,omitempty"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+}
+
+// Links holds the urls of the current and neighbouring pages
+type Links struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// Data sends data wrapped in an Envelope
+func Data(w http.ResponseWriter, statusCode int, data interface{}, meta *Meta, links *Links) error {
+	return JSON(w, statusCode, Envelope{
+		APIVersion: APIVersion,
+		Data:       data,
+		Meta:       meta,
+		Links:      links,
+	})
+}
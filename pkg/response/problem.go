@@ -0,0 +1,87 @@
+package response
+
+import (
+	"net/http"
+)
+
+// ProblemContentType is the media type of Problem responses
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes the error code to form the type uri of a Problem
+const problemTypePrefix = "urn:github-repo-stats:problem:"
+
+// ErrorCode is a stable machine-readable identifier of an error
+type ErrorCode string
+
+const (
+	// CodeInvalidRequest is returned when a request parameter or body is invalid
+	CodeInvalidRequest ErrorCode = "invalid_request"
+	// CodeNotFound is returned when a requested resource does not exist
+	CodeNotFound ErrorCode = "not_found"
+	// CodeConflict is returned when a resource already exists
+	CodeConflict ErrorCode = "conflict"
+	// CodeRouteNotFound is returned for unknown routes
+	CodeRouteNotFound ErrorCode = "route_not_found"
+	// CodeMethodNotAllowed is returned when a route does not support the request method
+	CodeMethodNotAllowed ErrorCode = "method_not_allowed"
+	// CodeNotAcceptable is returned when a route can not respond in any media type of the Accept header
+	CodeNotAcceptable ErrorCode = "not_acceptable"
+	// CodeInvalidSignature is returned for webhook deliveries which are not signed with the configured secret
+	CodeInvalidSignature ErrorCode = "invalid_signature"
+	// CodePayloadTooLarge is returned for webhook deliveries larger than the payload limit
+	CodePayloadTooLarge ErrorCode = "payload_too_large"
+	// CodeInternal is returned for unexpected errors, details are only logged
+	CodeInternal ErrorCode = "internal_error"
+)
+
+// Problem is the RFC 7807 JSON response format for errors.
+// it implements error so handlers can return it as is
+type Problem struct {
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Status    int       `json:"status"`
+	Detail    string    `json:"detail,omitempty"`
+	Code      ErrorCode `json:"code"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// NewProblem returns a Problem of the given status and code
+func NewProblem(status int, code ErrorCode, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypePrefix + string(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Error returns the detail of the problem
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+// InvalidRequest returns a Problem for when a request contains errors.
+func InvalidRequest(detail string) *Problem {
+	return NewProblem(http.StatusBadRequest, CodeInvalidRequest, detail)
+}
+
+// NotFound returns a Problem for when a requested resource does not exist.
+func NotFound(detail string) *Problem {
+	return NewProblem(http.StatusNotFound, CodeNotFound, detail)
+}
+
+// Conflict returns a Problem for when a resource already exists.
+func Conflict(detail string) *Problem {
+	return NewProblem(http.StatusConflict, CodeConflict, detail)
+}
+
+// InternalError returns a Problem for when there is internal error
+func InternalError() *Problem {
+	return NewProblem(http.StatusInternalServerError, CodeInternal, "an unexpected error occurred, quote the request_id when reporting it")
+}
+
+// WriteProblem sends p as application/problem+json
+func WriteProblem(w http.ResponseWriter, p *Problem) error {
+	return write(w, ProblemContentType, p.Status, p)
+}
@@ -6,73 +6,381 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/danielboakye/github-repo-stats/pkg/db/postgres"
+	"github.com/danielboakye/github-repo-stats/pkg/logging"
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/provider/bitbucket"
+	"github.com/danielboakye/github-repo-stats/pkg/provider/gitea"
+	"github.com/danielboakye/github-repo-stats/pkg/provider/github"
+	"github.com/danielboakye/github-repo-stats/pkg/provider/gitlab"
 	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// Config represents the settings of the github service
+type Config struct {
+	// APIURL is the base url of the github rest api
+	APIURL string
+	// GraphQLURL is the url of the github graphql api repos are fetched with when their fetch mode is graphql
+	GraphQLURL string
+	// Token authenticates github api requests, requests are anonymous when it is empty
+	Token string
+	// Since is the date commits are pulled from on the first sync of a repo, all commits are pulled when it is zero
+	Since time.Time
+	// SyncInterval is the wait between syncs of all tracked repos
+	SyncInterval time.Duration
+	// Backoff is the initial wait after github reports the rate limit is reached, it doubles on every retry
+	Backoff time.Duration
+	// QueueSize is the number of newly tracked repos that can wait for their first sync
+	QueueSize int
+	// Concurrency is the maximum number of repos synced at once
+	Concurrency int
+	// PendingInterval is how often a worker looks for repos tracked by other processes
+	PendingInterval time.Duration
+	// WebhookSecret verifies github webhook deliveries, github webhooks are disabled when it is empty
+	WebhookSecret string
+	// Gitlab, Gitea and Bitbucket are the settings of the providers of gitlab:, gitea: and bitbucket: repos
+	Gitlab    ProviderConfig
+	Gitea     ProviderConfig
+	Bitbucket ProviderConfig
+}
+
+// ProviderConfig represents the settings of a provider other than github
+type ProviderConfig struct {
+	// APIURL is the base url of the rest api of the provider
+	APIURL string
+	// Token authenticates api requests, requests are anonymous when it is empty
+	Token string
+	// WebhookSecret verifies webhook deliveries, webhooks of the provider are disabled when it is empty
+	WebhookSecret string
+}
+
+// DefaultConfig returns the settings used when none are configured
+func DefaultConfig() Config {
+	return Config{
+		APIURL:          "https://api.github.com",
+		GraphQLURL:      "https://api.github.com/graphql",
+		SyncInterval:    1 * time.Hour,
+		Backoff:         1 * time.Minute,
+		QueueSize:       100,
+		Concurrency:     4,
+		PendingInterval: 30 * time.Second,
+		Gitlab:          ProviderConfig{APIURL: "https://gitlab.com/api/v4"},
+		Gitea:           ProviderConfig{APIURL: "https://gitea.com/api/v1"},
+		Bitbucket:       ProviderConfig{APIURL: "https://api.bitbucket.org/2.0"},
+	}
+}
+
 // Service represents github repos service
 type Service struct {
-	repo            repository.Repository
-	logger          *slog.Logger
-	httpClient      *http.Client
-	newRepo         chan string
-	commitSinceDate time.Time
+	repo   repository.Repository
+	logger *slog.Logger
+	// github is also used for health checks, providers holds it along with the other providers.
+	// githubGraphQL fetches github repos with the graphql fetch mode, owners lists the repos of subscribed github owners
+	github         *github.Provider
+	githubGraphQL  *github.GraphQLProvider
+	owners         provider.OwnerLister
+	providers      map[repository.Provider]provider.Provider
+	webhookSecrets map[repository.Provider]string
+	newRepo        chan trackJob
+	config         Config
+	// syncs limits the number of repos synced at once
+	syncs chan struct{}
+
+	// heartbeat is the unix nano time the watcher last reported being alive
+	heartbeat      atomic.Int64
+	githubStatusMu sync.Mutex
+	githubStatus   GithubStatus
+
+	// listening is set when the listener consumes newRepo in this process
+	listening atomic.Bool
+	// stopped is closed by Stop, wg tracks the listener, the watcher and the syncs they start
+	stopped  chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
 }
 
 // NewService initiates a new github service manager
-func NewService(repo repository.Repository, logger *slog.Logger, commitSinceDate time.Time) *Service {
+func NewService(repo repository.Repository, logger *slog.Logger, config Config) *Service {
+	httpClient := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	githubConfig := github.Config{APIURL: config.APIURL, GraphQLURL: config.GraphQLURL, Token: config.Token}
+	githubProvider := github.NewProvider(httpClient, githubConfig)
+
 	return &Service{
-		repo:            repo,
-		logger:          logger,
-		httpClient:      &http.Client{},
-		newRepo:         make(chan string, 100),
-		commitSinceDate: commitSinceDate,
+		repo:          repo,
+		logger:        logger,
+		github:        githubProvider,
+		githubGraphQL: github.NewGraphQLProvider(httpClient, githubConfig),
+		owners:        githubProvider,
+		providers: map[repository.Provider]provider.Provider{
+			repository.ProviderGithub:    githubProvider,
+			repository.ProviderGitlab:    gitlab.NewProvider(httpClient, gitlab.Config{APIURL: config.Gitlab.APIURL, Token: config.Gitlab.Token}),
+			repository.ProviderGitea:     gitea.NewProvider(httpClient, gitea.Config{APIURL: config.Gitea.APIURL, Token: config.Gitea.Token}),
+			repository.ProviderBitbucket: bitbucket.NewProvider(httpClient, bitbucket.Config{APIURL: config.Bitbucket.APIURL, Token: config.Bitbucket.Token}),
+		},
+		webhookSecrets: map[repository.Provider]string{
+			repository.ProviderGithub:    config.WebhookSecret,
+			repository.ProviderGitlab:    config.Gitlab.WebhookSecret,
+			repository.ProviderGitea:     config.Gitea.WebhookSecret,
+			repository.ProviderBitbucket: config.Bitbucket.WebhookSecret,
+		},
+		newRepo: make(chan trackJob, config.QueueSize),
+		config:  config,
+		syncs:   make(chan struct{}, max(config.Concurrency, 1)),
+		stopped: make(chan struct{}),
+	}
+}
+
+// provider returns the provider repo is fetched with and the path of the repo on it
+func (s *Service) provider(repo *repository.GithubRepository) (provider.Provider, string, error) {
+	name, err := repository.ParseRepoName(repo.RepositoryName)
+	if err != nil {
+		return nil, "", err
+	}
+	if repo.FetchMode == repository.FetchModeGraphQL && name.Provider == repository.ProviderGithub {
+		return s.githubGraphQL, name.Path, nil
+	}
+
+	p, ok := s.providers[name.Provider]
+	if !ok {
+		return nil, "", fmt.Errorf("provider %s is not supported", name.Provider)
+	}
+
+	return p, name.Path, nil
+}
+
+// trackJob represents a repository queued for its first sync or, when ownerName is set,
+// an owner subscription queued for listing its repositories. attrs are the logging attributes of the request that queued it
+type trackJob struct {
+	repoName  string
+	ownerName string
+	attrs     []slog.Attr
+}
+
+// enqueue sends repoName to the listener queue to trigger a sync and reports whether it was queued.
+// once the service is stopped the job is dropped, the repo is synced by the watcher after the next start.
+// without a listener in this process the repo is left to a worker
+func (s *Service) enqueue(ctx context.Context, repoName string) bool {
+	return s.send(ctx, trackJob{repoName: repoName}, "repo")
+}
+
+// enqueueOwner sends ownerName to the listener queue to list the repositories of the owner like enqueue
+func (s *Service) enqueueOwner(ctx context.Context, ownerName string) bool {
+	return s.send(ctx, trackJob{ownerName: ownerName}, "owner")
+}
+
+// send sends job to the listener queue and reports whether it was queued, subject names the job in logs
+func (s *Service) send(ctx context.Context, job trackJob, subject string) bool {
+	if !s.listening.Load() {
+		s.logger.InfoContext(ctx, subject+"-left-for-worker")
+		return false
+	}
+
+	job.attrs = logging.Attrs(ctx)
+	metrics.QueueDepth.Inc()
+	select {
+	case <-s.stopped:
+		metrics.QueueDepth.Dec()
+		s.logger.WarnContext(ctx, "service-stopped:"+subject+"-not-queued")
+		return false
+	case <-ctx.Done():
+		metrics.QueueDepth.Dec()
+		s.logger.WarnContext(ctx, "request-cancelled:"+subject+"-not-queued")
+		return false
+	case s.newRepo <- job:
+		s.logger.InfoContext(ctx, "queued-"+subject+"-for-tracking")
+		return true
 	}
 }
 
-// getRepositoryID returns github repository id from the datastore
-// if github repo does not exist in the datastore, it creates it and returns its id
+// getOrCreateRepository returns github repository from the datastore
+// if github repo does not exist in the datastore, it creates it and returns it
 // it add repo to listener queue to trigger watch (pulling of commits and metadata) on the repo
-func (s *Service) getRepositoryID(ctx context.Context, repoName string) (string, error) {
-	var githubRepoID string
+func (s *Service) getOrCreateRepository(ctx context.Context, repoName string) (repository.GithubRepository, error) {
+	ctx = logging.With(ctx, slog.String(logging.RepositoryKey, repoName))
 	githubRepo, err := s.repo.GetRepositoryByName(ctx, repoName)
-	if errors.Is(err, postgres.ErrRecordNotFound) {
+	if errors.Is(err, repository.ErrNotFound) {
 		returnID, rpErr := s.repo.CreateRepository(ctx, repoName)
+		if errors.Is(rpErr, repository.ErrConflict) {
+			// repo was created by a concurrent request which already triggered the watch,
+			// it is read once more instead of retrying so a store reporting a conflict for a missing repo can not loop
+			githubRepo, err = s.repo.GetRepositoryByName(ctx, repoName)
+			if err != nil {
+				return githubRepo, fmt.Errorf("failed to get repository (%s) with error: %w", repoName, err)
+			}
+			return githubRepo, nil
+		}
 		if rpErr != nil {
-			s.logger.Error("error-creating-repo",
-				slog.String("repoName", repoName),
-				slog.String("error", err.Error()),
+			s.logger.ErrorContext(ctx, "error-creating-repo",
+				slog.String("error", rpErr.Error()),
 			)
-			return githubRepoID, fmt.Errorf("error creating repository: %w", err)
+			return githubRepo, fmt.Errorf("error creating repository: %w", rpErr)
 		}
 
 		// send message to channel to trigger loading
-		s.newRepo <- repoName
+		s.enqueue(ctx, repoName)
+
+		return repository.GithubRepository{ID: returnID, RepositoryName: repoName}, nil
+	}
+	if err != nil {
+		return githubRepo, fmt.Errorf("failed to get repository (%s) with error: %w", repoName, err)
+	}
+
+	return githubRepo, nil
+}
+
+// TrackRepository starts tracking a github repo fetched with mode and returns it, repos are fetched with their rest api
+// when mode is empty. it returns repository.ErrConflict if the repo is already tracked
+func (s *Service) TrackRepository(ctx context.Context, repoName string, mode repository.FetchMode) (repository.GithubRepository, error) {
+	ctx = logging.With(ctx, slog.String(logging.RepositoryKey, repoName))
+	repoID, err := s.repo.CreateRepository(ctx, repoName)
+	if errors.Is(err, repository.ErrConflict) {
+		return repository.GithubRepository{}, fmt.Errorf("repository %s is already tracked: %w", repoName, repository.ErrConflict)
+	}
+	if err != nil {
+		return repository.GithubRepository{}, fmt.Errorf("failed to track repository (%s): %w", repoName, err)
+	}
+	// the fetch mode is set before the first sync is queued
+	if mode != "" && mode != repository.FetchModeREST {
+		if err := s.repo.UpdateFetchMode(ctx, repoID, mode); err != nil {
+			return repository.GithubRepository{}, fmt.Errorf("failed to update fetch mode of repository (%s): %w", repoName, err)
+		}
+	}
 
-		githubRepoID = returnID
-		return githubRepoID, nil
+	// send message to channel to trigger loading
+	s.enqueue(ctx, repoName)
+
+	return s.GetRepository(ctx, repoName)
+}
+
+// GetRepository returns a tracked github repo.
+// it returns repository.ErrNotFound if the repo is not tracked
+func (s *Service) GetRepository(ctx context.Context, repoName string) (repository.GithubRepository, error) {
+	githubRepo, err := s.repo.GetRepositoryByName(ctx, repoName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return githubRepo, fmt.Errorf("repository %s is not tracked: %w", repoName, repository.ErrNotFound)
+	}
+	if err != nil {
+		return githubRepo, fmt.Errorf("failed to get repository (%s): %w", repoName, err)
+	}
+
+	return githubRepo, nil
+}
+
+// UpdateFetchMode changes the api a tracked repo is fetched with from its next sync on and returns the repo.
+// it returns repository.ErrNotFound if the repo is not tracked
+func (s *Service) UpdateFetchMode(ctx context.Context, repoName string, mode repository.FetchMode) (repository.GithubRepository, error) {
+	githubRepo, err := s.GetRepository(ctx, repoName)
+	if err != nil {
+		return githubRepo, err
 	}
+
+	if err := s.repo.UpdateFetchMode(ctx, githubRepo.ID, mode); err != nil {
+		return githubRepo, fmt.Errorf("failed to update fetch mode of repository (%s): %w", repoName, err)
+	}
+	githubRepo.FetchMode = mode
+
+	return githubRepo, nil
+}
+
+// GetRepositories returns all tracked github repos
+func (s *Service) GetRepositories(ctx context.Context) ([]*repository.GithubRepository, error) {
+	repos, err := s.repo.GetRepositories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repositories: %w", err)
+	}
+
+	return repos, nil
+}
+
+// StreamCommits calls fn with every commit of a tracked github repo matching filter,
+// it returns repository.ErrNotFound for an untracked repo and does not start tracking it
+func (s *Service) StreamCommits(ctx context.Context, repoName string, filter repository.CommitFilter, fn func(*repository.GithubCommit) error) error {
+	githubRepo, err := s.GetRepository(ctx, repoName)
 	if err != nil {
-		return githubRepoID, fmt.Errorf("failed to get repository (%s) with error: %w", repoName, err)
+		return err
 	}
 
-	return githubRepo.ID, nil
+	return s.repo.StreamCommitsByRepository(ctx, githubRepo.ID, filter, fn)
 }
 
-// GetCommits loads paginated commits for a github repo
-func (s *Service) GetCommits(ctx context.Context, repoName string, limit, offset int) ([]*repository.GithubCommit, error) {
-	githubRepoID, err := s.getRepositoryID(ctx, repoName)
+// GetCommits loads a page of commits for a github repo matching filter
+// along with the cursors of the next and previous pages and the number of matching commits
+func (s *Service) GetCommits(ctx context.Context, repoName string, filter repository.CommitFilter, page repository.Page) (repository.CommitPage, error) {
+	githubRepo, err := s.getOrCreateRepository(ctx, repoName)
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving repository id: %w", err)
+		return repository.CommitPage{}, fmt.Errorf("error retrieving repository id: %w", err)
 	}
-	commits, err := s.repo.GetCommitsByRepository(ctx, githubRepoID, limit, offset)
+	githubRepoID := githubRepo.ID
+
+	// one extra commit is loaded to know whether there is another page in the direction read
+	limit := page.Limit
+	page.Limit++
+	commits, err := s.repo.GetCommitsByRepository(ctx, githubRepoID, filter, page)
+	if err != nil {
+		return repository.CommitPage{}, fmt.Errorf("failed to get commits for repository (%v) with error: %w", githubRepoID, err)
+	}
+
+	before := page.Cursor != nil && page.Cursor.Before
+	hasMore := len(commits) > limit
+	if hasMore {
+		// pages before a cursor are returned in listing order so the extra commit is the first one
+		if before {
+			commits = commits[1:]
+		} else {
+			commits = commits[:limit]
+		}
+	}
+
+	total, err := s.repo.CountCommitsByRepository(ctx, githubRepoID, filter)
+	if err != nil {
+		return repository.CommitPage{}, fmt.Errorf("failed to count commits for repository (%v) with error: %w", githubRepoID, err)
+	}
+
+	result := repository.CommitPage{
+		Commits:      commits,
+		Total:        total,
+		LastSyncedAt: githubRepo.CommitLastPulledTime,
+	}
+	if len(commits) == 0 {
+		return result, nil
+	}
+	if hasMore || before {
+		result.Next = repository.NewCursor(commits[len(commits)-1], false)
+	}
+	if (before && hasMore) || (!before && (page.Cursor != nil || page.Offset > 0)) {
+		result.Prev = repository.NewCursor(commits[0], true)
+	}
+
+	return result, nil
+}
+
+// SearchCommits searches the commit messages of the given tracked repos ranked by relevance,
+// all tracked repos are searched when repoNames is empty
+func (s *Service) SearchCommits(ctx context.Context, query repository.SearchQuery, repoNames []string, limit, offset int) ([]*repository.CommitSearchResult, error) {
+	search := repository.CommitSearch{
+		Query:  query,
+		Limit:  limit,
+		Offset: offset,
+	}
+	for _, repoName := range repoNames {
+		githubRepo, err := s.GetRepository(ctx, repoName)
+		if err != nil {
+			return nil, err
+		}
+		search.RepositoryIDs = append(search.RepositoryIDs, githubRepo.ID)
+	}
+
+	results, err := s.repo.SearchCommits(ctx, search)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get commits for repository (%v) with error: %w", githubRepoID, err)
+		return nil, fmt.Errorf("failed to search commits: %w", err)
 	}
 
-	return commits, nil
+	return results, nil
 }
 
 // GetLeaderBoard loads leaderboard stats
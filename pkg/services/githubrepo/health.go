@@ -0,0 +1,88 @@
+package githubrepo
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// heartbeatInterval is how often an idle or waiting watcher beats
+	heartbeatInterval = 30 * time.Second
+	// githubStatusTTL is how long a github reachability probe is reused
+	githubStatusTTL = time.Minute
+)
+
+// GithubStatus represents the reachability of the github api and its rate limit
+type GithubStatus struct {
+	Reachable   bool      `json:"reachable"`
+	RateLimited bool      `json:"rate_limited"`
+	Remaining   int       `json:"rate_limit_remaining"`
+	Reset       time.Time `json:"rate_limit_reset"`
+	CheckedAt   time.Time `json:"checked_at"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// beat records that the watcher is alive
+func (s *Service) beat() {
+	s.heartbeat.Store(time.Now().UnixNano())
+}
+
+// LastHeartbeat returns when the watcher last reported being alive, zero before it started
+func (s *Service) LastHeartbeat() time.Time {
+	nanos := s.heartbeat.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nanos)
+}
+
+// wait blocks for d or until ctx is done, the watcher keeps beating while it waits
+// so long backoffs and sync intervals are not reported as a stuck watcher
+func (s *Service) wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			s.beat()
+		case <-timer.C:
+			s.beat()
+			return nil
+		}
+	}
+}
+
+// GithubStatus probes the github rate limit endpoint, which does not count against the quota.
+// results are reused for githubStatusTTL so frequent readiness checks do not hammer github
+func (s *Service) GithubStatus(ctx context.Context) GithubStatus {
+	s.githubStatusMu.Lock()
+	defer s.githubStatusMu.Unlock()
+
+	if time.Since(s.githubStatus.CheckedAt) < githubStatusTTL {
+		return s.githubStatus
+	}
+
+	status := GithubStatus{CheckedAt: time.Now()}
+	rateLimit, err := s.github.RateLimit(ctx)
+	if err != nil {
+		status.Error = err.Error()
+		if ctx.Err() != nil {
+			// the caller gave up, the probe says nothing about github and is not reused
+			return status
+		}
+	} else {
+		status.Reachable = true
+		status.Remaining = rateLimit.Remaining
+		status.Reset = rateLimit.Reset
+		status.RateLimited = status.Remaining == 0
+	}
+	s.githubStatus = status
+
+	return status
+}
@@ -0,0 +1,263 @@
+package githubrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/logging"
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// SubscribeOwner starts tracking the repositories of a github organization or user matching owner and returns the subscription,
+// the repositories are listed in the background. it returns repository.ErrConflict if the owner is already subscribed
+func (s *Service) SubscribeOwner(ctx context.Context, owner repository.OwnerSubscription) (repository.OwnerSubscription, error) {
+	ctx = logging.With(ctx, slog.String(logging.OwnerKey, owner.Name))
+	_, err := s.repo.CreateOwner(ctx, owner)
+	if errors.Is(err, repository.ErrConflict) {
+		return repository.OwnerSubscription{}, fmt.Errorf("owner %s is already subscribed: %w", owner.Name, repository.ErrConflict)
+	}
+	if err != nil {
+		return repository.OwnerSubscription{}, fmt.Errorf("failed to subscribe owner (%s): %w", owner.Name, err)
+	}
+
+	// send message to channel to trigger listing
+	s.enqueueOwner(ctx, owner.Name)
+
+	return s.GetOwner(ctx, owner.Name)
+}
+
+// GetOwner returns a subscribed owner.
+// it returns repository.ErrNotFound if the owner is not subscribed
+func (s *Service) GetOwner(ctx context.Context, ownerName string) (repository.OwnerSubscription, error) {
+	owner, err := s.repo.GetOwnerByName(ctx, ownerName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return owner, fmt.Errorf("owner %s is not subscribed: %w", ownerName, repository.ErrNotFound)
+	}
+	if err != nil {
+		return owner, fmt.Errorf("failed to get owner (%s): %w", ownerName, err)
+	}
+
+	return owner, nil
+}
+
+// GetOwners returns all subscribed owners
+func (s *Service) GetOwners(ctx context.Context) ([]*repository.OwnerSubscription, error) {
+	owners, err := s.repo.GetOwners(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owners: %w", err)
+	}
+
+	return owners, nil
+}
+
+// GetOwnerRepositories returns the repositories tracked for a subscribed owner including the deleted ones
+func (s *Service) GetOwnerRepositories(ctx context.Context, ownerName string) ([]*repository.GithubRepository, error) {
+	owner, err := s.GetOwner(ctx, ownerName)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := s.repo.GetRepositoriesByOwner(ctx, owner.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repositories of owner (%s): %w", ownerName, err)
+	}
+
+	return repos, nil
+}
+
+// GetOwnerStats aggregates the stats of the repositories of a subscribed owner along with its count top authors
+func (s *Service) GetOwnerStats(ctx context.Context, ownerName string, count int) (repository.OwnerStats, error) {
+	owner, err := s.GetOwner(ctx, ownerName)
+	if err != nil {
+		return repository.OwnerStats{}, err
+	}
+
+	stats, err := s.repo.GetOwnerStats(ctx, owner.ID, count)
+	if err != nil {
+		return stats, fmt.Errorf("failed to get stats of owner (%s): %w", ownerName, err)
+	}
+
+	return stats, nil
+}
+
+// SyncOwner lists the repositories of a subscribed owner once in the foreground,
+// the repositories it starts tracking are queued for their first sync
+func (s *Service) SyncOwner(ctx context.Context, ownerName string) error {
+	ctx = logging.With(ctx, slog.String(logging.OwnerKey, ownerName))
+	owner, err := s.GetOwner(ctx, ownerName)
+	if err != nil {
+		return err
+	}
+
+	repos, err := s.syncOwner(ctx, &owner)
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		s.enqueue(logging.With(ctx, slog.String(logging.RepositoryKey, repo.RepositoryName)), repo.RepositoryName)
+	}
+
+	return nil
+}
+
+// syncAllOwners lists the repositories of every subscribed owner, the repositories they start tracking are synced
+// along with the other tracked repos by the caller
+func (s *Service) syncAllOwners(ctx context.Context) {
+	owners, err := s.repo.GetOwners(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "error-retrieving-owners",
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	for _, owner := range owners {
+		ownerCtx := logging.With(ctx, slog.String(logging.OwnerKey, owner.Name))
+		if _, err := s.syncOwner(ownerCtx, owner); err != nil {
+			s.logger.ErrorContext(ownerCtx, "error-syncing-owner",
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// syncOwner lists the repositories of owner, tracks the ones matching it which are not tracked yet and marks the ones it tracked
+// which are no longer listed or matching as deleted. it returns the repositories the sync started tracking or restored
+func (s *Service) syncOwner(ctx context.Context, owner *repository.OwnerSubscription) ([]*repository.GithubRepository, error) {
+	name, err := repository.ParseOwnerName(owner.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	listed, err := s.listOwnerRepositories(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetRepositoriesByOwner(ctx, owner.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repositories of owner (%s): %w", owner.Name, err)
+	}
+	tracked := make(map[string]*repository.GithubRepository, len(existing))
+	for _, repo := range existing {
+		tracked[repo.RepositoryName] = repo
+	}
+
+	var synced []*repository.GithubRepository
+	seen := map[string]bool{}
+	for _, listedRepo := range listed {
+		if !owner.Matches(listedRepo.Name, listedRepo.Fork, listedRepo.Archived) {
+			continue
+		}
+		repoName := repository.RepoName{Provider: repository.ProviderGithub, Path: listedRepo.Path}.String()
+		seen[repoName] = true
+
+		repo, ok := tracked[repoName]
+		if ok && repo.DeletedAt == nil {
+			continue
+		}
+		if !ok {
+			if repo, err = s.trackOwnerRepository(ctx, repoName, owner.ID); err != nil {
+				return synced, err
+			}
+			if repo == nil {
+				continue
+			}
+		}
+		if repo.DeletedAt != nil {
+			if err := s.repo.UpdateRepositoryDeletedAt(ctx, repo.ID, nil); err != nil {
+				return synced, fmt.Errorf("failed to restore repository (%s): %w", repoName, err)
+			}
+			repo.DeletedAt = nil
+		}
+		synced = append(synced, repo)
+	}
+
+	now := time.Now().UTC()
+	var deleted int
+	for _, repo := range existing {
+		if seen[repo.RepositoryName] || repo.DeletedAt != nil {
+			continue
+		}
+		if err := s.repo.UpdateRepositoryDeletedAt(ctx, repo.ID, &now); err != nil {
+			return synced, fmt.Errorf("failed to mark repository (%s) as deleted: %w", repo.RepositoryName, err)
+		}
+		deleted++
+	}
+
+	if err := s.repo.UpdateOwnerLastSyncTime(ctx, owner.ID, now); err != nil {
+		return synced, fmt.Errorf("failed to update last sync time of owner (%s): %w", owner.Name, err)
+	}
+	s.logger.InfoContext(ctx, "synced-owner",
+		slog.Int("listed", len(listed)),
+		slog.Int("tracked", len(synced)),
+		slog.Int("deleted", deleted),
+	)
+
+	return synced, nil
+}
+
+// trackOwnerRepository starts tracking repoName for the owner subscription ownerID and returns it. repos which are already tracked,
+// on their own or for another owner, are left alone and nil is returned so the subscription never stops their syncs
+func (s *Service) trackOwnerRepository(ctx context.Context, repoName, ownerID string) (*repository.GithubRepository, error) {
+	_, err := s.repo.CreateRepository(ctx, repoName)
+	if errors.Is(err, repository.ErrConflict) {
+		s.logger.DebugContext(ctx, "skipping-repo-tracked-on-its-own",
+			slog.String(logging.RepositoryKey, repoName),
+		)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to track repository (%s): %w", repoName, err)
+	}
+
+	repo, err := s.repo.GetRepositoryByName(ctx, repoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository (%s): %w", repoName, err)
+	}
+	if err := s.repo.UpdateRepositoryOwner(ctx, repo.ID, ownerID); err != nil {
+		return nil, fmt.Errorf("failed to update owner of repository (%s): %w", repoName, err)
+	}
+	repo.OwnerID = &ownerID
+
+	return &repo, nil
+}
+
+// listOwnerRepositories lists every repository of the owner name, waiting for the rate limit to reset when it is reached
+func (s *Service) listOwnerRepositories(ctx context.Context, name repository.OwnerName) ([]provider.OwnerRepository, error) {
+	var (
+		repos  []provider.OwnerRepository
+		cursor string
+	)
+	backoffDuration := s.config.Backoff
+
+	for {
+		page, err := s.owners.ListOwnerRepositories(ctx, name.Kind, name.Login, cursor)
+		if errors.Is(err, provider.ErrRateLimitReached) {
+
+			backoffDuration *= 2
+
+			s.logger.WarnContext(ctx, "rate-limit-reached:retrying-after-backoff",
+				slog.String("backoffDuration", backoffDuration.String()),
+			)
+
+			if err := s.wait(ctx, backoffDuration); err != nil {
+				return nil, err
+			}
+			// retry after the backoff period
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of owner (%s): %w", name, err)
+		}
+
+		repos = append(repos, page.Repositories...)
+		if page.Next == "" {
+			return repos, nil
+		}
+		cursor = page.Next
+	}
+}
@@ -0,0 +1,133 @@
+package githubrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/logging"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// SyncProgress represents the progress of a repo sync after a page of commits is saved
+type SyncProgress struct {
+	Page    int
+	Commits int
+	// Total is the number of commits saved by the sync so far
+	Total int
+}
+
+// Sync syncs the metadata and new commits of repoName once in the foreground, the repo is tracked first if it is not.
+// progress is called after every page of commits when it is not nil
+func (s *Service) Sync(ctx context.Context, repoName string, progress func(SyncProgress)) error {
+	ctx = logging.With(ctx, slog.String(logging.RepositoryKey, repoName))
+	githubRepo, err := s.repo.GetRepositoryByName(ctx, repoName)
+	if errors.Is(err, repository.ErrNotFound) {
+		_, err = s.repo.CreateRepository(ctx, repoName)
+		if err != nil && !errors.Is(err, repository.ErrConflict) {
+			return fmt.Errorf("failed to track repository (%s): %w", repoName, err)
+		}
+		githubRepo, err = s.repo.GetRepositoryByName(ctx, repoName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get repository (%s): %w", repoName, err)
+	}
+
+	return s.trackRepo(ctx, &githubRepo, progress)
+}
+
+// StartWorker fires of the watcher without the listener, which only sees repos tracked in this process.
+// repos tracked through the api of other processes are picked up every PendingInterval instead
+func (s *Service) StartWorker(ctx context.Context) error {
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.StartReposWatcher(ctx)
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.StartPendingReposPoller(ctx)
+	}()
+
+	return nil
+}
+
+// StartPendingReposPoller syncs repos which appeared since the last poll and have never been synced.
+// repos found on the first poll are left to the watcher, every repo is synced at most once by the poller
+// so a repo without commits is not synced on every poll
+func (s *Service) StartPendingReposPoller(ctx context.Context) {
+	seen := map[string]bool{}
+	first := true
+	for {
+		repos, err := s.repo.GetRepositories(ctx)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			s.logger.ErrorContext(ctx, "error-retrieving-repos",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		// owners subscribed since the last poll are listed, the repos they start tracking are synced on the next poll
+		s.syncPendingOwners(ctx, seen, first)
+
+		for _, repo := range repos {
+			if seen[repo.ID] {
+				continue
+			}
+			seen[repo.ID] = true
+			if first || repo.CommitLastPulledTime != nil || repo.DeletedAt != nil {
+				continue
+			}
+
+			s.wg.Add(1)
+			go func(repo *repository.GithubRepository) {
+				defer s.wg.Done()
+				repoCtx := logging.With(ctx, slog.String(logging.RepositoryKey, repo.RepositoryName))
+				s.logger.InfoContext(repoCtx, "syncing-pending-repo")
+				if err := s.trackRepo(repoCtx, repo, nil); err != nil {
+					s.logger.ErrorContext(repoCtx, "error-tracking-repo",
+						slog.String("error", err.Error()),
+					)
+				}
+			}(repo)
+		}
+		first = false
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.config.PendingInterval):
+		}
+	}
+}
+
+// syncPendingOwners lists the repositories of the owners which were never synced and are not in seen, adding them to seen.
+// owners found on the first poll are left to the watcher
+func (s *Service) syncPendingOwners(ctx context.Context, seen map[string]bool, first bool) {
+	owners, err := s.repo.GetOwners(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "error-retrieving-owners",
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	for _, owner := range owners {
+		if seen[owner.ID] {
+			continue
+		}
+		seen[owner.ID] = true
+		if first || owner.LastSyncedAt != nil {
+			continue
+		}
+
+		ownerCtx := logging.With(ctx, slog.String(logging.OwnerKey, owner.Name))
+		s.logger.InfoContext(ownerCtx, "syncing-pending-owner")
+		if _, err := s.syncOwner(ownerCtx, owner); err != nil {
+			s.logger.ErrorContext(ownerCtx, "error-syncing-owner",
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
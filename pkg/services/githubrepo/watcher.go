@@ -2,133 +2,154 @@ package githubrepo
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
+	"sync"
 	"time"
 
-	"github.com/danielboakye/github-repo-stats/pkg/db/postgres"
+	"github.com/danielboakye/github-repo-stats/pkg/logging"
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/danielboakye/github-repo-stats/pkg/provider"
 	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/danielboakye/github-repo-stats/pkg/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	githubAPIURL                   = "https://api.github.com"
-	githubCommitsMaxRecordsPerPage = 100
-
-	defaultBackoffDuration = 1 * time.Minute
+	// pageSaveTimeout bounds saving a fetched page of commits, which is not interrupted by shutdown
+	pageSaveTimeout = 30 * time.Second
 
 	// ISODateFormat represents ISO 8601 format: YYYY-MM-DDTHH:MM:SSZ
 	ISODateFormat = "2006-01-02T15:04:05Z"
 )
 
-// GithubRepositoryResponse represents repository http response from github api we are interested in
-type GithubRepositoryResponse struct {
-	Description      string `json:"description"`
-	URL              string `json:"html_url"`
-	Language         string `json:"language"`
-	ForksCount       int    `json:"forks_count"`
-	StarsCount       int    `json:"stargazers_count"`
-	OpenIssuesCount  int    `json:"open_issues"`
-	SubscribersCount int    `json:"subscribers_count"`
-}
-
-// GithubCommitAuthor represents author in GithubCommitDetails
-type GithubCommitAuthor struct {
-	Name  string    `json:"name"`
-	Email string    `json:"email"`
-	Date  time.Time `json:"date"`
-}
-
-// GithubCommitDetails represents commit details in GithubCommitResponse
-type GithubCommitDetails struct {
-	Message string             `json:"message"`
-	Author  GithubCommitAuthor `json:"author"`
-}
+// Start fires of listeners for the service, they run until ctx is cancelled
+func (s *Service) Start(ctx context.Context) error {
+	s.listening.Store(true)
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.StartNewReposListener(ctx)
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.StartReposWatcher(ctx)
+	}()
 
-// GithubCommitResponse represents commit http response
-type GithubCommitResponse struct {
-	SHA    string              `json:"sha"`
-	Commit GithubCommitDetails `json:"commit"`
-	URL    string              `json:"html_url"`
+	return nil
 }
 
-var (
-	// ErrRateLimitReached represents rate limit reached error
-	ErrRateLimitReached = fmt.Errorf("rate limit error")
-)
+// Stop stops accepting new repos and waits until ctx is done for the listeners and in-flight syncs to return,
+// the context passed to Start must be cancelled first for them to return
+func (s *Service) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stopped) })
+	s.logger.InfoContext(ctx, "stopping-service")
 
-// Start fires of listeners for the service
-func (s *Service) Start(ctx context.Context) error {
-	go s.StartNewReposListener(ctx)
-	go s.StartReposWatcher(ctx)
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
 
-	return nil
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop service: %w", ctx.Err())
+	}
 }
 
-// StartNewReposListener starts a listens for new repositories and initiates a watch on it
+// StartNewReposListener starts a listens for new repositories and initiates a watch on it,
+// the repositories of newly subscribed owners are listed and queued as well
 func (s *Service) StartNewReposListener(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case repoName := <-s.newRepo:
-			go func(repoName string) {
-				innerCtx, cancel := context.WithCancel(ctx)
+		case job := <-s.newRepo:
+			metrics.QueueDepth.Dec()
+			s.wg.Add(1)
+			go func(job trackJob) {
+				defer s.wg.Done()
+				// the sync outlives the request that queued it but keeps its logging attributes
+				innerCtx, cancel := context.WithCancel(logging.With(ctx, job.attrs...))
 				defer cancel()
 
-				githubRepo, err := s.repo.GetRepositoryByName(innerCtx, repoName)
+				if job.ownerName != "" {
+					if err := s.SyncOwner(innerCtx, job.ownerName); err != nil {
+						s.logger.ErrorContext(innerCtx, "error-syncing-owner",
+							slog.String("error", err.Error()),
+						)
+					}
+					return
+				}
+
+				githubRepo, err := s.repo.GetRepositoryByName(innerCtx, job.repoName)
 				if err != nil {
-					s.logger.Error("error-getting-repo",
-						slog.String("repoName", repoName),
+					s.logger.ErrorContext(innerCtx, "error-getting-repo",
 						slog.String("error", err.Error()),
 					)
 					return
 				}
 
-				if err := s.trackRepo(innerCtx, &githubRepo); err != nil {
-					s.logger.Error("error-tracking-repo",
-						slog.String("repoName", repoName),
+				if err := s.trackRepo(innerCtx, &githubRepo, nil); err != nil {
+					s.logger.ErrorContext(innerCtx, "error-tracking-repo",
 						slog.String("error", err.Error()),
 					)
 					return
 				}
-			}(repoName)
+			}(job)
 		}
 	}
 }
 
 // StartReposWatcher starts the water for pulling commits and repo information
 func (s *Service) StartReposWatcher(ctx context.Context) {
-	if err := s.trackAllRepos(ctx); err != nil {
-		s.logger.Error("error-tracking-repos",
-			slog.String("error", err.Error()),
-		)
-	}
 	for {
-		select {
-		case <-ctx.Done():
+		s.beat()
+		if err := s.trackAllRepos(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "error-tracking-repos",
+				slog.String("error", err.Error()),
+			)
+		}
+		if err := s.wait(ctx, s.config.SyncInterval); err != nil {
 			return
-		case <-time.After(1 * time.Hour):
-			if err := s.trackAllRepos(ctx); err != nil {
-				s.logger.Error("error-tracking-repos",
-					slog.String("error", err.Error()),
-				)
-			}
 		}
 	}
 }
 
-func (s *Service) trackRepo(ctx context.Context, repo *repository.GithubRepository) error {
+// trackRepo syncs the metadata and new commits of repo, progress is called after every page of commits when it is not nil
+func (s *Service) trackRepo(ctx context.Context, repo *repository.GithubRepository, progress func(SyncProgress)) (err error) {
+	select {
+	case s.syncs <- struct{}{}:
+		defer func() { <-s.syncs }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "githubrepo.trackRepo",
+		trace.WithAttributes(attribute.String(logging.RepositoryKey, repo.RepositoryName)),
+	)
+	defer func(start time.Time) {
+		metrics.ObserveSync(repo.RepositoryName, start, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	p, path, err := s.provider(repo)
+	if err != nil {
+		return err
+	}
+
 	// update repo meta data
-	if err := s.updateRepoInformation(ctx, repo); err != nil {
+	if err := s.updateRepoInformation(ctx, p, path, repo); err != nil {
 		return fmt.Errorf("failed tracking repo metadata: %w", err)
 	}
 
 	// load commits
-	if err := s.trackCommits(ctx, repo); err != nil {
+	if err := s.trackCommits(ctx, p, path, repo, progress); err != nil {
 		return fmt.Errorf("failed tracking commits: %w", err)
 	}
 
@@ -136,83 +157,94 @@ func (s *Service) trackRepo(ctx context.Context, repo *repository.GithubReposito
 }
 
 func (s *Service) trackAllRepos(ctx context.Context) error {
-	s.logger.Info("tracking-all-repos")
+	// repos of subscribed owners are tracked or marked as deleted before all repos are synced
+	s.syncAllOwners(ctx)
+
+	s.logger.InfoContext(ctx, "tracking-all-repos")
 	// get names of all repos from db
 	repos, err := s.repo.GetRepositories(ctx)
-	if errors.Is(err, postgres.ErrRecordNotFound) {
-		s.logger.Warn("no-repos-to-watch")
+	if errors.Is(err, repository.ErrNotFound) {
+		s.logger.WarnContext(ctx, "no-repos-to-watch")
 		return nil
 	}
 	if err != nil {
-		s.logger.Error("error-retrieving-repos",
+		s.logger.ErrorContext(ctx, "error-retrieving-repos",
 			slog.String("error", err.Error()),
 		)
 		// send some sort of alert
 		return nil
 	}
 
+	// repos are synced concurrently, trackRepo limits how many run at once
+	var wg sync.WaitGroup
 	for _, repo := range repos {
-		if err := s.trackRepo(ctx, repo); err != nil {
-			s.logger.Error("error-tracking-repo",
-				slog.String("repoID", repo.ID),
-				slog.String("repoName", repo.RepositoryName),
-				slog.String("error", err.Error()),
-			)
+		// deleted repos are no longer listed for their owner and are kept for their stats only
+		if repo.DeletedAt != nil {
+			continue
 		}
+		wg.Add(1)
+		go func(repo *repository.GithubRepository) {
+			defer wg.Done()
+			repoCtx := logging.With(ctx, slog.String(logging.RepositoryKey, repo.RepositoryName))
+			if err := s.trackRepo(repoCtx, repo, nil); err != nil {
+				s.logger.ErrorContext(repoCtx, "error-tracking-repo",
+					slog.String("repoID", repo.ID),
+					slog.String("error", err.Error()),
+				)
+			}
+		}(repo)
 	}
+	wg.Wait()
 
 	return nil
 }
 
-func (s *Service) fetchRepoWithRetry(ctx context.Context, repoName string) (GithubRepositoryResponse, error) {
+func (s *Service) fetchRepoWithRetry(ctx context.Context, p provider.Provider, path string) (provider.Repository, error) {
 	var (
-		ghRepo GithubRepositoryResponse
-		err    error
+		repo provider.Repository
+		err  error
 	)
-	backoffDuration := defaultBackoffDuration
+	backoffDuration := s.config.Backoff
 
 	for {
-		ghRepo, err = s.fetchRepoData(ctx, repoName)
-		if errors.Is(err, ErrRateLimitReached) {
+		repo, err = p.FetchRepository(ctx, path)
+		if errors.Is(err, provider.ErrRateLimitReached) {
 
 			backoffDuration *= 2
 
-			s.logger.Warn("rate-limit-reached:retrying-after-backoff",
-				slog.String("repoName", repoName),
+			s.logger.WarnContext(ctx, "rate-limit-reached:retrying-after-backoff",
 				slog.String("backoffDuration", backoffDuration.String()),
 			)
 
-			select {
-			case <-ctx.Done():
-				return ghRepo, ctx.Err()
-			case <-time.After(backoffDuration):
-				// retry after the backoff period
-				continue
+			if err := s.wait(ctx, backoffDuration); err != nil {
+				return repo, err
 			}
+			// retry after the backoff period
+			continue
 		}
 		if err != nil {
-			return ghRepo, fmt.Errorf("error fetching repo metadata: %w", err)
+			return repo, fmt.Errorf("error fetching repo metadata: %w", err)
 		}
 
 		break
 	}
 
-	return ghRepo, nil
+	return repo, nil
 }
 
-func (s *Service) updateRepoInformation(ctx context.Context, gr *repository.GithubRepository) error {
-	ghRepo, err := s.fetchRepoWithRetry(ctx, gr.RepositoryName)
+func (s *Service) updateRepoInformation(ctx context.Context, p provider.Provider, path string, gr *repository.GithubRepository) error {
+	repo, err := s.fetchRepoWithRetry(ctx, p, path)
 	if err != nil {
 		return fmt.Errorf("error fetching commits with retry mechanism: %w", err)
 	}
 
-	gr.Description = &ghRepo.Description
-	gr.URL = &ghRepo.URL
-	gr.Language = &ghRepo.Language
-	gr.ForksCount = ghRepo.ForksCount
-	gr.StarsCount = ghRepo.StarsCount
-	gr.OpenIssuesCount = ghRepo.OpenIssuesCount
-	gr.WatchersCount = ghRepo.SubscribersCount
+	gr.Description = &repo.Description
+	gr.URL = &repo.URL
+	gr.Language = &repo.Language
+	gr.ForksCount = repo.ForksCount
+	gr.StarsCount = repo.StarsCount
+	gr.OpenIssuesCount = repo.OpenIssuesCount
+	gr.WatchersCount = repo.WatchersCount
 
 	if err := s.repo.UpdateRepository(ctx, gr); err != nil {
 		return fmt.Errorf("failed to update repo (%s): %w", gr.ID, err)
@@ -221,142 +253,92 @@ func (s *Service) updateRepoInformation(ctx context.Context, gr *repository.Gith
 	return nil
 }
 
-func (s *Service) fetchRepoData(ctx context.Context, repositoryName string) (GithubRepositoryResponse, error) {
-	var repo GithubRepositoryResponse
-	url := fmt.Sprintf("%s/repos/%s", githubAPIURL, repositoryName)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return repo, fmt.Errorf("error fetching from github: %w", err)
-	}
-
-	req.Header.Add("Accept", "application/vnd.github+json")
-	req.Header.Set("User-Agent", "go-github-fetcher")
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return repo, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusForbidden {
-		return repo, ErrRateLimitReached
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return repo, fmt.Errorf("failed to fetch commits: %s", resp.Status)
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
-		return repo, err
-	}
-
-	return repo, nil
-}
-
-func (s *Service) trackCommits(ctx context.Context, repo *repository.GithubRepository) error {
-	page := 1
-	backoffDuration := defaultBackoffDuration
+func (s *Service) trackCommits(ctx context.Context, p provider.Provider, path string, repo *repository.GithubRepository, progress func(SyncProgress)) error {
+	page, total := 1, 0
+	var cursor string
+	backoffDuration := s.config.Backoff
 	var since *time.Time
-	// set since to default in flags
-	if !s.commitSinceDate.IsZero() {
-		since = &s.commitSinceDate
+	// set since to the configured default
+	if !s.config.Since.IsZero() {
+		since = &s.config.Since
 	}
 	// if a first set of commits have been pulled. update since to last commit date
 	// so previous set of commits are ignored
 	if repo.CommitLastPulledTime != nil {
 		since = repo.CommitLastPulledTime
-		s.logger.Debug("fetching-commits-with-updated-since", slog.String("since", since.Format(ISODateFormat)))
+		s.logger.DebugContext(ctx, "fetching-commits-with-updated-since", slog.String("since", since.Format(ISODateFormat)))
 	}
 
 	for {
-		numberProcessed, err := s.processUntrackedCommits(ctx, repo.ID, repo.RepositoryName, page, since)
-		if errors.Is(err, ErrRateLimitReached) {
+		numberProcessed, next, err := s.processUntrackedCommits(ctx, p, path, repo, page, cursor, since)
+		if errors.Is(err, provider.ErrRateLimitReached) {
 
 			backoffDuration *= 2
 
-			s.logger.Warn("rate-limit-reached:retrying-after-backoff",
-				slog.String("repoName", repo.RepositoryName),
+			s.logger.WarnContext(ctx, "rate-limit-reached:retrying-after-backoff",
 				slog.Int("page", page),
 				slog.String("since", since.Format(ISODateFormat)),
 				slog.String("backoffDuration", backoffDuration.String()),
 			)
 
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(backoffDuration):
-				// retry after the backoff period
-				continue
+			if err := s.wait(ctx, backoffDuration); err != nil {
+				return err
 			}
+			// retry after the backoff period
+			continue
 		}
 		if err != nil {
 			return fmt.Errorf("error fetching commits: %w", err)
 		}
-		if numberProcessed < githubCommitsMaxRecordsPerPage {
+		s.beat()
+		total += numberProcessed
+		if progress != nil {
+			progress(SyncProgress{Page: page, Commits: numberProcessed, Total: total})
+		}
+		if next == "" {
 			return nil
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-		page = page + 1
+		page, cursor = page+1, next
 	}
 }
 
-func (s *Service) processUntrackedCommits(ctx context.Context, repoID, repoName string, page int, since *time.Time) (int, error) {
-	var numberProcessed int
-	url := fmt.Sprintf("%s/repos/%s/commits?page=%d&per_page=%d", githubAPIURL, repoName, page, githubCommitsMaxRecordsPerPage)
-	if since != nil {
-		url += fmt.Sprintf("&since=%s", since.Format(ISODateFormat))
-	}
-
-	s.logger.Info("fetch-commits", slog.String("url", url))
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return numberProcessed, fmt.Errorf("error fetching from github: %w", err)
-	}
+// processUntrackedCommits saves the page at cursor of the commits of repo listed by p and returns the cursor of the next page,
+// page counts the pages of the sync for logging
+func (s *Service) processUntrackedCommits(ctx context.Context, p provider.Provider, path string, repo *repository.GithubRepository, page int, cursor string, since *time.Time) (int, string, error) {
+	s.logger.InfoContext(ctx, "fetch-commits",
+		slog.String("provider", string(p.Name())),
+		slog.Int("page", page),
+	)
 
-	req.Header.Add("Accept", "application/vnd.github+json")
-	req.Header.Set("User-Agent", "go-github-fetcher")
-	resp, err := s.httpClient.Do(req)
+	commitPage, err := p.ListCommits(ctx, path, since, cursor)
 	if err != nil {
-		return numberProcessed, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusForbidden {
-		return numberProcessed, ErrRateLimitReached
+		return 0, "", err
 	}
+	commits := commitPage.Commits
 
-	if resp.StatusCode != http.StatusOK {
-		return numberProcessed, fmt.Errorf("failed to fetch commits: %s", resp.Status)
-	}
-
-	var commits []*GithubCommitResponse
-	decoder := json.NewDecoder(resp.Body)
-	err = decoder.Decode(&commits)
-	if err != nil {
-		return numberProcessed, err
-	}
+	// a fetched page is saved even when ctx is cancelled so a shutdown does not leave it half written
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), pageSaveTimeout)
+	defer cancel()
 
 	for _, commit := range commits {
-		if err := s.repo.SaveCommit(ctx, repository.GithubCommit{
-			ID:           uuid.New().String(),
-			RepositoryID: repoID,
-			CommitHash:   commit.SHA,
-			Message:      commit.Commit.Message,
-			AuthorName:   commit.Commit.Author.Name,
-			AuthorEmail:  commit.Commit.Author.Email,
-			Date:         commit.Commit.Author.Date,
-			URL:          commit.URL,
-		}); err != nil {
-			return numberProcessed, fmt.Errorf("failed to save new commit: %w", err)
+		commit.ID = uuid.New().String()
+		commit.RepositoryID = repo.ID
+		if err := s.repo.SaveCommit(saveCtx, commit); err != nil {
+			return 0, "", fmt.Errorf("failed to save new commit: %w", err)
 		}
+		metrics.CommitsIngested.WithLabelValues(repo.RepositoryName).Inc()
 	}
 
 	if len(commits) > 0 {
-		lastSyncTime := commits[len(commits)-1].Commit.Author.Date
-		if err := s.repo.UpdateCommitLastSyncTime(ctx, repoID, lastSyncTime); err != nil {
-			return numberProcessed, fmt.Errorf("failed to update last sync time: %w", err)
+		lastSyncTime := commits[len(commits)-1].Date
+		if err := s.repo.UpdateCommitLastSyncTime(saveCtx, repo.ID, lastSyncTime); err != nil {
+			return 0, "", fmt.Errorf("failed to update last sync time: %w", err)
 		}
 	}
 
-	return len(commits), nil
+	return len(commits), commitPage.Next, nil
 }
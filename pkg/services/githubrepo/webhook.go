@@ -0,0 +1,63 @@
+package githubrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/danielboakye/github-repo-stats/pkg/logging"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// ErrWebhookDisabled is returned for webhook deliveries of providers without a webhook secret
+var ErrWebhookDisabled = errors.New("webhooks are disabled")
+
+// WebhookResult represents the outcome of a webhook delivery
+type WebhookResult struct {
+	Event          string `json:"event"`
+	RepositoryName string `json:"repository_name,omitempty"`
+	// Queued reports whether a sync of the repo was queued, only pushes to tracked repos are synced
+	Queued bool `json:"queued"`
+}
+
+// HandleWebhook verifies a webhook delivery of providerName and queues a sync of the repo commits were pushed to.
+// untracked repos are not tracked by webhooks
+func (s *Service) HandleWebhook(ctx context.Context, providerName repository.Provider, r *http.Request) (WebhookResult, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return WebhookResult{}, fmt.Errorf("provider %s is not supported", providerName)
+	}
+	secret := s.webhookSecrets[providerName]
+	if secret == "" {
+		return WebhookResult{}, fmt.Errorf("%s %w", providerName, ErrWebhookDisabled)
+	}
+
+	event, err := p.ParseWebhook(r, secret)
+	if err != nil {
+		return WebhookResult{}, err
+	}
+
+	result := WebhookResult{Event: event.Event}
+	if event.Path == "" {
+		return result, nil
+	}
+	result.RepositoryName = repository.RepoName{Provider: providerName, Path: event.Path}.String()
+	if !event.Push {
+		return result, nil
+	}
+
+	ctx = logging.With(ctx, slog.String(logging.RepositoryKey, result.RepositoryName))
+	_, err = s.repo.GetRepositoryByName(ctx, result.RepositoryName)
+	if errors.Is(err, repository.ErrNotFound) {
+		s.logger.InfoContext(ctx, "webhook-for-untracked-repo")
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to get repository (%s): %w", result.RepositoryName, err)
+	}
+
+	result.Queued = s.enqueue(ctx, result.RepositoryName)
+	return result, nil
+}
@@ -0,0 +1,306 @@
+// Package gitimport imports the commits of local git clones and bare repositories by reading git log
+package gitimport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/logging"
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// Config represents the settings of the importer
+type Config struct {
+	// GitPath is the git executable, looked up in PATH when it is not a path
+	GitPath string
+	// ProgressInterval is the number of commits saved between progress reports
+	ProgressInterval int
+}
+
+// DefaultConfig returns the settings used when none are configured
+func DefaultConfig() Config {
+	return Config{
+		GitPath:          "git",
+		ProgressInterval: 1000,
+	}
+}
+
+// Options represents what is imported from a git repository
+type Options struct {
+	// Rev is the revision whose history is imported, HEAD when it is empty
+	Rev string
+	// URLPrefix is prepended to commit hashes to build commit urls, the commit url prefix of the repo on the public
+	// instance of its provider when it is empty, e.g. https://github.com/{owner}/{name}/commit/
+	URLPrefix string
+}
+
+// defaultURLPrefixes are the commit url prefixes of repos on the public instance of each provider
+var defaultURLPrefixes = map[repository.Provider]string{
+	repository.ProviderGithub:    "https://github.com/%s/commit/",
+	repository.ProviderGitlab:    "https://gitlab.com/%s/-/commit/",
+	repository.ProviderGitea:     "https://gitea.com/%s/commit/",
+	repository.ProviderBitbucket: "https://bitbucket.org/%s/commits/",
+}
+
+// Progress represents the progress of an import
+type Progress struct {
+	// Commits is the number of commits read so far
+	Commits int
+}
+
+// Importer represents the git import service
+type Importer struct {
+	repo   repository.Repository
+	logger *slog.Logger
+	config Config
+}
+
+// NewImporter initiates a new git importer
+func NewImporter(repo repository.Repository, logger *slog.Logger, config Config) *Importer {
+	return &Importer{
+		repo:   repo,
+		logger: logger,
+		config: config,
+	}
+}
+
+const (
+	// recordSeparator starts every commit in the git log output
+	recordSeparator = '\x1e'
+	// fieldSeparator ends every field of logFormat
+	fieldSeparator = "\x1f"
+	// logFormat prints the fields read by parseCommit, the numstat lines of the commit follow the last separator
+	logFormat = "%x1e%H%x1f%P%x1f%an%x1f%ae%x1f%aI%x1f%cn%x1f%ce%x1f%cI%x1f%B%x1f"
+	// logFields is the number of fields of logFormat
+	logFields = 9
+)
+
+// Import saves every commit reachable from opts.Rev in the git repository at path as commits of repoName, which is
+// tracked first if it is not. commits already saved are skipped so an import can be run again to pick up new commits.
+// the last sync time of the repository is moved forward to the newest imported commit so provider syncs continue from there.
+// progress is called every ProgressInterval commits and once at the end when it is not nil
+func (i *Importer) Import(ctx context.Context, path, repoName string, opts Options, progress func(Progress)) (int, error) {
+	ctx = logging.With(ctx, slog.String(logging.RepositoryKey, repoName))
+	rev := opts.Rev
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	// the revision is resolved first so a wrong path or revision does not track the repository
+	out, err := exec.CommandContext(ctx, i.config.GitPath, "-C", path, "rev-parse", "--verify", "--quiet", rev+"^{commit}").CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve %s in %s: %w: %s", rev, path, err, strings.TrimSpace(string(out)))
+	}
+
+	urlPrefix := opts.URLPrefix
+	if urlPrefix == "" {
+		name, err := repository.ParseRepoName(repoName)
+		if err != nil {
+			return 0, err
+		}
+		urlPrefix = fmt.Sprintf(defaultURLPrefixes[name.Provider], name.Path)
+	}
+
+	githubRepo, err := i.getOrCreateRepository(ctx, repoName)
+	if err != nil {
+		return 0, err
+	}
+
+	// quoted paths are turned off so only paths with control characters are quoted, renames are listed as
+	// a deletion and an addition so every numstat line has a single path
+	cmd := exec.CommandContext(ctx, i.config.GitPath,
+		"-C", path,
+		"-c", "core.quotePath=false",
+		"log", "--no-color", "--no-renames", "--numstat", "--format="+logFormat, rev, "--",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run git log: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to run git log: %w", err)
+	}
+
+	count, newest, readErr := i.saveCommits(ctx, bufio.NewReaderSize(stdout, 64*1024), githubRepo.ID, repoName, urlPrefix, progress)
+	if readErr != nil {
+		// git is killed so Wait does not block on a full pipe
+		_ = cmd.Process.Kill()
+	}
+	if err := cmd.Wait(); err != nil && readErr == nil {
+		return count, fmt.Errorf("git log failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if readErr != nil {
+		return count, readErr
+	}
+
+	lastSync := githubRepo.CommitLastPulledTime
+	if !newest.IsZero() && (lastSync == nil || newest.After(*lastSync)) {
+		if err := i.repo.UpdateCommitLastSyncTime(ctx, githubRepo.ID, newest); err != nil {
+			return count, fmt.Errorf("failed to update last sync time: %w", err)
+		}
+	}
+	if progress != nil {
+		progress(Progress{Commits: count})
+	}
+
+	i.logger.InfoContext(ctx, "import-complete",
+		slog.String("path", path),
+		slog.Int("commits", count),
+	)
+	return count, nil
+}
+
+// getOrCreateRepository returns repoName, tracking it first if it is not
+func (i *Importer) getOrCreateRepository(ctx context.Context, repoName string) (repository.GithubRepository, error) {
+	githubRepo, err := i.repo.GetRepositoryByName(ctx, repoName)
+	if errors.Is(err, repository.ErrNotFound) {
+		_, err = i.repo.CreateRepository(ctx, repoName)
+		if err != nil && !errors.Is(err, repository.ErrConflict) {
+			return githubRepo, fmt.Errorf("failed to track repository (%s): %w", repoName, err)
+		}
+		githubRepo, err = i.repo.GetRepositoryByName(ctx, repoName)
+	}
+	if err != nil {
+		return githubRepo, fmt.Errorf("failed to get repository (%s): %w", repoName, err)
+	}
+
+	return githubRepo, nil
+}
+
+// saveCommits saves every commit of the git log output r as it is read and returns the number of commits
+// and the newest author date
+func (i *Importer) saveCommits(ctx context.Context, r *bufio.Reader, repoID, repoName, urlPrefix string, progress func(Progress)) (int, time.Time, error) {
+	var newest time.Time
+
+	// everything before the first separator is empty
+	if _, err := r.ReadString(recordSeparator); err != nil {
+		if err == io.EOF {
+			return 0, newest, nil
+		}
+		return 0, newest, fmt.Errorf("failed to read git log: %w", err)
+	}
+
+	count := 0
+	for {
+		record, err := r.ReadString(recordSeparator)
+		if err != nil && err != io.EOF {
+			return count, newest, fmt.Errorf("failed to read git log: %w", err)
+		}
+		last := err == io.EOF
+
+		commit, parseErr := parseCommit(strings.TrimSuffix(record, string(recordSeparator)))
+		if parseErr != nil {
+			return count, newest, parseErr
+		}
+		commit.RepositoryID = repoID
+		commit.URL = urlPrefix + commit.CommitHash
+		if err := i.repo.SaveCommit(ctx, *commit); err != nil {
+			return count, newest, fmt.Errorf("failed to save commit: %w", err)
+		}
+		metrics.CommitsIngested.WithLabelValues(repoName).Inc()
+
+		count++
+		if commit.Date.After(newest) {
+			newest = commit.Date
+		}
+		if progress != nil && i.config.ProgressInterval > 0 && count%i.config.ProgressInterval == 0 {
+			progress(Progress{Commits: count})
+		}
+
+		if last {
+			return count, newest, nil
+		}
+	}
+}
+
+// parseCommit parses a commit printed with logFormat followed by its numstat lines
+func parseCommit(record string) (*repository.GithubCommit, error) {
+	fields := strings.SplitN(record, fieldSeparator, logFields+1)
+	if len(fields) != logFields+1 {
+		return nil, fmt.Errorf("failed to parse git log: expected %d fields, got %d", logFields, len(fields)-1)
+	}
+
+	authorDate, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse author date of %s: %w", fields[0], err)
+	}
+	committerDate, err := time.Parse(time.RFC3339, fields[7])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse committer date of %s: %w", fields[0], err)
+	}
+
+	commit := &repository.GithubCommit{
+		CommitHash:     fields[0],
+		Parents:        strings.Fields(fields[1]),
+		AuthorName:     fields[2],
+		AuthorEmail:    fields[3],
+		Date:           authorDate,
+		CommitterName:  fields[5],
+		CommitterEmail: fields[6],
+		CommitterDate:  &committerDate,
+		Message:        strings.TrimRight(fields[8], "\n"),
+	}
+
+	// merges have no diff in git log, their stats are unknown rather than empty
+	if commit.IsMerge() {
+		return commit, nil
+	}
+
+	stats := &repository.CommitDelta{}
+	for _, line := range strings.Split(fields[9], "\n") {
+		if line == "" {
+			continue
+		}
+		added, deleted, path, err := parseNumstat(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse changes of %s: %w", commit.CommitHash, err)
+		}
+		stats.Additions += added
+		stats.Deletions += deleted
+		stats.FilesChanged++
+		commit.Files = append(commit.Files, path)
+	}
+	commit.Stats = stats
+
+	return commit, nil
+}
+
+// parseNumstat parses a numstat line, binary files are listed with - instead of line counts and count as no lines
+func parseNumstat(line string) (added, deleted int, path string, err error) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 {
+		return 0, 0, "", fmt.Errorf("unexpected numstat line %q", line)
+	}
+	if parts[0] != "-" {
+		if added, err = strconv.Atoi(parts[0]); err != nil {
+			return 0, 0, "", fmt.Errorf("unexpected numstat line %q", line)
+		}
+	}
+	if parts[1] != "-" {
+		if deleted, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, "", fmt.Errorf("unexpected numstat line %q", line)
+		}
+	}
+
+	path = parts[2]
+	// paths with control characters are quoted even with core.quotePath off
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+	}
+
+	return added, deleted, path, nil
+}
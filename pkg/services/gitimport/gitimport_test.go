@@ -0,0 +1,253 @@
+package gitimport
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/db/memory"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitRepo represents a git repository created for a test
+type gitRepo struct {
+	t    *testing.T
+	path string
+}
+
+// newGitRepo creates an empty git repository, the test is skipped when git is not installed
+func newGitRepo(t *testing.T) *gitRepo {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	g := &gitRepo{t: t, path: t.TempDir()}
+	g.git(time.Time{}, "init", "-q", "-b", "main")
+	return g
+}
+
+// git runs a git command in the repository, commits are authored by alice and committed by bob at date
+func (g *gitRepo) git(date time.Time, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", g.path}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null",
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Alice",
+		"GIT_AUTHOR_EMAIL=alice@example.com",
+		"GIT_AUTHOR_DATE="+date.Format(time.RFC3339),
+		"GIT_COMMITTER_NAME=Bob",
+		"GIT_COMMITTER_EMAIL=bob@example.com",
+		"GIT_COMMITTER_DATE="+date.Add(time.Hour).Format(time.RFC3339),
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(g.t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+// commit writes files, a nil content removes the file, and commits them with message
+func (g *gitRepo) commit(date time.Time, message string, files map[string][]byte) string {
+	for name, content := range files {
+		path := filepath.Join(g.path, name)
+		if content == nil {
+			require.NoError(g.t, os.Remove(path))
+			continue
+		}
+		require.NoError(g.t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(g.t, os.WriteFile(path, content, 0o644))
+	}
+	g.git(date, "add", "-A")
+	g.git(date, "commit", "-q", "--allow-empty", "-m", message)
+	return g.git(date, "rev-parse", "HEAD")
+}
+
+func newTestImporter() (*Importer, repository.Repository) {
+	repo := memory.NewRepository()
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	return NewImporter(repo, logger, DefaultConfig()), repo
+}
+
+// go test -timeout 30s -run ^TestImport$ ./pkg/services/gitimport -v
+func TestImport(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	g := newGitRepo(t)
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	first := g.commit(base, "Initial commit\n\nwith a body", map[string][]byte{
+		"README.md":   []byte("hello\nworld\n"),
+		"src/main.go": []byte("package main\n"),
+		"logo.png":    {0, 1, 2, 0, 3},
+	})
+	g.git(base, "checkout", "-q", "-b", "feature")
+	feature := g.commit(base.Add(time.Hour), "Add feature", map[string][]byte{
+		"src/feature.go": []byte("package main\n\nfunc feature() {}\n"),
+	})
+	g.git(base, "checkout", "-q", "main")
+	second := g.commit(base.Add(2*time.Hour), "Update readme", map[string][]byte{
+		"README.md":   []byte("hello\n"),
+		"src/main.go": nil,
+	})
+	g.git(base.Add(3*time.Hour), "merge", "-q", "--no-ff", "-m", "Merge feature", "feature")
+	merge := g.git(base, "rev-parse", "HEAD")
+
+	importer, repo := newTestImporter()
+	var reports []Progress
+	count, err := importer.Import(ctx, g.path, "owner/repo", Options{}, func(p Progress) {
+		reports = append(reports, p)
+	})
+	require.NoError(err)
+	assert.Equal(4, count)
+	assert.Equal([]Progress{{Commits: 4}}, reports)
+
+	githubRepo, err := repo.GetRepositoryByName(ctx, "owner/repo")
+	require.NoError(err, "the repository is tracked by the import")
+	require.NotNil(githubRepo.CommitLastPulledTime)
+	assert.True(base.Add(3 * time.Hour).Equal(*githubRepo.CommitLastPulledTime))
+
+	commits, err := repo.GetCommitsByRepository(ctx, githubRepo.ID, repository.CommitFilter{}, repository.Page{Limit: 10})
+	require.NoError(err)
+	require.Len(commits, 4)
+	assert.Equal([]string{merge, second, feature, first}, []string{
+		commits[0].CommitHash, commits[1].CommitHash, commits[2].CommitHash, commits[3].CommitHash,
+	})
+
+	mergeCommit := commits[0]
+	assert.Equal([]string{second, feature}, mergeCommit.Parents)
+	assert.True(mergeCommit.IsMerge())
+	assert.Nil(mergeCommit.Stats, "merges have no stats")
+
+	firstCommit := commits[3]
+	assert.Equal("Initial commit\n\nwith a body", firstCommit.Message)
+	assert.Equal("Alice", firstCommit.AuthorName)
+	assert.Equal("alice@example.com", firstCommit.AuthorEmail)
+	assert.True(base.Equal(firstCommit.Date))
+	assert.Equal("Bob", firstCommit.CommitterName)
+	assert.Equal("bob@example.com", firstCommit.CommitterEmail)
+	require.NotNil(firstCommit.CommitterDate)
+	assert.True(base.Add(time.Hour).Equal(*firstCommit.CommitterDate))
+	assert.Equal("https://github.com/owner/repo/commit/"+first, firstCommit.URL)
+	assert.Empty(firstCommit.Parents)
+	// the binary file counts as a changed file without lines
+	assert.Equal(&repository.CommitDelta{Additions: 3, Deletions: 0, FilesChanged: 3}, firstCommit.Stats)
+	assert.Equal(&repository.CommitDelta{Additions: 0, Deletions: 2, FilesChanged: 2}, commits[1].Stats)
+
+	// changed files are saved for path filters
+	byPath, err := repo.GetCommitsByRepository(ctx, githubRepo.ID, repository.CommitFilter{Path: "src/main.go"}, repository.Page{Limit: 10})
+	require.NoError(err)
+	require.Len(byPath, 2)
+	assert.Equal(second, byPath[0].CommitHash)
+	assert.Equal(first, byPath[1].CommitHash)
+
+	// importing again only saves the new commits
+	third := g.commit(base.Add(4*time.Hour), "Add license", map[string][]byte{"LICENSE": []byte("MIT\n")})
+	count, err = importer.Import(ctx, g.path, "owner/repo", Options{}, nil)
+	require.NoError(err)
+	assert.Equal(5, count, "every commit is read again")
+	total, err := repo.CountCommitsByRepository(ctx, githubRepo.ID, repository.CommitFilter{})
+	require.NoError(err)
+	assert.Equal(5, total)
+	commits, err = repo.GetCommitsByRepository(ctx, githubRepo.ID, repository.CommitFilter{}, repository.Page{Limit: 1})
+	require.NoError(err)
+	assert.Equal(third, commits[0].CommitHash)
+}
+
+// go test -timeout 30s -run ^TestImportOptions$ ./pkg/services/gitimport -v
+func TestImportOptions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	g := newGitRepo(t)
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	first := g.commit(base, "Initial commit", map[string][]byte{"a.txt": []byte("a\n")})
+	g.git(base, "tag", "v1")
+	g.commit(base.Add(time.Hour), "Second commit", map[string][]byte{"b.txt": []byte("b\n")})
+
+	// bare clones are imported the same way
+	bare := t.TempDir()
+	g.git(base, "clone", "-q", "--bare", g.path, bare)
+
+	importer, repo := newTestImporter()
+	count, err := importer.Import(ctx, bare, "mirror/repo", Options{Rev: "v1", URLPrefix: "https://git.example.com/mirror/repo/-/commit/"}, nil)
+	require.NoError(err)
+	assert.Equal(1, count)
+
+	githubRepo, err := repo.GetRepositoryByName(ctx, "mirror/repo")
+	require.NoError(err)
+	commits, err := repo.GetCommitsByRepository(ctx, githubRepo.ID, repository.CommitFilter{}, repository.Page{Limit: 10})
+	require.NoError(err)
+	require.Len(commits, 1)
+	assert.Equal("https://git.example.com/mirror/repo/-/commit/"+first, commits[0].URL)
+
+	// commit urls default to the public instance of the provider of the repository
+	_, err = importer.Import(ctx, bare, "gitlab:group/subgroup/repo", Options{Rev: "v1"}, nil)
+	require.NoError(err)
+	githubRepo, err = repo.GetRepositoryByName(ctx, "gitlab:group/subgroup/repo")
+	require.NoError(err)
+	assert.Equal(repository.ProviderGitlab, githubRepo.Provider)
+	commits, err = repo.GetCommitsByRepository(ctx, githubRepo.ID, repository.CommitFilter{}, repository.Page{Limit: 10})
+	require.NoError(err)
+	require.Len(commits, 1)
+	assert.Equal("https://gitlab.com/group/subgroup/repo/-/commit/"+first, commits[0].URL)
+
+	// importing an older revision does not move the last sync time back
+	_, err = importer.Import(ctx, bare, "mirror/repo", Options{}, nil)
+	require.NoError(err)
+	_, err = importer.Import(ctx, bare, "mirror/repo", Options{Rev: "v1"}, nil)
+	require.NoError(err)
+	githubRepo, err = repo.GetRepositoryByName(ctx, "mirror/repo")
+	require.NoError(err)
+	assert.True(base.Add(time.Hour).Equal(*githubRepo.CommitLastPulledTime))
+
+	// nothing is tracked when the path is not a repository or the revision does not exist
+	_, err = importer.Import(ctx, t.TempDir(), "owner/empty", Options{}, nil)
+	assert.ErrorContains(err, "failed to resolve HEAD")
+	_, err = repo.GetRepositoryByName(ctx, "owner/empty")
+	assert.ErrorIs(err, repository.ErrNotFound)
+
+	_, err = importer.Import(ctx, bare, "mirror/missing", Options{Rev: "missing"}, nil)
+	assert.ErrorContains(err, "failed to resolve missing")
+	_, err = repo.GetRepositoryByName(ctx, "mirror/missing")
+	assert.ErrorIs(err, repository.ErrNotFound)
+}
+
+// go test -timeout 30s -run ^TestParseNumstat$ ./pkg/services/gitimport -v
+func TestParseNumstat(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		line    string
+		added   int
+		deleted int
+		path    string
+		err     bool
+	}{
+		{line: "3\t1\tsrc/main.go", added: 3, deleted: 1, path: "src/main.go"},
+		{line: "-\t-\tlogo.png", path: "logo.png"},
+		{line: "1\t0\tdocs/read me.md", added: 1, path: "docs/read me.md"},
+		{line: "1\t0\t\"tab\\there.txt\"", added: 1, path: "tab\there.txt"},
+		{line: "1\t0\t\"caf\\303\\251.txt\"", added: 1, path: "café.txt"},
+		{line: "x\t0\tfile", err: true},
+		{line: "1 0 file", err: true},
+	}
+	for _, tc := range testCases {
+		added, deleted, path, err := parseNumstat(tc.line)
+		if tc.err {
+			assert.Error(err, tc.line)
+			continue
+		}
+		assert.NoError(err, tc.line)
+		assert.Equal(tc.added, added, tc.line)
+		assert.Equal(tc.deleted, deleted, tc.line)
+		assert.Equal(tc.path, path, tc.line)
+	}
+}
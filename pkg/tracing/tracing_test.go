@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -timeout 30s -run ^TestEnabled$ ./pkg/tracing -v
+func TestEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+	assert.False(Enabled())
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	assert.True(Enabled())
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "none")
+	assert.False(Enabled())
+}
+
+// go test -timeout 30s -run ^TestSetupNoop$ ./pkg/tracing -v
+func TestSetupNoop(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	shutdown, err := Setup(context.Background())
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	// spans of the default provider are not recorded
+	_, span := Tracer().Start(context.Background(), "noop")
+	assert.False(t, span.IsRecording())
+	span.End()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/danielboakye/github-repo-stats/pkg/config"
+	"github.com/danielboakye/github-repo-stats/pkg/httpserver"
+	"github.com/danielboakye/github-repo-stats/pkg/metrics"
+	"github.com/danielboakye/github-repo-stats/pkg/services/githubrepo"
+	"github.com/danielboakye/github-repo-stats/pkg/tracing"
+)
+
+// mode represents the components run by the serve commands
+type mode struct {
+	api     bool
+	watcher bool
+}
+
+var (
+	modeAll    = mode{api: true, watcher: true}
+	modeAPI    = mode{api: true}
+	modeWorker = mode{watcher: true}
+)
+
+// serve runs the components of m until ctx is cancelled or the server fails, then shuts them down
+// within the shutdown timeout and releases the database and tracing.
+// without the api the server only serves health checks and metrics
+func serve(ctx context.Context, cfg config.Config, m mode, logger *slog.Logger) error {
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	store, err := openStore(ctx, cfg.Database, logger)
+	if err != nil {
+		return err
+	}
+	if conn := store.conn; conn != nil {
+		defer func() {
+			if err := conn.Close(); err != nil {
+				logger.Error("failed-closing-database", slog.String("error", err.Error()))
+			}
+		}()
+		if err := metrics.RegisterDB("db", conn); err != nil {
+			return fmt.Errorf("failed to register database metrics: %w", err)
+		}
+	}
+
+	svcCtx, cancelSvc := context.WithCancel(ctx)
+	defer cancelSvc()
+
+	githubSvc := githubrepo.NewService(store.repo, logger, cfg.Service())
+	if m.watcher {
+		start := githubSvc.Start
+		if !m.api {
+			start = githubSvc.StartWorker
+		}
+		if err := start(svcCtx); err != nil {
+			return fmt.Errorf("failed to start background service: %w", err)
+		}
+
+		logger.Info("starting-watcher",
+			slog.String("since", cfg.Sync.Since),
+		)
+	}
+
+	addr := cfg.Server.Addr()
+	apiServer := httpserver.NewServer(addr, cfg.Server.Timeouts(), store.repo, githubSvc, httpserver.Options{
+		DB:       store.conn,
+		Migrator: store.migrator,
+		API:      m.api,
+		Watcher:  m.watcher,
+	}, logger)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- apiServer.Start()
+	}()
+
+	var startErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("shutting-down", slog.String("timeout", cfg.Server.ShutdownTimeout.String()))
+	case err := <-serverErr:
+		if err != nil {
+			startErr = fmt.Errorf("failed to start http server on %s: %w", addr, err)
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// requests are drained before the watcher stops so they can still queue repos,
+	// queued repos which are not synced yet are picked up by the watcher after the next start
+	if err := apiServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed-shutting-down-server", slog.String("error", err.Error()))
+	}
+	cancelSvc()
+	if err := githubSvc.Stop(shutdownCtx); err != nil {
+		logger.Error("failed-stopping-service", slog.String("error", err.Error()))
+	}
+	if startErr != nil {
+		return startErr
+	}
+
+	logger.Info("shutdown-complete")
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/danielboakye/github-repo-stats/pkg/config"
+	"github.com/danielboakye/github-repo-stats/pkg/db/memory"
+	"github.com/danielboakye/github-repo-stats/pkg/db/migrate"
+	"github.com/danielboakye/github-repo-stats/pkg/db/postgres"
+	"github.com/danielboakye/github-repo-stats/pkg/db/sqlite"
+	"github.com/danielboakye/github-repo-stats/pkg/repository"
+)
+
+// resolveStore returns store if set, otherwise the store selected by the scheme of dsn
+func resolveStore(store, dsn string) string {
+	if store != "" {
+		return store
+	}
+
+	scheme, _, _ := strings.Cut(dsn, ":")
+	switch scheme {
+	case sqlite.URLScheme:
+		return config.StoreSQLite
+	case config.StoreMemory:
+		return config.StoreMemory
+	default:
+		return config.StorePostgres
+	}
+}
+
+// openSQL opens the sql store of cfg and returns its connection and migrator
+func openSQL(ctx context.Context, cfg config.DatabaseConfig, logger *slog.Logger) (*sql.DB, *migrate.Migrator, error) {
+	store, dsn := resolveStore(cfg.Store, cfg.URL), cfg.URL
+	var (
+		conn        *sql.DB
+		newMigrator func(*sql.DB) (*migrate.Migrator, error)
+		err         error
+	)
+
+	switch store {
+	case config.StorePostgres:
+		conn, err = postgres.NewConnection(ctx, postgres.Config{
+			URL:        dsn,
+			Pool:       cfg.Pool(),
+			Retry:      cfg.Retry(),
+			UsePgxPool: cfg.PgxPool,
+		}, logger)
+		newMigrator = postgres.NewMigrator
+	case config.StoreSQLite:
+		conn, err = sqlite.NewConnection(dsn)
+		newMigrator = sqlite.NewMigrator
+	default:
+		return nil, nil, fmt.Errorf("store %q does not use a sql database", store)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start %s db: %w", store, err)
+	}
+
+	migrator, err := newMigrator(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return conn, migrator, nil
+}
+
+// openedStore represents an opened storage backend,
+// conn and migrator are nil for stores without a database
+type openedStore struct {
+	repo     repository.Repository
+	conn     *sql.DB
+	migrator *migrate.Migrator
+}
+
+// openStore opens the storage backend selected by the store setting or the database url scheme
+func openStore(ctx context.Context, cfg config.DatabaseConfig, logger *slog.Logger) (openedStore, error) {
+	store := resolveStore(cfg.Store, cfg.URL)
+	switch store {
+	case config.StoreMemory:
+		logger.Warn("using-memory-store", slog.String("info", "data is lost when the process exits"))
+		return openedStore{repo: memory.NewRepository()}, nil
+	case config.StorePostgres, config.StoreSQLite:
+		conn, migrator, err := openSQL(ctx, cfg, logger)
+		if err != nil {
+			return openedStore{}, err
+		}
+
+		if cfg.AutoMigrate {
+			applied, err := migrator.Up(ctx)
+			if err != nil {
+				conn.Close()
+				return openedStore{}, fmt.Errorf("failed to apply migrations: %w", err)
+			}
+			logger.Info("migrations-applied", slog.Int("count", len(applied)))
+		}
+
+		opened := openedStore{conn: conn, migrator: migrator}
+		switch store {
+		case config.StoreSQLite:
+			opened.repo = sqlite.NewRepository(conn)
+		default:
+			opened.repo = postgres.NewRepository(conn)
+		}
+		return opened, nil
+	default:
+		return openedStore{}, fmt.Errorf("unknown store %q, must be one of: %s, %s, %s", store, config.StorePostgres, config.StoreSQLite, config.StoreMemory)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/danielboakye/github-repo-stats/pkg/config"
+	"github.com/danielboakye/github-repo-stats/pkg/httpserver"
+	"github.com/danielboakye/github-repo-stats/pkg/services/githubrepo"
+)
+
+const syncUsage = "usage: sync <owner/repo>"
+
+// runSync handles the sync subcommand, progress is written to stderr
+func runSync(ctx context.Context, cfg config.Config, args []string, logger *slog.Logger) error {
+	if len(args) != 1 {
+		return errors.New(syncUsage)
+	}
+	repoName, err := httpserver.NormalizeRepoName(args[0])
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(ctx, cfg.Database, logger)
+	if err != nil {
+		return err
+	}
+	if store.conn != nil {
+		defer store.conn.Close()
+	}
+
+	githubSvc := githubrepo.NewService(store.repo, logger, cfg.Service())
+	start := time.Now()
+	total := 0
+	err = githubSvc.Sync(ctx, repoName, func(p githubrepo.SyncProgress) {
+		total = p.Total
+		fmt.Fprintf(os.Stderr, "%s: page %d, %d commits, %d in total\n", repoName, p.Page, p.Commits, p.Total)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync %s after %d commits: %w", repoName, total, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "synced %d commits of %s in %s\n", total, repoName, time.Since(start).Round(time.Millisecond))
+	return nil
+}